@@ -0,0 +1,200 @@
+package main
+
+import (
+	"client/internal/api"
+	io "client/internal/pretty_io"
+	ws "client/internal/websocket"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var roomNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// session is what the commands of the user act on.
+type session struct {
+	client    *ws.Client
+	apiClient *api.Client
+	formatter *io.Formatter
+}
+
+// command is a slash command handled by the client,
+// the other slash commands are sent to the server, see /help.
+type command struct {
+	usage string
+	help  string
+	// run handles the command, the returned error stops the client.
+	run func(s *session, args []string) error
+}
+
+// commands are the client commands by name, the name starts with '/'.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"/nick": {
+			usage: "/nick <username>",
+			help:  "reconnect with another username",
+			run:   changeNick,
+		},
+		"/join": {
+			usage: "/join <room>",
+			help:  "leave the room and join another one",
+			run:   joinRoom,
+		},
+		"/leave": {
+			usage: "/leave",
+			help:  "leave the room and return to #" + defaultRoom,
+			run:   leaveRoom,
+		},
+		"/me": {
+			usage: "/me <action>",
+			help:  "describe what you are doing, e.g. /me waves",
+			run:   sendAction,
+		},
+		"/help": {
+			usage: "/help",
+			help:  "show the commands of the client and the server",
+			run:   showHelp,
+		},
+		"/quit": {
+			usage: "/quit",
+			help:  "exit the chat",
+			run: func(s *session, _ []string) error {
+				s.formatter.Stop()
+				return nil
+			},
+		},
+		"/react": {
+			usage: "/react <#> <emoji>",
+			help:  "react to the message",
+			run: func(s *session, args []string) error {
+				return sendReaction(s.client, s.formatter, true, args)
+			},
+		},
+		"/unreact": {
+			usage: "/unreact <#> <emoji>",
+			help:  "remove your reaction to the message",
+			run: func(s *session, args []string) error {
+				return sendReaction(s.client, s.formatter, false, args)
+			},
+		},
+		"/reply": {
+			usage: "/reply <#> <message>",
+			help:  "reply to the message",
+			run: func(s *session, args []string) error {
+				return sendReply(s.client, s.formatter, args)
+			},
+		},
+		"/thread": {
+			usage: "/thread <#>",
+			help:  "show the replies to the message",
+			run: func(s *session, args []string) error {
+				return requestThread(s.client, s.formatter, args)
+			},
+		},
+		"/mentions": {
+			usage: "/mentions",
+			help:  "show the last messages mentioning you",
+			run: func(s *session, _ []string) error {
+				return s.client.WriteMentions()
+			},
+		},
+		"/search": {
+			usage: "/search <query>",
+			help:  "search the messages in all rooms",
+			run: func(s *session, args []string) error {
+				search(s.apiClient, s.formatter, args)
+				return nil
+			},
+		},
+	}
+}
+
+// runCommand runs the client command or sends it to the server if the client doesn't know it.
+func runCommand(s *session, name string, args []string) error {
+	cmd, ok := commands[name]
+	if !ok {
+		return s.client.WriteCommand(strings.TrimPrefix(name, "/"), args)
+	}
+	return cmd.run(s, args)
+}
+
+// showHelp prints the client commands and requests the commands available on the server.
+func showHelp(s *session, _ []string) error {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b := strings.Builder{}
+	b.WriteString("── commands ──\n")
+	for _, name := range names {
+		b.WriteString(fmt.Sprintf("  %s - %s\n", commands[name].usage, commands[name].help))
+	}
+	s.formatter.PrintMessage(b.String())
+	return s.client.WriteCommand("help", nil)
+}
+
+// changeNick handles "/nick <username>" command.
+func changeNick(s *session, args []string) error {
+	if len(args) != 1 {
+		s.formatter.PrintMessage("usage: /nick <username>\n")
+		return nil
+	}
+	if !validateUsername(args[0]) {
+		s.formatter.PrintMessage(io.Sanitize(fmt.Sprintf("username '%s' is not valid\n", args[0])))
+		return nil
+	}
+
+	reconnect(s, args[0], s.client.Room())
+	return nil
+}
+
+// joinRoom handles "/join <room>" command.
+func joinRoom(s *session, args []string) error {
+	if len(args) != 1 {
+		s.formatter.PrintMessage("usage: /join <room>\n")
+		return nil
+	}
+	room := strings.TrimPrefix(args[0], "#")
+	if !roomNameRegexp.MatchString(room) {
+		s.formatter.PrintMessage("room name must consist of 1-64 latin letters, digits, '_' or '-'\n")
+		return nil
+	}
+
+	reconnect(s, s.client.Username(), room)
+	return nil
+}
+
+// leaveRoom handles "/leave" command.
+func leaveRoom(s *session, _ []string) error {
+	if s.client.Room() == defaultRoom {
+		s.formatter.PrintMessage(fmt.Sprintf("you cannot leave #%s, use /quit to exit\n", defaultRoom))
+		return nil
+	}
+
+	reconnect(s, s.client.Username(), defaultRoom)
+	return nil
+}
+
+// sendAction handles "/me <action>" command.
+func sendAction(s *session, args []string) error {
+	if len(args) == 0 {
+		s.formatter.PrintMessage("usage: /me <action>\n")
+		return nil
+	}
+	return s.client.WriteMessage(websocket.TextMessage, "/me "+strings.Join(args, " "))
+}
+
+// reconnect connects to the room as the user, the current connection
+// is kept if the server refuses the new one.
+func reconnect(s *session, username string, room string) {
+	err := s.client.Reconnect(username, room)
+	if err != nil {
+		s.formatter.PrintMessage(io.Sanitize(fmt.Sprintf("cannot join #%s as %s: %s\n", room, username, err.Error())))
+	}
+}
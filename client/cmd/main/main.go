@@ -2,38 +2,116 @@ package main
 
 import (
 	"bufio"
+	"client/internal/api"
+	"client/internal/config"
 	io "client/internal/pretty_io"
 	ws "client/internal/websocket"
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"github.com/gorilla/websocket"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/term"
 	"log"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// defaultRoom is the room every user is in, /leave returns to it.
+const defaultRoom = "general"
+
+const (
+	// sendRetries limits the connection attempts and the attempts to send a rate limited message
+	// without the interface, so the scripts fail instead of hanging.
+	sendRetries = 3
+	// sendTimeout limits the wait for the server to broadcast the sent message back or to reject it.
+	sendTimeout = 10 * time.Second
+	// sendBackoff is the pause before the rate limited message is sent again, it doubles after every attempt.
+	sendBackoff = time.Second
+	// rateLimitedText is the text of the error frame the server rejects the frames over the rate limit with.
+	rateLimitedText = "rate limit exceeded, slow down"
+)
+
+// errRateLimited is returned for the messages rejected by the rate limit of the server.
+var errRateLimited = errors.New(rateLimitedText)
+
 var in *bufio.Reader
 
 func main() {
 	in = bufio.NewReader(os.Stdin)
 
-	fmt.Print("Введите имя пользователя: ")
-	username := readLine()
+	cfg, err := config.Get(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.LogFile != "" {
+		f, err := os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		log.SetOutput(f)
+	}
+	endpoint, err := cfg.Endpoint()
+	if err != nil {
+		fatal(err)
+	}
+	if !roomNameRegexp.MatchString(cfg.Room) {
+		fatal(fmt.Errorf("room name '%s' must consist of 1-64 latin letters, digits, '_' or '-'", cfg.Room))
+	}
+	if err = io.SetTheme(cfg.Theme); err != nil {
+		fatal(err)
+	}
 
-	for !validateUsername(username) {
-		fmt.Printf("Имя '%s' невалидно, попробуйте другое имя: ", username)
+	username := cfg.Username
+	switch {
+	case username != "" && !validateUsername(username):
+		fatal(fmt.Errorf("username '%s' is not valid", username))
+	// the username cannot be prompted if stdin is piped
+	case username == "" && (cfg.Send == "-" || !term.IsTerminal(int(os.Stdin.Fd()))):
+		fatal(errors.New("username is required, set it with -username or CHAT_USERNAME"))
+	case username == "":
+		fmt.Print("Введите имя пользователя: ")
 		username = readLine()
+
+		for !validateUsername(username) {
+			fmt.Printf("Имя '%s' невалидно, попробуйте другое имя: ", username)
+			username = readLine()
+		}
 	}
 
-	client := ws.NewClient("localhost:8080", "/api/v1/chat", username)
+	retries := ws.RetryForever
+	if cfg.Send != "" {
+		retries = sendRetries
+	}
+	// moderators and admins pass their token in the config
+	client, err := ws.NewClient(endpoint.Host, endpoint.Path, username, cfg.Room, cfg.Token, endpoint.TLS, retries)
+	if err != nil {
+		fatal(err)
+	}
+	apiClient := api.NewClient(endpoint.Host, endpoint.TLS)
 	defer func() {
 		log.Println("closing the connection")
 		err := client.CloseConnection()
 		log.Println(err)
 	}()
 
-	formatter := io.NewFormatter()
+	if cfg.Send != "" {
+		if err = send(client, cfg.Send); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	formatter := io.NewFormatter(username, cfg.Room)
+	s := &session{client: client, apiClient: apiClient, formatter: formatter}
 
 	eg, ctx := errgroup.WithContext(context.Background())
 	errCh := make(chan error, 1)
@@ -54,7 +132,46 @@ func main() {
 	eg.Go(func() error {
 		go func() {
 			log.Println("start sending messages")
-			errCh <- sendMessages(client, formatter)
+			errCh <- sendMessages(s)
+		}()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errCh:
+			return err
+		}
+	})
+
+	eg.Go(func() error {
+		go func() {
+			errCh <- sendTyping(client, formatter)
+		}()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errCh:
+			return err
+		}
+	})
+
+	eg.Go(func() error {
+		go func() {
+			errCh <- sendRead(client, formatter)
+		}()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errCh:
+			return err
+		}
+	})
+
+	eg.Go(func() error {
+		go func() {
+			errCh <- showContext(apiClient, formatter)
 		}()
 
 		select {
@@ -66,6 +183,8 @@ func main() {
 	})
 
 	eg.Go(func() error {
+		go showStatus(client, formatter)
+
 		err := formatter.Run()
 		if err != nil {
 			return err
@@ -78,6 +197,90 @@ func main() {
 	}
 }
 
+// fatal reports the error on stderr even if the log is written to the file.
+func fatal(err error) {
+	if log.Writer() != os.Stderr {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	log.Fatal(err)
+}
+
+// send sends the message, or the lines of stdin if it is "-", without starting the interface.
+// Every message waits for the server to broadcast it back or to reject it, so the rejected messages fail the send.
+func send(client *ws.Client, message string) error {
+	messages := []string{message}
+	if message == "-" {
+		messages = nil
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				messages = append(messages, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("cannot read messages: %w", err)
+		}
+	}
+
+	replies := readReplies(client)
+	for i, m := range messages {
+		err := sendAndWait(client, replies, m)
+		if err != nil {
+			return fmt.Errorf("%d of %d messages are sent: %w", i, len(messages), err)
+		}
+	}
+	return nil
+}
+
+// sendAndWait sends the message and waits for the reply of the server,
+// the rate limited message is sent again after a pause.
+func sendAndWait(client *ws.Client, replies <-chan error, message string) error {
+	backoff := sendBackoff
+	for attempt := 0; ; attempt++ {
+		err := client.WriteMessage(websocket.TextMessage, message)
+		if err != nil {
+			return fmt.Errorf("error while sending message: %w", err)
+		}
+
+		select {
+		case err = <-replies:
+		case <-time.After(sendTimeout):
+			// the lost connection is restored by the reading goroutine, the send does not wait for it
+			return fmt.Errorf("no reply from the server in %s", sendTimeout)
+		}
+		if !errors.Is(err, errRateLimited) || attempt >= sendRetries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// readReplies reads the frames of the server and passes nil for the messages of the user broadcast back
+// and the errors for the error frames, the history and the messages of the other users are skipped.
+func readReplies(client *ws.Client) <-chan error {
+	replies := make(chan error)
+	go func() {
+		for {
+			_, msg, err := client.ReadMessage()
+			if err != nil {
+				replies <- fmt.Errorf("error while getting reply: %w", err)
+				return
+			}
+
+			switch {
+			case msg.Type == ws.TypeError && msg.Text == rateLimitedText:
+				replies <- errRateLimited
+			case msg.Type == ws.TypeError:
+				replies <- fmt.Errorf("message is rejected: %s", msg.Text)
+			case (msg.Type == ws.TypeMessage || msg.Type == "") && !msg.History && msg.Username == client.Username():
+				replies <- nil
+			}
+		}
+	}()
+	return replies
+}
+
 func validateUsername(s string) bool {
 	return len(s) >= 3
 }
@@ -93,18 +296,65 @@ func readLine() string {
 func getMessages(client *ws.Client, formatter *io.Formatter) error {
 	for {
 		_, msg, err := client.ReadMessage()
+		if errors.Is(err, ws.ErrReconnected) {
+			formatter.SwitchSession(client.Username(), client.Room())
+			formatter.PrintMessage(io.Sanitize(fmt.Sprintf("joined #%s as %s\n", client.Room(), client.Username())))
+			continue
+		}
 		if err != nil {
 			return fmt.Errorf("error while getting message: %w", err)
 		}
-		formatter.PrintMessage(fmt.Sprintf("%s: %s\n", msg.Username, msg.Text))
+
+		switch msg.Type {
+		case ws.TypeTyping:
+			formatter.ShowTyping(msg.Username)
+		case ws.TypeUnread:
+			formatter.SetLastRead(msg.LastRead)
+			printUnread(msg, formatter)
+		case ws.TypeGap:
+			formatter.PrintMessage(io.Sanitize(fmt.Sprintf("── older messages missed in #%s are not shown ──\n", msg.Room)))
+		case ws.TypeReaction:
+			delta := 1
+			if msg.Action == ws.ReactionRemove {
+				delta = -1
+			}
+			formatter.UpdateReaction(msg.ID, msg.Emoji, delta)
+		case ws.TypeThread:
+			formatter.PrintThread(toViewMessages(msg.Messages))
+		case ws.TypeMention:
+			formatter.PrintMention(toViewMessage(msg))
+		case ws.TypeMentions:
+			formatter.PrintMentions(toViewMessages(msg.Messages))
+		case ws.TypeError:
+			printError(msg, formatter)
+		case ws.TypeModeration:
+			printModeration(msg, formatter)
+		case ws.TypeCommand:
+			formatter.PrintMessage(io.Sanitize(fmt.Sprintf("── /%s ──\n%s\n", msg.Command, msg.Text)))
+		case ws.TypeAnnouncement:
+			formatter.PrintMessage(io.Sanitize(fmt.Sprintf("announcement: %s\n", msg.Text)))
+		default:
+			formatter.HideTyping(msg.Username)
+			formatter.AddMessage(toViewMessage(msg))
+		}
 	}
 }
 
-func sendMessages(client *ws.Client, formatter *io.Formatter) error {
-	in := formatter.GetInput()
+func sendMessages(s *session) error {
+	in := s.formatter.GetInput()
 
 	for message := range in {
-		err := client.WriteMessage(websocket.TextMessage, message)
+		var err error
+		if command, args := parseCommand(message); command != "" {
+			err = runCommand(s, command, args)
+		} else {
+			err = s.client.WriteMessage(websocket.TextMessage, message)
+		}
+		// the message is not sent, but the connection may still be restored
+		if errors.Is(err, ws.ErrQueueFull) {
+			s.formatter.PrintMessage(fmt.Sprintf("message is not sent: %s\n", err.Error()))
+			continue
+		}
 		if err != nil {
 			return fmt.Errorf("error while sending message: %w", err)
 		}
@@ -112,3 +362,214 @@ func sendMessages(client *ws.Client, formatter *io.Formatter) error {
 
 	return nil
 }
+
+func sendTyping(client *ws.Client, formatter *io.Formatter) error {
+	typing := formatter.GetTyping()
+
+	for range typing {
+		err := client.WriteTyping()
+		if err != nil {
+			return fmt.Errorf("error while sending typing notification: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func sendRead(client *ws.Client, formatter *io.Formatter) error {
+	read := formatter.GetRead()
+
+	for id := range read {
+		err := client.WriteRead(id)
+		// the next read marker covers this one
+		if errors.Is(err, ws.ErrQueueFull) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error while sending read marker: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// showStatus shows the state of the connection in the header until the client is closed.
+func showStatus(client *ws.Client, formatter *io.Formatter) {
+	for status := range client.Statuses() {
+		formatter.SetStatus(statusText(status), status.State == ws.StateConnected)
+	}
+}
+
+func statusText(status ws.Status) string {
+	if status.State == ws.StateConnected {
+		return "● online"
+	}
+	text := fmt.Sprintf("◌ reconnecting, attempt %d in %s", status.Attempt, status.Retry)
+	if status.Queued > 0 {
+		text += fmt.Sprintf(", %d queued", status.Queued)
+	}
+	return text
+}
+
+// showContext loads the context of the search results selected by the user.
+func showContext(apiClient *api.Client, formatter *io.Formatter) error {
+	jump := formatter.GetJump()
+
+	for id := range jump {
+		messages, err := apiClient.Context(id)
+		if err != nil {
+			formatter.PrintMessage(fmt.Sprintf("cannot load context of the message: %s\n", err.Error()))
+			continue
+		}
+		formatter.ShowContext(id, toViewMessages(messages))
+	}
+
+	return nil
+}
+
+// printError prints the error the server responded with, along with the invalid fields of the frame.
+func printError(msg ws.Message, formatter *io.Formatter) {
+	b := strings.Builder{}
+	b.WriteString(fmt.Sprintf("error: %s\n", msg.Text))
+	for _, e := range msg.Errors {
+		field := strings.TrimPrefix(e.Field, "/")
+		if field == "" {
+			field = "frame"
+		}
+		b.WriteString(fmt.Sprintf("  %s: %s\n", field, e.Error))
+	}
+	formatter.PrintMessage(io.Sanitize(b.String()))
+}
+
+// printModeration prints the moderator action against the user.
+func printModeration(msg ws.Message, formatter *io.Formatter) {
+	b := strings.Builder{}
+	b.WriteString(fmt.Sprintf("%s: %s %s", msg.Moderator, msg.Action, msg.Target))
+	if msg.Duration > 0 {
+		b.WriteString(fmt.Sprintf(" for %s", time.Duration(msg.Duration)*time.Second))
+	}
+	if msg.Reason != "" {
+		b.WriteString(fmt.Sprintf(" (%s)", msg.Reason))
+	}
+	b.WriteString("\n")
+	formatter.PrintMessage(io.Sanitize(b.String()))
+}
+
+// printUnread prints the number of unread messages in the other rooms.
+func printUnread(msg ws.Message, formatter *io.Formatter) {
+	rooms := make([]string, 0, len(msg.Unread))
+	for room, count := range msg.Unread {
+		if room != msg.Room && count > 0 {
+			rooms = append(rooms, fmt.Sprintf("#%s (%d)", room, count))
+		}
+	}
+	if len(rooms) == 0 {
+		return
+	}
+	sort.Strings(rooms)
+	formatter.PrintMessage(fmt.Sprintf("unread messages: %s\n", io.Sanitize(strings.Join(rooms, ", "))))
+}
+
+// parseCommand splits the input into a command and its arguments,
+// an empty command is returned for plain messages.
+func parseCommand(s string) (string, []string) {
+	if !strings.HasPrefix(s, "/") {
+		return "", nil
+	}
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return fields[0], fields[1:]
+}
+
+// sendReaction handles "/react <#> <emoji>" and "/unreact <#> <emoji>" commands.
+func sendReaction(client *ws.Client, formatter *io.Formatter, add bool, args []string) error {
+	if len(args) != 2 {
+		formatter.PrintMessage("usage: /react <#> <emoji>, /unreact <#> <emoji>\n")
+		return nil
+	}
+
+	id, ok := messageID(formatter, args[0])
+	if !ok {
+		return nil
+	}
+	return client.WriteReaction(id, args[1], add)
+}
+
+// sendReply handles "/reply <#> <message>" command.
+func sendReply(client *ws.Client, formatter *io.Formatter, args []string) error {
+	if len(args) < 2 {
+		formatter.PrintMessage("usage: /reply <#> <message>\n")
+		return nil
+	}
+
+	id, ok := messageID(formatter, args[0])
+	if !ok {
+		return nil
+	}
+	return client.WriteReply(strings.Join(args[1:], " "), id)
+}
+
+// requestThread handles "/thread <#>" command.
+func requestThread(client *ws.Client, formatter *io.Formatter, args []string) error {
+	if len(args) != 1 {
+		formatter.PrintMessage("usage: /thread <#>\n")
+		return nil
+	}
+
+	id, ok := messageID(formatter, args[0])
+	if !ok {
+		return nil
+	}
+	return client.WriteThread(id)
+}
+
+// search handles "/search <query>" command, the messages are searched in all rooms.
+func search(apiClient *api.Client, formatter *io.Formatter, args []string) {
+	if len(args) == 0 {
+		formatter.PrintMessage("usage: /search <query>\n")
+		return
+	}
+
+	query := strings.Join(args, " ")
+	messages, err := apiClient.Search(query, "")
+	if err != nil {
+		formatter.PrintMessage(fmt.Sprintf("cannot search messages: %s\n", err.Error()))
+		return
+	}
+	formatter.ShowSearch(query, toViewMessages(messages))
+}
+
+func toViewMessages(messages []ws.Message) []io.Message {
+	res := make([]io.Message, 0, len(messages))
+	for _, m := range messages {
+		res = append(res, toViewMessage(m))
+	}
+	return res
+}
+
+func toViewMessage(msg ws.Message) io.Message {
+	return io.Message{
+		ID:        msg.ID,
+		Username:  msg.Username,
+		Text:      msg.Text,
+		Room:      msg.Room,
+		Reactions: msg.Reactions,
+		ReplyTo:   msg.ReplyTo,
+		Replies:   msg.Replies,
+		History:   msg.History,
+	}
+}
+
+// messageID returns the ID of the message with the reference number, e.g. "#3" or "3".
+func messageID(formatter *io.Formatter, ref string) (int64, bool) {
+	n, err := strconv.Atoi(strings.TrimPrefix(ref, "#"))
+	if err == nil {
+		if id, ok := formatter.MessageID(n); ok {
+			return id, true
+		}
+	}
+	formatter.PrintMessage(fmt.Sprintf("message %s not found\n", ref))
+	return 0, false
+}
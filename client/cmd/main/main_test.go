@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	ws "client/internal/websocket"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newChatServer accepts the connections like the chat server: the history is sent on connect,
+// the message "bad" is rejected, the first message "slow" is rate limited and the others are broadcast back.
+func newChatServer(t *testing.T) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username := r.URL.Query().Get("username")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		_ = conn.WriteJSON(ws.Message{Type: ws.TypeMessage, ID: 1, Username: username, Text: "old", History: true})
+		limited := false
+		for id := int64(2); ; id++ {
+			var msg ws.Message
+			if err = conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			switch {
+			case msg.Text == "bad":
+				_ = conn.WriteJSON(ws.Message{Type: ws.TypeError, Username: "WRONG MESSAGE ERROR", Text: "invalid message"})
+			case msg.Text == "slow" && !limited:
+				limited = true
+				_ = conn.WriteJSON(ws.Message{Type: ws.TypeError, Username: "WRONG MESSAGE ERROR", Text: rateLimitedText})
+			default:
+				_ = conn.WriteJSON(ws.Message{Type: ws.TypeMessage, ID: id, Username: username, Text: msg.Text})
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSend(t *testing.T) {
+	type testcase struct {
+		name    string
+		message string
+		stdin   string
+		err     string
+	}
+
+	tests := []testcase{
+		{name: "message", message: "hello"},
+		{name: "stdin", message: "-", stdin: "hello\n\nslow\nbye\n"},
+		{name: "rejected message", message: "-", stdin: "hello\nbad\nbye\n", err: "1 of 3 messages are sent: message is rejected: invalid message"},
+	}
+
+	server := newChatServer(t)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			in = bufio.NewReader(strings.NewReader(test.stdin))
+			client, err := ws.NewClient(strings.TrimPrefix(server.URL, "http://"), "/api/v1/chat", "danil", "general", "", nil, 0)
+			require.NoError(t, err)
+			defer client.CloseConnection()
+
+			err = send(client, test.message)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	ws "client/internal/websocket"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const requestTimeout = 5 * time.Second
+
+// Client requests the REST API of the chat service.
+type Client struct {
+	host string
+	// scheme is https for the secure connections.
+	scheme string
+	http   *http.Client
+}
+
+type messagesResponse struct {
+	Messages []ws.Message `json:"messages"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewClient creates the client of the API, the requests are secure if tlsConfig is set.
+func NewClient(host string, tlsConfig *tls.Config) *Client {
+	scheme := "http"
+	if tlsConfig != nil {
+		scheme = "https"
+	}
+	return &Client{
+		host:   host,
+		scheme: scheme,
+		http: &http.Client{
+			Timeout:   requestTimeout,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig},
+		},
+	}
+}
+
+// Search returns the messages matching the full-text query in the room,
+// the messages are searched in all rooms if the room is empty.
+func (c *Client) Search(query string, room string) ([]ws.Message, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	if room != "" {
+		params.Set("room", room)
+	}
+	return c.loadMessages("/api/v1/messages/search", params)
+}
+
+// Context returns the message with the given ID surrounded by the messages sent before and after it.
+func (c *Client) Context(id int64) ([]ws.Message, error) {
+	return c.loadMessages(fmt.Sprintf("/api/v1/messages/%d/context", id), nil)
+}
+
+func (c *Client) loadMessages(path string, params url.Values) ([]ws.Message, error) {
+	u := url.URL{Scheme: c.scheme, Host: c.host, Path: path, RawQuery: params.Encode()}
+	resp, err := c.http.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		e := errorResponse{}
+		if json.NewDecoder(resp.Body).Decode(&e) != nil || e.Error == "" {
+			e.Error = resp.Status
+		}
+		return nil, errors.New(e.Error)
+	}
+
+	res := messagesResponse{}
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return nil, err
+	}
+	return res.Messages, nil
+}
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/pelletier/go-toml/v2"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	defaultServer = "ws://localhost:8080/api/v1/chat"
+	defaultRoom   = "general"
+	defaultTheme  = "dark"
+)
+
+// Config is the client configuration. The values are taken from the flags, then from the environment,
+// then from the config file, ~/.config/websocket-chat/config.toml by default.
+type Config struct {
+	// Server is the URL of the chat, ws:// and http:// or wss:// and https:// for TLS,
+	// the path defaults to /api/v1/chat.
+	Server string `toml:"server"`
+	// TLS connects with TLS even if the server URL is ws:// or http://.
+	TLS bool `toml:"tls"`
+	// CAFile is the PEM file with the certificates the server certificate is verified with,
+	// the system certificates are used if it is empty.
+	CAFile string `toml:"ca_file"`
+
+	Username string `toml:"username"`
+	// Token is required for the usernames of moderators and admins.
+	Token string `toml:"token"`
+	Room  string `toml:"room"`
+
+	// Theme is the color theme of the interface: dark, light or mono.
+	Theme string `toml:"theme"`
+	// LogFile is the file the client logs to, the log is written to stderr if it is empty.
+	LogFile string `toml:"log_file"`
+
+	// Send is the message sent without starting the interface, "-" sends the lines of stdin.
+	Send string `toml:"-"`
+}
+
+// Endpoint is the resolved address of the chat.
+type Endpoint struct {
+	Host string
+	Path string
+	// TLS is nil for the plain connections.
+	TLS *tls.Config
+}
+
+// Get parses the config from the command line arguments, the environment and the config file.
+func Get(args []string) (Config, error) {
+	cfg := Config{Server: defaultServer, Room: defaultRoom, Theme: defaultTheme}
+
+	// the usage and the flag errors are printed by the flag set
+	fs := flag.NewFlagSet("client", flag.ContinueOnError)
+	path := fs.String("config", "", "config file, CHAT_CONFIG (default ~/.config/websocket-chat/config.toml)")
+	flags := Config{}
+	fs.StringVar(&flags.Server, "server", "", "chat URL, CHAT_SERVER (default "+defaultServer+")")
+	fs.BoolVar(&flags.TLS, "tls", false, "connect with TLS, CHAT_TLS")
+	fs.StringVar(&flags.CAFile, "ca-file", "", "PEM file with the CA certificates of the server, CHAT_CA_FILE")
+	fs.StringVar(&flags.Username, "username", "", "username, CHAT_USERNAME (prompted if empty)")
+	fs.StringVar(&flags.Token, "token", "", "token of moderators and admins, CHAT_TOKEN")
+	fs.StringVar(&flags.Room, "room", "", "room to join, CHAT_ROOM (default "+defaultRoom+")")
+	fs.StringVar(&flags.Theme, "theme", "", "color theme: dark, light or mono, CHAT_THEME (default "+defaultTheme+")")
+	fs.StringVar(&flags.LogFile, "log-file", "", "file to write the log to, CHAT_LOG_FILE (default stderr)")
+	fs.StringVar(&flags.Send, "send", "", `send the message and exit, "-" sends the lines of stdin`)
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if fs.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected argument '%s'", fs.Arg(0))
+	}
+
+	if err := loadFile(&cfg, *path); err != nil {
+		return Config{}, err
+	}
+	if err := loadEnv(&cfg); err != nil {
+		return Config{}, err
+	}
+
+	// only the flags set by the user override the other sources
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "server":
+			cfg.Server = flags.Server
+		case "tls":
+			cfg.TLS = flags.TLS
+		case "ca-file":
+			cfg.CAFile = flags.CAFile
+		case "username":
+			cfg.Username = flags.Username
+		case "token":
+			cfg.Token = flags.Token
+		case "room":
+			cfg.Room = flags.Room
+		case "theme":
+			cfg.Theme = flags.Theme
+		case "log-file":
+			cfg.LogFile = flags.LogFile
+		case "send":
+			cfg.Send = flags.Send
+		}
+	})
+	return cfg, nil
+}
+
+// loadFile reads the config file, the file at the default path is optional.
+func loadFile(cfg *Config, path string) error {
+	if path == "" {
+		path = os.Getenv("CHAT_CONFIG")
+	}
+	optional := path == ""
+	if optional {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil
+		}
+		path = filepath.Join(dir, "websocket-chat", "config.toml")
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && optional {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot read config file: %w", err)
+	}
+
+	decoder := toml.NewDecoder(bytes.NewReader(data)).DisallowUnknownFields()
+	err = decoder.Decode(cfg)
+	var strict *toml.StrictMissingError
+	if errors.As(err, &strict) {
+		return fmt.Errorf("%s: unknown keys:\n%s", path, strict.String())
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func loadEnv(cfg *Config) error {
+	for name, value := range map[string]*string{
+		"CHAT_SERVER":   &cfg.Server,
+		"CHAT_CA_FILE":  &cfg.CAFile,
+		"CHAT_USERNAME": &cfg.Username,
+		"CHAT_TOKEN":    &cfg.Token,
+		"CHAT_ROOM":     &cfg.Room,
+		"CHAT_THEME":    &cfg.Theme,
+		"CHAT_LOG_FILE": &cfg.LogFile,
+	} {
+		if s, ok := os.LookupEnv(name); ok {
+			*value = s
+		}
+	}
+
+	if s, ok := os.LookupEnv("CHAT_TLS"); ok {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%w: variable 'CHAT_TLS' must be bool", err)
+		}
+		cfg.TLS = v
+	}
+	return nil
+}
+
+// Endpoint resolves the server URL, the secure schemes enable TLS.
+func (c Config) Endpoint() (Endpoint, error) {
+	u, err := url.Parse(c.Server)
+	if err != nil {
+		return Endpoint{}, fmt.Errorf("invalid server URL: %w", err)
+	}
+	if u.Host == "" {
+		return Endpoint{}, fmt.Errorf("invalid server URL '%s': host is missing", c.Server)
+	}
+
+	secure := c.TLS
+	switch u.Scheme {
+	case "ws", "http":
+	case "wss", "https":
+		secure = true
+	default:
+		return Endpoint{}, fmt.Errorf("invalid server URL '%s': scheme must be ws, wss, http or https", c.Server)
+	}
+
+	e := Endpoint{Host: u.Host, Path: u.Path}
+	if e.Path == "" || e.Path == "/" {
+		e.Path = "/api/v1/chat"
+	}
+	if !secure {
+		return e, nil
+	}
+
+	e.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return Endpoint{}, fmt.Errorf("cannot read CA file: %w", err)
+		}
+		e.TLS.RootCAs = x509.NewCertPool()
+		if !e.TLS.RootCAs.AppendCertsFromPEM(pem) {
+			return Endpoint{}, fmt.Errorf("no certificates in CA file '%s'", c.CAFile)
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// envNames are the variables read by Get.
+var envNames = []string{
+	"CHAT_CONFIG", "CHAT_SERVER", "CHAT_TLS", "CHAT_CA_FILE", "CHAT_USERNAME",
+	"CHAT_TOKEN", "CHAT_ROOM", "CHAT_THEME", "CHAT_LOG_FILE",
+}
+
+// isolate unsets the variables of the client and moves the user config dir to a temporary one,
+// the returned dir is the one the default config file is read from.
+func isolate(t *testing.T) string {
+	for _, name := range envNames {
+		t.Setenv(name, "")
+		require.NoError(t, os.Unsetenv(name))
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	return filepath.Join(home, ".config", "websocket-chat")
+}
+
+func TestGet(t *testing.T) {
+	type testcase struct {
+		name string
+		// {dir} in the args and the env is replaced with the dir of the file
+		args []string
+		env  map[string]string
+		// file is written to {dir}/config.toml, defaultFile writes it to the default path instead
+		file        string
+		defaultFile bool
+		expected    Config
+		err         string
+	}
+
+	defaults := Config{Server: defaultServer, Room: defaultRoom, Theme: defaultTheme}
+
+	tests := []testcase{
+		{
+			name:     "defaults without default file",
+			expected: defaults,
+		},
+		{
+			name:        "default file",
+			file:        "server = \"wss://chat.example.com\"\nusername = \"danil\"\n",
+			defaultFile: true,
+			expected:    Config{Server: "wss://chat.example.com", Username: "danil", Room: defaultRoom, Theme: defaultTheme},
+		},
+		{
+			name: "flags override env and env overrides file",
+			args: []string{"-config", "{dir}/config.toml", "-username", "flag", "-send", "hello"},
+			env:  map[string]string{"CHAT_USERNAME": "env", "CHAT_ROOM": "env", "CHAT_TLS": "true"},
+			file: "server = \"ws://file:8080\"\nusername = \"file\"\nroom = \"file\"\ntheme = \"light\"\n",
+			expected: Config{
+				Server:   "ws://file:8080",
+				TLS:      true,
+				Username: "flag",
+				Room:     "env",
+				Theme:    "light",
+				Send:     "hello",
+			},
+		},
+		{
+			name:     "flag set to zero value",
+			args:     []string{"-tls=false", "-room", ""},
+			env:      map[string]string{"CHAT_TLS": "true", "CHAT_ROOM": "random"},
+			expected: Config{Server: defaultServer, Theme: defaultTheme},
+		},
+		{
+			name:     "file from env",
+			env:      map[string]string{"CHAT_CONFIG": "{dir}/config.toml"},
+			file:     "token = \"secret\"\nlog_file = \"chat.log\"\n",
+			expected: Config{Server: defaultServer, Token: "secret", Room: defaultRoom, Theme: defaultTheme, LogFile: "chat.log"},
+		},
+		{
+			name: "missing explicit file",
+			args: []string{"-config", "{dir}/missing.toml"},
+			err:  "cannot read config file",
+		},
+		{
+			name: "missing file from env",
+			env:  map[string]string{"CHAT_CONFIG": "{dir}/missing.toml"},
+			err:  "cannot read config file",
+		},
+		{
+			name:        "unknown key",
+			file:        "username = \"danil\"\ncolour = \"red\"\n",
+			defaultFile: true,
+			err:         "unknown keys",
+		},
+		{
+			name:        "invalid file",
+			file:        "username = ",
+			defaultFile: true,
+			err:         "config.toml",
+		},
+		{
+			name: "invalid bool variable",
+			env:  map[string]string{"CHAT_TLS": "maybe"},
+			err:  "CHAT_TLS",
+		},
+		{
+			name: "unknown flag",
+			args: []string{"-colour", "red"},
+			err:  "flag provided but not defined",
+		},
+		{
+			name: "unexpected argument",
+			args: []string{"-username", "danil", "hello"},
+			err:  "unexpected argument 'hello'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := isolate(t)
+			if !test.defaultFile {
+				dir = t.TempDir()
+			}
+			if test.file != "" {
+				require.NoError(t, os.MkdirAll(dir, 0o700))
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "config.toml"), []byte(test.file), 0o600))
+			}
+			for name, value := range test.env {
+				t.Setenv(name, strings.ReplaceAll(value, "{dir}", dir))
+			}
+			args := make([]string, 0, len(test.args))
+			for _, arg := range test.args {
+				args = append(args, strings.ReplaceAll(arg, "{dir}", dir))
+			}
+
+			cfg, err := Get(args)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, cfg)
+		})
+	}
+}
+
+func TestConfig_Endpoint(t *testing.T) {
+	type testcase struct {
+		name string
+		cfg  Config
+		host string
+		path string
+		// secure is set if the endpoint has the TLS config, ca if it has the CA certificates
+		secure bool
+		ca     bool
+		err    string
+	}
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	writeCA(t, caFile)
+	badCAFile := filepath.Join(dir, "bad.pem")
+	require.NoError(t, os.WriteFile(badCAFile, []byte("not a certificate"), 0o600))
+
+	tests := []testcase{
+		{name: "ws", cfg: Config{Server: "ws://localhost:8080/api/v1/chat"}, host: "localhost:8080", path: "/api/v1/chat"},
+		{name: "http", cfg: Config{Server: "http://localhost:8080/chat"}, host: "localhost:8080", path: "/chat"},
+		{name: "default path", cfg: Config{Server: "ws://localhost:8080"}, host: "localhost:8080", path: "/api/v1/chat"},
+		{name: "root path", cfg: Config{Server: "ws://localhost:8080/"}, host: "localhost:8080", path: "/api/v1/chat"},
+		{name: "wss", cfg: Config{Server: "wss://chat.example.com"}, host: "chat.example.com", path: "/api/v1/chat", secure: true},
+		{name: "https", cfg: Config{Server: "https://chat.example.com:8443/api/v1/chat"}, host: "chat.example.com:8443", path: "/api/v1/chat", secure: true},
+		{name: "tls flag", cfg: Config{Server: "ws://localhost:8080", TLS: true}, host: "localhost:8080", path: "/api/v1/chat", secure: true},
+		{
+			name:   "ca file",
+			cfg:    Config{Server: "wss://localhost:8443", CAFile: caFile},
+			host:   "localhost:8443",
+			path:   "/api/v1/chat",
+			secure: true,
+			ca:     true,
+		},
+		// the CA file is not used for the plain connections
+		{name: "ca file without tls", cfg: Config{Server: "ws://localhost:8080", CAFile: badCAFile}, host: "localhost:8080", path: "/api/v1/chat"},
+		{name: "missing ca file", cfg: Config{Server: "wss://localhost:8443", CAFile: filepath.Join(dir, "missing.pem")}, err: "cannot read CA file"},
+		{name: "bad ca file", cfg: Config{Server: "wss://localhost:8443", CAFile: badCAFile}, err: "no certificates in CA file"},
+		{name: "unknown scheme", cfg: Config{Server: "ftp://localhost:8080"}, err: "scheme must be ws, wss, http or https"},
+		{name: "missing host", cfg: Config{Server: "localhost:8080"}, err: "host is missing"},
+		{name: "invalid url", cfg: Config{Server: "ws://local host:8080"}, err: "invalid server URL"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := test.cfg.Endpoint()
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.host, e.Host)
+			assert.Equal(t, test.path, e.Path)
+			assert.Equal(t, test.secure, e.TLS != nil)
+			if e.TLS != nil {
+				assert.Equal(t, test.ca, e.TLS.RootCAs != nil)
+			}
+		})
+	}
+}
+
+// writeCA writes the certificate of a test TLS server to the file.
+func writeCA(t *testing.T, path string) {
+	srv := httptest.NewTLSServer(nil)
+	defer srv.Close()
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
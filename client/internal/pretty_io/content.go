@@ -0,0 +1,209 @@
+package pretty_io
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	dividerStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	refStyle      = lipgloss.NewStyle().Faint(true)
+	reactionStyle = lipgloss.NewStyle().Faint(true).PaddingLeft(4)
+	quoteStyle    = lipgloss.NewStyle().Faint(true).Italic(true).PaddingLeft(2)
+	mentionStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("11"))
+)
+
+// quoteLength is the maximal number of characters of the quoted parent message.
+const quoteLength = 40
+
+// actionPrefix starts the messages sent with /me, they are shown as "* username text".
+const actionPrefix = "/me "
+
+// Message is a chat message shown by the formatter.
+type Message struct {
+	ID        int64
+	Username  string
+	Text      string
+	Room      string
+	Reactions map[string]int
+	// ReplyTo is the ID of the parent message, Replies is the number of replies to the message.
+	ReplyTo int64
+	Replies int
+	// History is set for the messages loaded on connect, they are already counted in Replies.
+	History bool
+}
+
+// entry is a single message or system line shown in the viewport.
+type entry struct {
+	// ref is the number the user refers to the message by in commands,
+	// system lines have no reference number.
+	ref  int
+	msg  Message
+	text string
+	// line is the first line of the entry in the rendered content.
+	line int
+}
+
+// render renders the entry, mentions of the username are highlighted.
+func (e *entry) render(parent *entry, username string) string {
+	if e.ref == 0 {
+		return e.text
+	}
+
+	b := strings.Builder{}
+	if e.msg.ReplyTo != 0 {
+		b.WriteString(quoteStyle.Render(quote(parent)))
+		b.WriteString("\n")
+	}
+	ref := refStyle.Render(fmt.Sprintf("#%d", e.ref))
+	if action, ok := strings.CutPrefix(e.msg.Text, actionPrefix); ok {
+		b.WriteString(fmt.Sprintf("%s * %s %s\n", ref, e.msg.Username, highlightMentions(action, username)))
+	} else {
+		b.WriteString(fmt.Sprintf("%s %s: %s\n", ref, e.msg.Username, highlightMentions(e.msg.Text, username)))
+	}
+
+	details := renderReactions(e.msg.Reactions)
+	if e.msg.Replies > 0 {
+		details = strings.TrimSpace(fmt.Sprintf("%s  💬 %d", details, e.msg.Replies))
+	}
+	if details != "" {
+		b.WriteString(reactionStyle.Render(details))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// quote returns the line quoting the parent of a reply.
+func quote(parent *entry) string {
+	if parent == nil {
+		return "↪ reply to a message not shown"
+	}
+
+	text := []rune(parent.msg.Text)
+	if len(text) > quoteLength {
+		text = append(text[:quoteLength], '…')
+	}
+	return fmt.Sprintf("↪ #%d %s: %s", parent.ref, parent.msg.Username, string(text))
+}
+
+// highlightMentions highlights "@username" in the text, mentions of
+// the other users starting with the username are left as is.
+func highlightMentions(text string, username string) string {
+	if username == "" {
+		return text
+	}
+
+	re := regexp.MustCompile(`@` + regexp.QuoteMeta(username) + `([^\p{L}\p{N}_-]|$)`)
+	return re.ReplaceAllStringFunc(text, func(s string) string {
+		rest := strings.TrimPrefix(s, "@"+username)
+		return mentionStyle.Render("@"+username) + rest
+	})
+}
+
+func renderReactions(reactions map[string]int) string {
+	emojis := make([]string, 0, len(reactions))
+	for emoji, count := range reactions {
+		if count > 0 {
+			emojis = append(emojis, emoji)
+		}
+	}
+	sort.Strings(emojis)
+
+	parts := make([]string, 0, len(emojis))
+	for _, emoji := range emojis {
+		parts = append(parts, fmt.Sprintf("%s %d", emoji, reactions[emoji]))
+	}
+	return strings.Join(parts, "  ")
+}
+
+// renderContent renders the entries into the viewport content.
+func (m *model) renderContent() {
+	b := strings.Builder{}
+	line := 0
+	divided := false
+	byID := make(map[int64]*entry, len(m.entries))
+	for i := range m.entries {
+		e := &m.entries[i]
+		if e.ref != 0 {
+			byID[e.msg.ID] = e
+		}
+		if m.lastRead > 0 && !divided && e.msg.ID > m.lastRead {
+			b.WriteString(dividerStyle.Render("── new messages ──"))
+			b.WriteString("\n")
+			line++
+			divided = true
+		}
+
+		e.line = line
+		text := e.render(byID[e.msg.ReplyTo], m.username)
+		b.WriteString(text)
+		line += strings.Count(text, "\n")
+	}
+
+	m.content = b.String()
+	m.viewport.SetContent(m.content)
+}
+
+// addEntry shows the new entry, live replies increase the number of replies to the parent.
+func (m *model) addEntry(e entry) {
+	m.entries = append(m.entries, e)
+	if e.ref != 0 && e.msg.ReplyTo != 0 && !e.msg.History {
+		if parent := m.findEntry(e.msg.ReplyTo); parent != nil {
+			parent.msg.Replies++
+		}
+	}
+	m.renderContent()
+}
+
+func (m *model) findEntry(id int64) *entry {
+	for i := len(m.entries) - 1; i >= 0; i-- {
+		if m.entries[i].ref != 0 && m.entries[i].msg.ID == id {
+			return &m.entries[i]
+		}
+	}
+	return nil
+}
+
+// updateReaction changes the count of the emoji under the message.
+func (m *model) updateReaction(id int64, emoji string, delta int) {
+	e := m.findEntry(id)
+	if e == nil {
+		return
+	}
+
+	if e.msg.Reactions == nil {
+		e.msg.Reactions = make(map[string]int)
+	}
+	e.msg.Reactions[emoji] = max(0, e.msg.Reactions[emoji]+delta)
+	m.renderContent()
+}
+
+// notifyRead reports the last message scrolled into view if it has not been reported yet.
+func (m *model) notifyRead() {
+	if !m.ready {
+		return
+	}
+
+	bottom := m.viewport.YOffset + m.viewport.Height
+	last := int64(0)
+	for _, e := range m.entries {
+		if e.line >= bottom {
+			break
+		}
+		last = max(last, e.msg.ID)
+	}
+	if last <= m.readSent {
+		return
+	}
+	m.readSent = last
+
+	// keep only the latest ID if the previous one has not been sent yet
+	select {
+	case <-m.read:
+	default:
+	}
+	m.read <- last
+}
@@ -1,22 +1,147 @@
 package pretty_io
 
 import (
+	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
+	"strings"
+	"sync"
 )
 
 type Formatter struct {
 	m *model
 	p *tea.Program
+
+	mx sync.Mutex
+	// refs are IDs of the shown messages, the reference number of a message is its index + 1.
+	refs []int64
 }
 
 func (f *Formatter) PrintMessage(msg string) {
 	f.p.Send(newMsg{text: msg})
 }
 
+// AddMessage shows the chat message with a reference number the user can refer to it by.
+func (f *Formatter) AddMessage(msg Message) {
+	msg = msg.sanitized()
+	if msg.ID == 0 {
+		f.PrintMessage(fmt.Sprintf("%s: %s\n", msg.Username, msg.Text))
+		return
+	}
+
+	f.mx.Lock()
+	f.refs = append(f.refs, msg.ID)
+	ref := len(f.refs)
+	f.mx.Unlock()
+
+	f.p.Send(newMsg{ref: ref, msg: msg})
+}
+
+// MessageID returns the ID of the message shown with the reference number.
+func (f *Formatter) MessageID(ref int) (int64, bool) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if ref < 1 || ref > len(f.refs) {
+		return 0, false
+	}
+	return f.refs[ref-1], true
+}
+
+// PrintThread shows the message with the replies to it.
+func (f *Formatter) PrintThread(messages []Message) {
+	if len(messages) == 0 {
+		return
+	}
+	messages = sanitizeMessages(messages)
+
+	b := strings.Builder{}
+	b.WriteString(fmt.Sprintf("── thread of %s: %s ──\n", messages[0].Username, messages[0].Text))
+	for _, msg := range messages[1:] {
+		b.WriteString(fmt.Sprintf("  ↪ %s: %s\n", msg.Username, msg.Text))
+	}
+	if len(messages) == 1 {
+		b.WriteString("  no replies yet\n")
+	}
+	b.WriteString("──\n")
+	f.PrintMessage(b.String())
+}
+
+// PrintMention shows the notification about the message mentioning the user in another room.
+func (f *Formatter) PrintMention(msg Message) {
+	msg = msg.sanitized()
+	f.PrintMessage(mentionStyle.Render(fmt.Sprintf("🔔 %s mentioned you in #%s: %s", msg.Username, msg.Room, msg.Text)) + "\n")
+}
+
+// PrintMentions shows the last messages mentioning the user.
+func (f *Formatter) PrintMentions(messages []Message) {
+	messages = sanitizeMessages(messages)
+	b := strings.Builder{}
+	b.WriteString("── mentions ──\n")
+	for _, msg := range messages {
+		b.WriteString(fmt.Sprintf("  #%s %s: %s\n", msg.Room, msg.Username, msg.Text))
+	}
+	if len(messages) == 0 {
+		b.WriteString("  no mentions yet\n")
+	}
+	b.WriteString("──\n")
+	f.PrintMessage(b.String())
+}
+
+// ShowSearch shows the search results in the overlay, the user can select
+// a result to see its context, see GetJump.
+func (f *Formatter) ShowSearch(query string, messages []Message) {
+	f.p.Send(searchMsg{query: sanitizeLine(query), messages: sanitizeMessages(messages)})
+}
+
+// ShowContext shows the messages around the selected search result in the overlay.
+func (f *Formatter) ShowContext(id int64, messages []Message) {
+	f.p.Send(contextMsg{id: id, messages: sanitizeMessages(messages)})
+}
+
+// GetJump returns the channel with IDs of the search results the user wants to see the context of.
+func (f *Formatter) GetJump() <-chan int64 {
+	return f.m.jump
+}
+
+// UpdateReaction changes the count of the emoji reaction under the message.
+func (f *Formatter) UpdateReaction(id int64, emoji string, delta int) {
+	f.p.Send(reactionMsg{id: id, emoji: sanitizeLine(emoji), delta: delta})
+}
+
+// SetLastRead places the "new messages" divider after the message.
+func (f *Formatter) SetLastRead(id int64) {
+	f.p.Send(lastReadMsg{id: id})
+}
+
+// GetRead returns the channel with IDs of the messages scrolled into view.
+func (f *Formatter) GetRead() <-chan int64 {
+	return f.m.read
+}
+
 func (f *Formatter) GetInput() <-chan string {
 	return f.m.input
 }
 
+// GetTyping returns the channel that receives a value each time the user types,
+// the values are throttled by the model so they can be sent to the server as is.
+func (f *Formatter) GetTyping() <-chan struct{} {
+	return f.m.typing
+}
+
+// ShowTyping shows in the footer that the user is typing.
+func (f *Formatter) ShowTyping(username string) {
+	f.p.Send(typingMsg{username: sanitizeLine(username)})
+}
+
+// HideTyping removes the user from the footer typing indicator.
+func (f *Formatter) HideTyping(username string) {
+	f.p.Send(typingDoneMsg{username: sanitizeLine(username)})
+}
+
+// SetStatus shows the state of the connection in the header.
+func (f *Formatter) SetStatus(text string, online bool) {
+	f.p.Send(statusMsg{text: sanitizeLine(text), online: online})
+}
+
 func (f *Formatter) Run() error {
 	_, err := f.p.Run()
 	return err
@@ -26,8 +151,19 @@ func (f *Formatter) Stop() {
 	f.p.Quit()
 }
 
-func NewFormatter() *Formatter {
-	m := initialModel()
+// SwitchSession clears the shown messages after the client has reconnected as the user to the room,
+// the reference numbers start over.
+func (f *Formatter) SwitchSession(username string, room string) {
+	f.mx.Lock()
+	f.refs = nil
+	f.mx.Unlock()
+
+	f.p.Send(sessionMsg{username: sanitizeLine(username), room: sanitizeLine(room)})
+}
+
+// NewFormatter creates the formatter for the user in the room, mentions of the user are highlighted.
+func NewFormatter(username string, room string) *Formatter {
+	m := initialModel(username, room)
 	p := tea.NewProgram(
 		m,
 		tea.WithAltScreen(),       // use the full size of the terminal in its "alternate screen buffer"
@@ -0,0 +1,148 @@
+package pretty_io
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"strings"
+)
+
+var (
+	overlayTitleStyle = lipgloss.NewStyle().Bold(true)
+	overlayHelpStyle  = lipgloss.NewStyle().Faint(true)
+	selectedStyle     = lipgloss.NewStyle().Reverse(true)
+	focusStyle        = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("11"))
+)
+
+// overlay shows the search results or the context of a found message over the chat.
+type overlay struct {
+	title    string
+	messages []Message
+	// selected is the index of the selected search result, context overlays have no selection.
+	selected int
+	// focus is the ID of the message the context is shown for.
+	focus int64
+	// results is the search overlay the context has been opened from.
+	results *overlay
+
+	viewport viewport.Model
+}
+
+func newSearchOverlay(query string, messages []Message, width int, height int) *overlay {
+	o := &overlay{
+		title:    fmt.Sprintf("search \"%s\": %d found", query, len(messages)),
+		messages: messages,
+		viewport: viewport.New(width, height),
+	}
+	o.render()
+	return o
+}
+
+func newContextOverlay(id int64, messages []Message, results *overlay) *overlay {
+	o := &overlay{
+		title:    "context",
+		messages: messages,
+		selected: -1,
+		focus:    id,
+		results:  results,
+		viewport: viewport.New(results.viewport.Width, results.viewport.Height),
+	}
+	o.render()
+	for i, msg := range messages {
+		if msg.ID == id {
+			o.scrollTo(i)
+		}
+	}
+	return o
+}
+
+func (o *overlay) render() {
+	b := strings.Builder{}
+	b.WriteString(overlayTitleStyle.Render(o.title))
+	b.WriteString("\n")
+	if o.results == nil {
+		b.WriteString(overlayHelpStyle.Render("↑/↓ select, enter show context, esc close"))
+	} else {
+		b.WriteString(overlayHelpStyle.Render("↑/↓ scroll, esc back to results"))
+	}
+	b.WriteString("\n")
+
+	for i, msg := range o.messages {
+		line := fmt.Sprintf("#%s %s: %s", msg.Room, msg.Username, strings.ReplaceAll(msg.Text, "\n", " "))
+		switch {
+		case i == o.selected:
+			line = selectedStyle.Render(line)
+		case msg.ID == o.focus:
+			line = focusStyle.Render(line)
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	if len(o.messages) == 0 {
+		b.WriteString("nothing found\n")
+	}
+	o.viewport.SetContent(b.String())
+}
+
+// scrollTo scrolls the viewport to show the message with index i,
+// the first two lines of the content are the title and the help.
+func (o *overlay) scrollTo(i int) {
+	line := i + 2
+	switch {
+	case line < o.viewport.YOffset+2:
+		o.viewport.SetYOffset(max(0, line-2))
+	case line >= o.viewport.YOffset+o.viewport.Height:
+		o.viewport.SetYOffset(line - o.viewport.Height + 1)
+	}
+}
+
+// selectedID returns the ID of the selected search result.
+func (o *overlay) selectedID() (int64, bool) {
+	if o.selected < 0 || o.selected >= len(o.messages) {
+		return 0, false
+	}
+	return o.messages[o.selected].ID, true
+}
+
+// updateOverlay handles the keys pressed while the overlay is shown.
+func (m *model) updateOverlay(msg tea.KeyMsg) tea.Cmd {
+	o := m.overlay
+	switch {
+	case msg.Type == tea.KeyEsc:
+		m.overlay = o.results
+		return nil
+	case msg.Type == tea.KeyEnter && o.results == nil:
+		if id, ok := o.selectedID(); ok {
+			select {
+			case m.jump <- id:
+			default:
+			}
+		}
+		return nil
+	case msg.Type == tea.KeyUp && o.results == nil:
+		o.selected = max(0, o.selected-1)
+		o.render()
+		o.scrollTo(o.selected)
+		return nil
+	case msg.Type == tea.KeyDown && o.results == nil:
+		o.selected = max(0, min(len(o.messages)-1, o.selected+1))
+		o.render()
+		o.scrollTo(o.selected)
+		return nil
+	}
+
+	var cmd tea.Cmd
+	o.viewport, cmd = o.viewport.Update(msg)
+	return cmd
+}
+
+type searchMsg struct {
+	query    string
+	messages []Message
+}
+
+type contextMsg struct {
+	id       int64
+	messages []Message
+}
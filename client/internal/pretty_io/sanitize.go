@@ -0,0 +1,62 @@
+package pretty_io
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// escapeRegexp matches terminal escape sequences: CSI (e.g. colors and cursor movement),
+// OSC (e.g. window title and hyperlinks) and the other two-character sequences.
+var escapeRegexp = regexp.MustCompile(`\x1b(?:\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)?|[@-Z\\-_])`)
+
+// Sanitize makes the text received from the server safe to show in the terminal:
+// escape sequences and control characters except new lines are removed, tabs are replaced with spaces.
+func Sanitize(s string) string {
+	s = strings.ToValidUTF8(s, string(unicode.ReplacementChar))
+	s = escapeRegexp.ReplaceAllString(s, "")
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n':
+			return r
+		case r == '\t':
+			return ' '
+		case unicode.IsControl(r), isBidiControl(r):
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// sanitizeLine sanitizes the text shown in a single line, e.g. usernames.
+func sanitizeLine(s string) string {
+	return strings.ReplaceAll(Sanitize(s), "\n", " ")
+}
+
+// isBidiControl reports whether the rune changes the direction of the text.
+func isBidiControl(r rune) bool {
+	return (r >= '\u202a' && r <= '\u202e') || (r >= '\u2066' && r <= '\u2069') || r == '\u200e' || r == '\u200f'
+}
+
+// sanitized returns the copy of the message safe to show in the terminal.
+func (msg Message) sanitized() Message {
+	msg.Username = sanitizeLine(msg.Username)
+	msg.Text = Sanitize(msg.Text)
+	msg.Room = sanitizeLine(msg.Room)
+	if msg.Reactions != nil {
+		reactions := make(map[string]int, len(msg.Reactions))
+		for emoji, count := range msg.Reactions {
+			reactions[sanitizeLine(emoji)] += count
+		}
+		msg.Reactions = reactions
+	}
+	return msg
+}
+
+func sanitizeMessages(messages []Message) []Message {
+	res := make([]Message, 0, len(messages))
+	for _, msg := range messages {
+		res = append(res, msg.sanitized())
+	}
+	return res
+}
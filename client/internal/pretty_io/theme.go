@@ -0,0 +1,36 @@
+package pretty_io
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// themes change the colored styles, the dark theme is the default one.
+var themes = map[string]func(){
+	"dark": func() {
+		dividerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+		mentionStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("11"))
+		focusStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("11"))
+	},
+	// the bright colors of the dark theme are hard to read on the light background
+	"light": func() {
+		dividerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+		mentionStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("4"))
+		focusStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("4"))
+	},
+	"mono": func() {
+		dividerStyle = lipgloss.NewStyle().Bold(true)
+		mentionStyle = lipgloss.NewStyle().Bold(true).Underline(true)
+		focusStyle = lipgloss.NewStyle().Bold(true).Underline(true)
+	},
+}
+
+// SetTheme sets the color theme: dark, light or mono. It must be called before the formatter is run.
+func SetTheme(name string) error {
+	apply, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("unknown theme '%s', use dark, light or mono", name)
+	}
+	apply()
+	return nil
+}
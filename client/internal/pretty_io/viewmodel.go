@@ -6,10 +6,19 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"sort"
 	"strings"
+	"time"
 )
 
-const useHighPerformanceRenderer = false
+const (
+	useHighPerformanceRenderer = false
+
+	// typingThrottle is the minimal interval between two typing notifications of the user.
+	typingThrottle = 2 * time.Second
+	// typingTimeout is how long the typing indicator is shown after the last notification.
+	typingTimeout = 4 * time.Second
+)
 
 var (
 	titleStyle = func() lipgloss.Style {
@@ -23,18 +32,47 @@ var (
 		b.Left = "┤"
 		return titleStyle.Copy().BorderStyle(b)
 	}()
+
+	typingStyle = lipgloss.NewStyle().Faint(true).Italic(true).Padding(0, 1)
+
+	offlineStyle = infoStyle.Copy().Faint(true)
 )
 
 type model struct {
 	content   string
+	entries   []entry
 	input     chan string
 	ready     bool
 	err       error
 	viewport  viewport.Model
 	textInput textinput.Model
+
+	typing      chan struct{}
+	lastTyping  time.Time
+	typingUsers map[string]time.Time
+
+	// read receives the ID of the last message scrolled into view.
+	read     chan int64
+	readSent int64
+	// lastRead is the last message read in the previous sessions,
+	// the "new messages" divider is shown after it.
+	lastRead int64
+
+	// username is the name of the user, mentions of the user are highlighted.
+	username string
+	// room is the room the user is connected to, it is shown in the header.
+	room string
+	// status is the state of the connection shown in the header, it is faint while offline.
+	status string
+	online bool
+
+	// overlay is shown instead of the chat messages while it is set.
+	overlay *overlay
+	// jump receives the IDs of the search results the user wants to see the context of.
+	jump chan int64
 }
 
-func initialModel() *model {
+func initialModel(username string, room string) *model {
 	ti := textinput.New()
 	ti.Placeholder = "Введите сообщение"
 	ti.Focus()
@@ -46,6 +84,16 @@ func initialModel() *model {
 		err:       nil,
 		content:   "",
 		input:     make(chan string, 3),
+
+		typing:      make(chan struct{}, 1),
+		typingUsers: make(map[string]time.Time),
+
+		read: make(chan int64, 1),
+		jump: make(chan int64, 1),
+
+		username: username,
+		room:     room,
+		online:   true,
 	}
 }
 
@@ -58,9 +106,13 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmd  tea.Cmd
 		cmds []tea.Cmd
 	)
+	value := m.textInput.Value()
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.overlay != nil && msg.Type != tea.KeyCtrlC {
+			return m, m.updateOverlay(msg)
+		}
 		switch msg.Type {
 		case tea.KeyCtrlC, tea.KeyEsc:
 			return m, tea.Quit
@@ -90,13 +142,55 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.viewport.Width = msg.Width
 			m.viewport.Height = int(float64(msg.Height)*0.9) - verticalMarginHeight
 		}
+		for o := m.overlay; o != nil; o = o.results {
+			o.viewport.Width = m.viewport.Width
+			o.viewport.Height = m.viewport.Height
+		}
 
 		if useHighPerformanceRenderer {
 			cmds = append(cmds, viewport.Sync(m.viewport))
 		}
 	case newMsg:
-		m.content = fmt.Sprintf("%s%s", m.content, msg.text)
-		m.viewport.SetContent(m.content)
+		m.addEntry(entry{ref: msg.ref, msg: msg.msg, text: msg.text})
+
+	case searchMsg:
+		m.overlay = newSearchOverlay(msg.query, msg.messages, m.viewport.Width, m.viewport.Height)
+
+	case contextMsg:
+		if m.overlay != nil && m.overlay.results == nil {
+			m.overlay = newContextOverlay(msg.id, msg.messages, m.overlay)
+		}
+
+	case sessionMsg:
+		m.switchSession(msg.username, msg.room)
+
+	case statusMsg:
+		m.status = msg.text
+		m.online = msg.online
+
+	case reactionMsg:
+		m.updateReaction(msg.id, msg.emoji, msg.delta)
+
+	case lastReadMsg:
+		m.lastRead = msg.id
+		m.readSent = max(m.readSent, msg.id)
+		m.renderContent()
+
+	case typingMsg:
+		m.typingUsers[msg.username] = time.Now()
+		cmds = append(cmds, tea.Tick(typingTimeout, func(time.Time) tea.Msg {
+			return typingExpiredMsg{}
+		}))
+
+	case typingDoneMsg:
+		delete(m.typingUsers, msg.username)
+
+	case typingExpiredMsg:
+		for username, t := range m.typingUsers {
+			if time.Since(t) >= typingTimeout {
+				delete(m.typingUsers, username)
+			}
+		}
 	}
 
 	m.viewport, cmd = m.viewport.Update(msg)
@@ -105,35 +199,134 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.textInput, cmd = m.textInput.Update(msg)
 	cmds = append(cmds, cmd)
 
+	if _, ok := msg.(tea.KeyMsg); ok && m.textInput.Value() != value && m.textInput.Value() != "" {
+		m.notifyTyping()
+	}
+	m.notifyRead()
+
 	return m, tea.Batch(cmds...)
 }
 
+// switchSession forgets the messages of the previous room.
+func (m *model) switchSession(username string, room string) {
+	m.username = username
+	m.room = room
+	m.entries = nil
+	m.lastRead = 0
+	m.readSent = 0
+	m.typingUsers = make(map[string]time.Time)
+	m.overlay = nil
+	m.renderContent()
+}
+
+// notifyTyping reports that the user is typing at most once per typingThrottle.
+func (m *model) notifyTyping() {
+	if time.Since(m.lastTyping) < typingThrottle {
+		return
+	}
+	m.lastTyping = time.Now()
+
+	select {
+	case m.typing <- struct{}{}:
+	default:
+	}
+}
+
 type newMsg struct {
+	ref  int
+	msg  Message
 	text string
 }
 
+// sessionMsg replaces the shown messages after the client has reconnected as the user to the room.
+type sessionMsg struct {
+	username string
+	room     string
+}
+
+// statusMsg changes the state of the connection shown in the header.
+type statusMsg struct {
+	text   string
+	online bool
+}
+
+type reactionMsg struct {
+	id    int64
+	emoji string
+	delta int
+}
+
+type lastReadMsg struct {
+	id int64
+}
+
+type typingMsg struct {
+	username string
+}
+
+type typingDoneMsg struct {
+	username string
+}
+
+type typingExpiredMsg struct{}
+
 func (m *model) View() string {
 	if !m.ready {
 		return "\n  Initializing..."
 	}
+	content := m.viewport.View()
+	if m.overlay != nil {
+		content = m.overlay.viewport.View()
+	}
 	return fmt.Sprintf("%s\n%s\n%s\n%s",
 		m.headerView(),
-		m.viewport.View(),
+		content,
 		m.footerView(),
 		m.textInput.View(),
 	)
 }
 
 func (m *model) headerView() string {
-	title := titleStyle.Render("Сообщения")
-	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(title)))
-	return lipgloss.JoinHorizontal(lipgloss.Center, title, line)
+	title := titleStyle.Render(fmt.Sprintf("Сообщения #%s", m.room))
+	status := ""
+	if m.status != "" {
+		style := infoStyle
+		if !m.online {
+			style = offlineStyle
+		}
+		status = style.Render(m.status)
+	}
+	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(title)-lipgloss.Width(status)))
+	return lipgloss.JoinHorizontal(lipgloss.Center, title, line, status)
 }
 
 func (m *model) footerView() string {
 	info := infoStyle.Render(fmt.Sprintf("%3.f%%", m.viewport.ScrollPercent()*100))
-	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(info)))
-	return lipgloss.JoinHorizontal(lipgloss.Center, line, info)
+	typing := ""
+	if text := m.typingView(); text != "" {
+		typing = typingStyle.Render(text)
+	}
+	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(info)-lipgloss.Width(typing)))
+	return lipgloss.JoinHorizontal(lipgloss.Center, typing, line, info)
+}
+
+func (m *model) typingView() string {
+	users := make([]string, 0, len(m.typingUsers))
+	for username := range m.typingUsers {
+		users = append(users, username)
+	}
+	sort.Strings(users)
+
+	switch {
+	case len(users) == 0:
+		return ""
+	case len(users) == 1:
+		return fmt.Sprintf("%s is typing…", users[0])
+	case len(users) <= 3:
+		return fmt.Sprintf("%s are typing…", strings.Join(users, ", "))
+	default:
+		return "several people are typing…"
+	}
 }
 
 type (
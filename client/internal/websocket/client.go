@@ -1,59 +1,469 @@
 package websocket
 
 import (
+	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/gorilla/websocket"
+	"io"
 	"log"
+	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+const (
+	// minReconnectBackoff is the delay before the first attempt to restore the connection,
+	// it doubles after every failed attempt up to maxReconnectBackoff.
+	minReconnectBackoff = 500 * time.Millisecond
+	maxReconnectBackoff = 30 * time.Second
+	// maxQueuedFrames limits the frames written while the connection is being restored.
+	maxQueuedFrames = 100
+)
+
+// RetryForever makes NewClient retry the connection attempts until the server refuses the user.
+const RetryForever = -1
+
+var (
+	// ErrReconnected is returned by ReadMessage once the client has reconnected,
+	// the next messages are read from the new connection.
+	ErrReconnected = errors.New("reconnected")
+	// ErrQueueFull is returned by the writes while the connection is being restored
+	// and maxQueuedFrames frames are already waiting for it.
+	ErrQueueFull = errors.New("the connection is being restored, too many messages are waiting for it")
+)
+
+// RefusedError is returned when the server refuses the connection, e.g. because the user is banned.
+type RefusedError struct {
+	StatusCode int
+	Status     string
+	// Reason is the explanation from the response body.
+	Reason string
+}
+
+func (e *RefusedError) Error() string {
+	return fmt.Sprintf("dial: %s: %s", e.Status, e.Reason)
+}
+
+// permanent reports whether the server refuses the connection until the request is changed.
+func (e *RefusedError) permanent() bool {
+	return e.StatusCode >= 400 && e.StatusCode < 500 && e.StatusCode != http.StatusTooManyRequests
+}
+
 type Client struct {
+	host  string
+	addr  string
+	token string
+	// tls is nil for the plain connections.
+	tls *tls.Config
+
+	// mx guards the connection and the session, they are replaced on reconnect.
+	mx       sync.RWMutex
 	conn     *websocket.Conn
 	username string
+	room     string
+	// connected is false while the broken connection is being restored,
+	// closed is set when the user closes the connection, it is not restored then.
+	connected bool
+	closed    bool
+	// wmx serializes writes, the connection supports only one concurrent writer.
+	wmx sync.Mutex
+
+	// queue holds the frames written while the connection is being restored.
+	qmx   sync.Mutex
+	queue [][]byte
+
+	// lastID is the ID of the last message of the room received from the server,
+	// the restored connection starts after it.
+	lastID atomic.Int64
+
+	smx    sync.Mutex
+	status Status
+	// statuses holds the last status until it is received.
+	statuses chan Status
+}
+
+// NewClient connects to the chat, the token is required for the usernames of moderators and admins,
+// the connection is secure if tlsConfig is set. The failed attempts are retried with the exponential
+// backoff unless the server refuses the user, at most retries times unless it is RetryForever.
+func NewClient(host string, addr string, username string, room string, token string, tlsConfig *tls.Config, retries int) (*Client, error) {
+	client := &Client{
+		host:     host,
+		addr:     addr,
+		token:    token,
+		tls:      tlsConfig,
+		username: username,
+		room:     room,
+		statuses: make(chan Status, 1),
+	}
+
+	backoff := minReconnectBackoff
+	for attempt := 0; ; attempt++ {
+		conn, err := client.dial(username, room, 0)
+		if err == nil {
+			client.conn = conn
+			client.connected = true
+			client.notify(Status{State: StateConnected})
+			return client, nil
+		}
+
+		var refused *RefusedError
+		if errors.As(err, &refused) && refused.permanent() {
+			return nil, err
+		}
+		if retries != RetryForever && attempt >= retries {
+			return nil, err
+		}
+		log.Printf("%s, retrying in %s", err.Error(), backoff)
+		time.Sleep(backoff)
+		backoff = min(2*backoff, maxReconnectBackoff)
+	}
 }
 
-func NewClient(host string, addr string, username string) *Client {
-	u := url.URL{Scheme: "ws", Host: host, Path: addr}
+// dial opens the connection, the server sends the messages after the given one instead of the last messages if it is set.
+func (c *Client) dial(username string, room string, after int64) (*websocket.Conn, error) {
+	query := url.Values{}
+	query.Set("username", username)
+	query.Set("room", room)
+	if after > 0 {
+		query.Set("after", strconv.FormatInt(after, 10))
+	}
+	u := url.URL{Scheme: "ws", Host: c.host, Path: c.addr, RawQuery: query.Encode()}
+	if c.tls != nil {
+		u.Scheme = "wss"
+	}
 	log.Printf("connecting to %s", u.String())
 
-	c, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	header := http.Header{}
+	if c.token != "" {
+		header.Set("Authorization", "Bearer "+c.token)
+	}
+	dialer := *websocket.DefaultDialer
+	dialer.TLSClientConfig = c.tls
+	conn, resp, err := dialer.Dial(u.String(), header)
 	if err != nil {
-		log.Fatal("dial:", err)
+		// the server explains the refused connections, e.g. the ban, in the response body
+		if resp != nil {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return nil, &RefusedError{StatusCode: resp.StatusCode, Status: resp.Status, Reason: strings.TrimSpace(string(body))}
+		}
+		return nil, fmt.Errorf("dial: %w", err)
 	}
+	return conn, nil
+}
 
-	client := &Client{
-		conn:     c,
-		username: username,
+// Reconnect connects to the room as the user and closes the previous connection,
+// the previous connection is kept if the new one cannot be opened.
+// The frames queued for the previous room are dropped.
+func (c *Client) Reconnect(username string, room string) error {
+	conn, err := c.dial(username, room, 0)
+	if err != nil {
+		return err
 	}
-	return client
+
+	// the writes wait for the new connection
+	c.wmx.Lock()
+	defer c.wmx.Unlock()
+
+	c.mx.Lock()
+	prev := c.conn
+	c.conn = conn
+	c.username = username
+	c.room = room
+	c.connected = true
+	c.mx.Unlock()
+
+	c.qmx.Lock()
+	c.queue = nil
+	c.qmx.Unlock()
+	c.lastID.Store(0)
+	c.notify(Status{State: StateConnected})
+
+	// the reading goroutine gets ErrReconnected
+	return prev.Close()
 }
 
+// Username returns the username of the current connection.
+func (c *Client) Username() string {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	return c.username
+}
+
+// Room returns the room of the current connection.
+func (c *Client) Room() string {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	return c.room
+}
+
+func (c *Client) connection() *websocket.Conn {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	return c.conn
+}
+
+// CloseConnection closes the connection, it is not restored after that.
 func (c *Client) CloseConnection() error {
-	return c.conn.Close()
+	c.mx.Lock()
+	c.closed = true
+	c.mx.Unlock()
+	return c.connection().Close()
 }
 
+// ReadMessage reads the next frame from the server. The broken connection is restored with the
+// exponential backoff, the error is returned if the connection cannot be restored, e.g. the user is banned.
 func (c *Client) ReadMessage() (messageType int, msg Message, err error) {
-	messageType, p, err := c.conn.ReadMessage()
-	if err != nil {
-		return messageType, Message{}, err
+	for {
+		conn := c.connection()
+		messageType, p, err := conn.ReadMessage()
+		if err != nil && conn != c.connection() {
+			return messageType, Message{}, ErrReconnected
+		}
+		if err != nil {
+			if !c.resumable(err) {
+				return messageType, Message{}, err
+			}
+			err = c.resume(conn, err)
+			if err != nil {
+				return messageType, Message{}, err
+			}
+			continue
+		}
+
+		err = json.Unmarshal(p, &msg)
+		if err != nil {
+			return messageType, Message{}, err
+		}
+
+		// the messages of the room are read by a single goroutine
+		if (msg.Type == TypeMessage || msg.Type == "") && msg.ID > c.lastID.Load() {
+			c.lastID.Store(msg.ID)
+		}
+		return messageType, msg, nil
 	}
+}
 
-	err = json.Unmarshal(p, &msg)
-	if err != nil {
-		return messageType, Message{}, err
+// resumable reports whether the connection broken with the error should be restored,
+// the connections closed by the user and the connections of the kicked or banned users are not.
+func (c *Client) resumable(err error) bool {
+	c.mx.RLock()
+	closed := c.closed
+	c.mx.RUnlock()
+	return !closed && !websocket.IsCloseError(err, websocket.ClosePolicyViolation)
+}
+
+// resume restores the broken connection, the server sends the messages missed meanwhile.
+// The attempts are retried with the exponential backoff until the server refuses the user,
+// ErrReconnected is returned if the user has switched the room meanwhile.
+func (c *Client) resume(broken *websocket.Conn, cause error) error {
+	c.mx.Lock()
+	c.connected = false
+	c.mx.Unlock()
+	_ = broken.Close()
+
+	backoff := minReconnectBackoff
+	for attempt := 1; ; attempt++ {
+		c.notify(Status{State: StateReconnecting, Attempt: attempt, Retry: backoff, Err: cause})
+		time.Sleep(backoff)
+
+		c.mx.RLock()
+		closed, switched := c.closed, c.conn != broken
+		c.mx.RUnlock()
+		if closed {
+			return cause
+		}
+		// the user has switched the room meanwhile
+		if switched {
+			return ErrReconnected
+		}
+
+		conn, err := c.dial(c.Username(), c.Room(), c.lastID.Load())
+		if err != nil {
+			var refused *RefusedError
+			if errors.As(err, &refused) && refused.permanent() {
+				return err
+			}
+			cause = err
+			backoff = min(2*backoff, maxReconnectBackoff)
+			continue
+		}
+		if !c.restore(broken, conn) {
+			_ = conn.Close()
+			return ErrReconnected
+		}
+		return nil
+	}
+}
+
+// restore replaces the broken connection and sends the queued frames to the new one,
+// it returns false if the broken connection has been replaced by the user meanwhile.
+func (c *Client) restore(broken *websocket.Conn, conn *websocket.Conn) bool {
+	c.wmx.Lock()
+	defer c.wmx.Unlock()
+
+	c.mx.Lock()
+	if c.conn != broken {
+		c.mx.Unlock()
+		return false
+	}
+	c.conn = conn
+	c.connected = true
+	c.mx.Unlock()
+
+	c.qmx.Lock()
+	queue := c.queue
+	c.queue = nil
+	c.qmx.Unlock()
+	for i, data := range queue {
+		err := conn.WriteMessage(websocket.TextMessage, data)
+		if err != nil {
+			// the rest is sent after the connection is restored again
+			c.qmx.Lock()
+			c.queue = append(queue[i:], c.queue...)
+			c.qmx.Unlock()
+			break
+		}
 	}
 
-	return messageType, msg, err
+	c.notify(Status{State: StateConnected})
+	return true
 }
 
 func (c *Client) WriteMessage(messageType int, msg string) error {
 	m := Message{
-		Username: c.username,
+		Type:     TypeMessage,
+		Username: c.Username(),
 		Text:     msg,
 	}
-	data, err := json.Marshal(m)
+	return c.writeJSON(messageType, m)
+}
+
+// WriteReply sends the message replying to the message with the given ID.
+func (c *Client) WriteReply(msg string, replyTo int64) error {
+	m := Message{
+		Type:     TypeMessage,
+		Username: c.Username(),
+		Text:     msg,
+		ReplyTo:  replyTo,
+	}
+	return c.writeJSON(websocket.TextMessage, m)
+}
+
+// WriteThread requests the replies to the message with the given ID.
+func (c *Client) WriteThread(id int64) error {
+	m := Message{
+		Type: TypeThread,
+		ID:   id,
+	}
+	return c.writeJSON(websocket.TextMessage, m)
+}
+
+// WriteMentions requests the last messages mentioning the user.
+func (c *Client) WriteMentions() error {
+	m := Message{
+		Type: TypeMentions,
+	}
+	return c.writeJSON(websocket.TextMessage, m)
+}
+
+// WriteCommand sends the command to the server, the name is given without the leading '/'.
+func (c *Client) WriteCommand(name string, args []string) error {
+	m := Message{
+		Type:    TypeCommand,
+		Command: name,
+		Args:    args,
+	}
+	return c.writeJSON(websocket.TextMessage, m)
+}
+
+// WriteTyping notifies the other room members that the user is typing.
+func (c *Client) WriteTyping() error {
+	m := Message{
+		Type:     TypeTyping,
+		Username: c.Username(),
+	}
+	// the typing notifications are useless after the connection is restored
+	return c.write(websocket.TextMessage, m, false)
+}
+
+// WriteRead marks messages up to the given one as read.
+func (c *Client) WriteRead(id int64) error {
+	m := Message{
+		Type: TypeRead,
+		ID:   id,
+	}
+	return c.writeJSON(websocket.TextMessage, m)
+}
+
+// WriteReaction adds the emoji reaction to the message or removes it.
+func (c *Client) WriteReaction(id int64, emoji string, add bool) error {
+	m := Message{
+		Type:     TypeReaction,
+		ID:       id,
+		Username: c.Username(),
+		Emoji:    emoji,
+		Action:   ReactionAdd,
+	}
+	if !add {
+		m.Action = ReactionRemove
+	}
+	return c.writeJSON(websocket.TextMessage, m)
+}
+
+func (c *Client) writeJSON(messageType int, v any) error {
+	return c.write(messageType, v, true)
+}
+
+// write sends the frame, the frame written while the connection is being restored is queued
+// and sent after it is restored, or dropped if queue is false.
+func (c *Client) write(messageType int, v any, queue bool) error {
+	data, err := json.Marshal(v)
 	if err != nil {
 		return err
 	}
-	return c.conn.WriteMessage(messageType, data)
+
+	c.wmx.Lock()
+	defer c.wmx.Unlock()
+
+	c.mx.RLock()
+	conn, connected := c.conn, c.connected
+	c.mx.RUnlock()
+	if connected {
+		// the reading goroutine finds out that the connection is broken and restores it,
+		// the frame is sent after that
+		if err = conn.WriteMessage(messageType, data); err == nil {
+			return nil
+		}
+	}
+	if !queue {
+		return nil
+	}
+
+	c.qmx.Lock()
+	if len(c.queue) >= maxQueuedFrames {
+		c.qmx.Unlock()
+		return ErrQueueFull
+	}
+	c.queue = append(c.queue, data)
+	c.qmx.Unlock()
+
+	// the status shows the number of the queued frames
+	c.smx.Lock()
+	s := c.status
+	c.smx.Unlock()
+	c.notify(s)
+	return nil
+}
+
+// Pending returns the number of the frames waiting for the connection to be restored.
+func (c *Client) Pending() int {
+	c.qmx.Lock()
+	defer c.qmx.Unlock()
+	return len(c.queue)
 }
@@ -0,0 +1,36 @@
+package websocket
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewClient_Retries(t *testing.T) {
+	// the address is free, so the connections are refused
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	_, err = NewClient(addr, "/api/v1/chat", "danil", "general", "", nil, 1)
+	assert.ErrorContains(t, err, "dial")
+}
+
+func TestNewClient_Refused(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "banned by gleb: spam", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	// the refused user is not retried even if the retries are not limited
+	_, err := NewClient(strings.TrimPrefix(server.URL, "http://"), "/api/v1/chat", "danil", "general", "", nil, RetryForever)
+	var refused *RefusedError
+	require.ErrorAs(t, err, &refused)
+	assert.Equal(t, http.StatusForbidden, refused.StatusCode)
+	assert.Equal(t, "banned by gleb: spam", refused.Reason)
+}
@@ -1,6 +1,67 @@
 package websocket
 
+const (
+	TypeMessage      = "message"
+	TypeTyping       = "typing"
+	TypeRead         = "read"
+	TypeUnread       = "unread"
+	TypeGap          = "gap"
+	TypeReaction     = "reaction"
+	TypeThread       = "thread"
+	TypeMention      = "mention"
+	TypeMentions     = "mentions"
+	TypeError        = "error"
+	TypeModeration   = "moderation"
+	TypeAnnouncement = "announcement"
+	TypeCommand      = "command"
+
+	ReactionAdd    = "add"
+	ReactionRemove = "remove"
+)
+
 type Message struct {
-	Username string `json:"username" required:"true"`
-	Text     string `json:"message" required:"true"`
+	Type     string `json:"type,omitempty"`
+	ID       int64  `json:"id,omitempty"`
+	Username string `json:"username,omitempty" required:"true"`
+	Text     string `json:"message,omitempty" required:"true"`
+	Room     string `json:"room,omitempty"`
+
+	ReplyTo   int64          `json:"reply_to,omitempty"`
+	Replies   int            `json:"replies,omitempty"`
+	History   bool           `json:"history,omitempty"`
+	Reactions map[string]int `json:"reactions,omitempty"`
+	Mentions  []string       `json:"mentions,omitempty"`
+	// Emoji and Action describe a reaction to the message with ID.
+	Emoji  string `json:"emoji,omitempty"`
+	Action string `json:"action,omitempty"`
+
+	// Messages is the thread or the mentions loaded by the server.
+	Messages []Message `json:"messages,omitempty"`
+
+	// Errors describe the invalid fields of the frame the server responded with an error to.
+	Errors []FieldError `json:"errors,omitempty"`
+
+	// LastRead and Unread are sent by the server on connect.
+	LastRead int64          `json:"last_read,omitempty"`
+	Unread   map[string]int `json:"unread,omitempty"`
+
+	// After and Before are the IDs of the messages between which the missed messages are not sent on reconnect.
+	After  int64 `json:"after,omitempty"`
+	Before int64 `json:"before,omitempty"`
+
+	// Moderator, Target, Duration in seconds and Reason describe the moderator action with Action.
+	Moderator string `json:"moderator,omitempty"`
+	Target    string `json:"target,omitempty"`
+	Duration  int64  `json:"duration,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+
+	// Command and Args are the command sent to the server, the reply has the Command and the Text.
+	Command string   `json:"command,omitempty"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// FieldError describes the field of a frame that doesn't match the server schema.
+type FieldError struct {
+	Field string `json:"field"`
+	Error string `json:"error"`
 }
@@ -0,0 +1,43 @@
+package websocket
+
+import "time"
+
+// State is the state of the connection to the chat.
+type State int
+
+const (
+	StateConnected State = iota
+	// StateReconnecting means the broken connection is being restored.
+	StateReconnecting
+)
+
+// Status describes the connection, the client sends it to Statuses whenever it changes.
+type Status struct {
+	State State
+	// Attempt is the number of the next attempt to restore the connection, Retry is the delay before it.
+	Attempt int
+	Retry   time.Duration
+	// Err is the reason the connection is broken.
+	Err error
+	// Queued is the number of the frames waiting for the connection to be restored.
+	Queued int
+}
+
+// Statuses returns the changes of the connection status, only the last status is kept until it is received.
+func (c *Client) Statuses() <-chan Status {
+	return c.statuses
+}
+
+// notify replaces the unreceived status with the given one.
+func (c *Client) notify(s Status) {
+	c.smx.Lock()
+	defer c.smx.Unlock()
+
+	s.Queued = c.Pending()
+	c.status = s
+	select {
+	case <-c.statuses:
+	default:
+	}
+	c.statuses <- s
+}
@@ -1,5 +1,7 @@
+-- creates the schema of the first version, the later changes are the migrations applied by the storage service,
+-- see services/storage/internal/adapters/postgres/migrations
 CREATE TABLE IF NOT EXISTS messages (
   id BIGSERIAL,
   username CHARACTER VARYING(128) NOT NULL,
   data TEXT NOT NULL
-)
\ No newline at end of file
+);
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"chat/internal/adapters/redis"
 	"chat/internal/adapters/websocket"
 	"chat/internal/app"
 	"chat/internal/config"
+	"chat/internal/filter"
+	"chat/internal/logging"
 	"chat/internal/repository"
+	"chat/internal/tracing"
 	"context"
 	"errors"
 	"fmt"
@@ -19,28 +23,31 @@ import (
 const EnvFile = "example.env"
 
 func main() {
-	logger := &logrus.Logger{
-		Out: os.Stderr,
-		Formatter: &logrus.TextFormatter{
-			ForceColors:   true,
-			PadLevelText:  true,
-			FullTimestamp: true,
-		},
-		Hooks: logrus.LevelHooks{},
-		Level: logrus.DebugLevel,
-	}
+	// the logger is configured when the config is loaded
+	logger := logrus.New()
 
 	cfg, err := config.Get(logger, EnvFile)
 	if err != nil {
 		logger.WithError(err).Fatal("cannot parse config")
 	}
+	logging.Configure(logger, cfg.Logging)
+
+	tracerProvider, err := tracing.NewProvider(cfg.Tracing)
+	if err != nil {
+		logger.WithError(err).Fatal("cannot create tracer provider")
+	}
 
 	repo, err := repository.NewRepository(cfg.Postgres, cfg.Redis, cfg.Kafka)
 	if err != nil {
 		logger.WithError(err).Fatal("cannot create repository")
 	}
-	a := app.New(repo, cfg.App)
-	server := websocket.NewServer(a, cfg.Server, logger)
+	chain, err := filter.NewChain(cfg.Filter, filter.DefaultFilters()...)
+	if err != nil {
+		logger.WithError(err).Fatal("cannot create message filter")
+	}
+	a := app.New(repo, chain, cfg.App)
+	limiter := redis.NewLimiter(cfg.Redis)
+	server := websocket.NewServer(a, limiter, repo, cfg.Server, logger)
 
 	// graceful shutdown
 	eg, ctx := errgroup.WithContext(context.Background())
@@ -56,6 +63,58 @@ func main() {
 		}
 	})
 
+	// the filter rules are reloaded on SIGHUP
+	sigHup := make(chan os.Signal, 1)
+	signal.Notify(sigHup, syscall.SIGHUP)
+	eg.Go(func() error {
+		for {
+			select {
+			case <-sigHup:
+				_ = chain.Reload()
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	})
+
+	// the moderator actions of all replicas are applied to the local connections
+	eg.Go(func() error {
+		err := server.ListenModeration(ctx)
+		if err != nil {
+			return fmt.Errorf("cannot listen moderation: %w", err)
+		}
+		return nil
+	})
+
+	eg.Go(func() error {
+		err := server.ListenAnnouncements(ctx)
+		if err != nil {
+			return fmt.Errorf("cannot listen announcements: %w", err)
+		}
+		return nil
+	})
+
+	eg.Go(func() error {
+		logger.WithField("port", cfg.Server.AdminPort).Info("admin server: start listening")
+		defer logger.WithField("port", cfg.Server.AdminPort).Infof("admin server: close listening")
+
+		errCh := make(chan error)
+
+		go func() {
+			err := server.ListenAndServeAdmin()
+			if !errors.Is(err, http.ErrServerClosed) && err != nil {
+				errCh <- err
+			}
+		}()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errCh:
+			return fmt.Errorf("admin server can't listen and serve requests: %s", err.Error())
+		}
+	})
+
 	eg.Go(func() error {
 		logger.WithField("port", cfg.Server.Port).Info("websocket server: start listening")
 		defer logger.WithField("port", cfg.Server.Port).Infof("websocket server: close listening")
@@ -82,10 +141,26 @@ func main() {
 	}
 
 	logger.Info("start graceful shutdown")
-	err = server.GracefulShutdown(context.Background())
+	// the shutdown timeout starts after the delay, when the connections start to drain
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownDelay+cfg.Server.ShutdownTimeout)
+	defer cancel()
+	err = server.GracefulShutdown(shutdownCtx)
 	if err != nil {
 		logger.Infof("cannot gracefully shutdown the server: %s", err.Error())
 	} else {
 		logger.Infof("server was successfully shutted down")
 	}
+
+	// the messages saved by the last frames are still queued in the producer, the flush has its own deadline
+	// because the shutdown context may be already done by the delay and the drain
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), cfg.Kafka.FlushTimeout)
+	defer cancelFlush()
+	if err = repo.Close(flushCtx); err != nil {
+		logger.WithError(err).Error("cannot close the repository")
+	}
+
+	// the spans are flushed after the last messages are produced
+	if err = tracerProvider.Shutdown(context.Background()); err != nil {
+		logger.WithError(err).Error("cannot flush the spans")
+	}
 }
@@ -1,9 +1,18 @@
 package kafka
 
-import "github.com/sirupsen/logrus"
+import (
+	"github.com/sirupsen/logrus"
+	"time"
+)
 
 type Config struct {
 	Brokers []string
 	Topic   string
-	Logger  logrus.FieldLogger
+	// ConnectRetries is the number of the retried attempts to connect to the brokers on start,
+	// ConnectBackoff is the delay before the first retry, it doubles after every attempt.
+	ConnectRetries int
+	ConnectBackoff time.Duration
+	// FlushTimeout limits the time of flushing the buffered records on shutdown.
+	FlushTimeout time.Duration
+	Logger       logrus.FieldLogger
 }
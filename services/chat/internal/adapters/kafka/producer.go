@@ -2,65 +2,230 @@ package kafka
 
 import (
 	"chat/internal/domain"
+	"chat/internal/logging"
+	"chat/internal/metrics"
+	"chat/internal/tracing"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/IBM/sarama"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"strconv"
+	"sync"
 	"time"
 )
 
+// EventHeader is the header with the type of the event stored in the record,
+// records without it are treated as messages by consumers.
+const EventHeader = "event"
+
+const (
+	eventMessage  = "message"
+	eventReaction = "reaction"
+)
+
+// maxConnectBackoff limits the delay between the attempts to connect to the brokers.
+const maxConnectBackoff = 10 * time.Second
+
+// ErrClosed is returned when the event is produced after the producer is closed.
+var ErrClosed = errors.New("kafka producer is closed")
+
 type Producer struct {
-	conn  sarama.AsyncProducer
-	log   logrus.FieldLogger
-	topic string
+	client sarama.Client
+	conn   sarama.AsyncProducer
+	log    logrus.FieldLogger
+	topic  string
+
+	// mu guards closed, the records are sent to the input of the producer only while it is open
+	mu     sync.RWMutex
+	closed bool
 }
 
+// NewProducer connects to the brokers, the failed attempts are retried
+// cfg.ConnectRetries times with the exponential backoff starting at cfg.ConnectBackoff.
 func NewProducer(cfg *Config) (*Producer, error) {
 	config := sarama.NewConfig()
 	config.Version = sarama.DefaultVersion
 	config.Producer.RequiredAcks = sarama.WaitForLocal
 	config.Producer.Compression = sarama.CompressionSnappy
 	config.Producer.Flush.Frequency = 500 * time.Millisecond
+	// the acknowledged records are counted in the metrics
+	config.Producer.Return.Successes = true
 
-	producer, err := sarama.NewAsyncProducer(cfg.Brokers, config)
+	client, err := connect(cfg, config)
+	if err != nil {
+		cfg.Logger.WithError(err).Error("cannot connect to kafka brokers")
+		return nil, err
+	}
+	producer, err := sarama.NewAsyncProducerFromClient(client)
 	if err != nil {
 		cfg.Logger.WithError(err).Error("cannot create new kafka producer")
+		_ = client.Close()
 		return nil, err
 	}
 
+	// the span of a record ends when the record is acknowledged or fails
 	go func() {
-		for err = range producer.Errors() {
+		for err := range producer.Errors() {
+			metrics.KafkaProduced.WithLabelValues(metrics.ResultError).Inc()
 			cfg.Logger.WithError(err).Error("failed to produce message")
+			if span, ok := err.Msg.Metadata.(trace.Span); ok {
+				tracing.RecordError(span, err.Err)
+				span.End()
+			}
+		}
+	}()
+	go func() {
+		for msg := range producer.Successes() {
+			metrics.KafkaProduced.WithLabelValues(metrics.ResultSuccess).Inc()
+			if span, ok := msg.Metadata.(trace.Span); ok {
+				span.SetAttributes(
+					semconv.MessagingDestinationPartitionID(strconv.Itoa(int(msg.Partition))),
+					semconv.MessagingKafkaMessageOffset(int(msg.Offset)),
+				)
+				span.End()
+			}
 		}
 	}()
 
 	return &Producer{
-		conn:  producer,
-		topic: cfg.Topic,
-		log:   cfg.Logger,
+		client: client,
+		conn:   producer,
+		topic:  cfg.Topic,
+		log:    cfg.Logger,
 	}, nil
 }
 
-func (p *Producer) SaveMessage(_ context.Context, message domain.Message) error {
-	p.log.
-		WithField("message", message).
-		Info("trying to produce message")
-	b, err := json.Marshal(message)
-	if err != nil {
-		p.log.WithError(err).Error("cannot marshal message")
+func connect(cfg *Config, config *sarama.Config) (sarama.Client, error) {
+	backoff := cfg.ConnectBackoff
+	for attempt := 0; ; attempt++ {
+		client, err := sarama.NewClient(cfg.Brokers, config)
+		if err == nil {
+			return client, nil
+		}
+		if attempt >= cfg.ConnectRetries {
+			return nil, err
+		}
+
+		cfg.Logger.
+			WithError(err).
+			WithField("attempt", attempt+1).
+			WithField("backoff", backoff).
+			Warn("cannot connect to kafka brokers, retrying")
+		time.Sleep(backoff)
+		backoff = min(2*backoff, maxConnectBackoff)
+	}
+}
+
+// Ping checks that the metadata of the topic can be loaded from the brokers.
+func (p *Producer) Ping(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.client.RefreshMetadata(p.topic)
+	}()
+
+	select {
+	case err := <-errCh:
 		return err
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	p.conn.Input() <- &sarama.ProducerMessage{
-		Topic: p.topic,
-		Key:   nil,
-		Value: sarama.ByteEncoder(b),
+}
+
+func (p *Producer) SaveMessage(ctx context.Context, message domain.Message) error {
+	log := logging.FromContext(ctx, p.log)
+	log.WithField("message", message).
+		Debug("trying to produce message")
+	err := p.produce(ctx, eventMessage, message)
+	if err != nil {
+		return err
 	}
-	p.log.
-		WithField("message", message).
+	log.WithField("message", message).
 		Info("message was produced")
 	return nil
 }
 
-func (p *Producer) Close() error {
-	return p.conn.Close()
+func (p *Producer) SaveReaction(ctx context.Context, reaction domain.Reaction) error {
+	log := logging.FromContext(ctx, p.log)
+	log.WithField("reaction", reaction).
+		Debug("trying to produce reaction")
+	err := p.produce(ctx, eventReaction, reaction)
+	if err != nil {
+		return err
+	}
+	log.WithField("reaction", reaction).
+		Info("reaction was produced")
+	return nil
+}
+
+// produce sends the event to the topic, the trace context of ctx is passed in the record headers.
+func (p *Producer) produce(ctx context.Context, event string, v any) error {
+	ctx, span := tracing.Tracer().Start(ctx, p.topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypePublish,
+			semconv.MessagingDestinationName(p.topic),
+		),
+	)
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		p.log.WithError(err).Error("cannot marshal message")
+		tracing.RecordError(span, err)
+		span.End()
+		return err
+	}
+	headers := []sarama.RecordHeader{
+		{Key: []byte(EventHeader), Value: []byte(event)},
+	}
+	otel.GetTextMapPropagator().Inject(ctx, headersCarrier{headers: &headers})
+
+	record := &sarama.ProducerMessage{
+		Topic:    p.topic,
+		Key:      nil,
+		Value:    sarama.ByteEncoder(b),
+		Headers:  headers,
+		Metadata: span,
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		tracing.RecordError(span, ErrClosed)
+		span.End()
+		return ErrClosed
+	}
+	select {
+	case p.conn.Input() <- record:
+		return nil
+	case <-ctx.Done():
+		tracing.RecordError(span, ctx.Err())
+		span.End()
+		return ctx.Err()
+	}
+}
+
+// Close flushes the buffered records and closes the producer, the events produced after it fail with ErrClosed.
+// The records which are not acknowledged until ctx is done are lost, the producer is left to be closed with the process.
+func (p *Producer) Close(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		// the input is closed only after the events being sent to it are sent
+		p.mu.Lock()
+		p.closed = true
+		p.mu.Unlock()
+		errCh <- errors.Join(p.conn.Close(), p.client.Close())
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("cannot flush the buffered records: %w", ctx.Err())
+	}
 }
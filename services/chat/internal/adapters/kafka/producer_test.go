@@ -0,0 +1,57 @@
+package kafka
+
+import (
+	"chat/internal/domain"
+	"context"
+	"github.com/IBM/sarama"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+	"time"
+)
+
+// inputProducer is the async producer with the input nobody reads unless the test does.
+type inputProducer struct {
+	sarama.AsyncProducer
+	input chan *sarama.ProducerMessage
+}
+
+func (p inputProducer) Input() chan<- *sarama.ProducerMessage {
+	return p.input
+}
+
+func newTestProducer(closed bool) (*Producer, chan *sarama.ProducerMessage) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	input := make(chan *sarama.ProducerMessage)
+	return &Producer{conn: inputProducer{input: input}, log: log, topic: "messages", closed: closed}, input
+}
+
+func TestProducer_SaveMessage(t *testing.T) {
+	message := domain.Message{ID: 42, Username: "danil", Text: "hello", Room: domain.DefaultRoom}
+
+	t.Run("produced", func(t *testing.T) {
+		p, input := newTestProducer(false)
+		records := make(chan *sarama.ProducerMessage, 1)
+		go func() { records <- <-input }()
+		assert.NoError(t, p.SaveMessage(context.Background(), message))
+
+		record := <-records
+		assert.Equal(t, "messages", record.Topic)
+		assert.Equal(t, []byte(eventMessage), record.Headers[0].Value)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		// the input of the closed producer is closed too, sending to it would panic
+		p, _ := newTestProducer(true)
+		assert.ErrorIs(t, p.SaveMessage(context.Background(), message), ErrClosed)
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		p, _ := newTestProducer(false)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, p.SaveMessage(ctx, message), context.DeadlineExceeded)
+	})
+}
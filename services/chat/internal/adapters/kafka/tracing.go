@@ -0,0 +1,37 @@
+package kafka
+
+import (
+	"github.com/IBM/sarama"
+)
+
+// headersCarrier passes the trace context in the headers of the produced record.
+type headersCarrier struct {
+	headers *[]sarama.RecordHeader
+}
+
+func (c headersCarrier) Get(key string) string {
+	for _, h := range *c.headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c headersCarrier) Set(key string, value string) {
+	for i, h := range *c.headers {
+		if string(h.Key) == key {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.headers = append(*c.headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+func (c headersCarrier) Keys() []string {
+	keys := make([]string, 0, len(*c.headers))
+	for _, h := range *c.headers {
+		keys = append(keys, string(h.Key))
+	}
+	return keys
+}
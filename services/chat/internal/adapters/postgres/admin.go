@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+)
+
+// deleteUserMessagesQuery deletes the messages of the user along with their mentions and reactions,
+// it returns the number of the deleted messages in every room.
+const deleteUserMessagesQuery = `WITH deleted AS (
+    DELETE FROM messages WHERE username = $1 RETURNING id, room
+), deleted_mentions AS (
+    DELETE FROM mentions WHERE message_id IN (SELECT id FROM deleted)
+), deleted_reactions AS (
+    DELETE FROM message_reactions WHERE message_id IN (SELECT id FROM deleted)
+), deleted_reaction_users AS (
+    DELETE FROM message_reaction_users WHERE message_id IN (SELECT id FROM deleted)
+)
+SELECT room, count(*) FROM deleted GROUP BY room;`
+
+// DeleteUserMessages deletes all messages of the user and returns the number of the deleted messages by room.
+func (r *Repository) DeleteUserMessages(ctx context.Context, username string) (map[string]int, error) {
+	rows, err := r.pool.Query(ctx, deleteUserMessagesQuery, username)
+	if err != nil {
+		r.log.
+			WithError(err).
+			WithField("username", username).
+			Error("cannot delete user messages")
+		return nil, newPostgresError(err)
+	}
+	defer rows.Close()
+
+	deleted := make(map[string]int)
+	for rows.Next() {
+		var (
+			room  string
+			count int
+		)
+		err = rows.Scan(&room, &count)
+		if err != nil {
+			r.log.
+				WithError(err).
+				WithField("username", username).
+				Error("cannot scan deleted messages")
+			return nil, newPostgresError(err)
+		}
+		deleted[room] = count
+	}
+	if err = rows.Err(); err != nil {
+		r.log.
+			WithError(err).
+			WithField("username", username).
+			Error("cannot delete user messages")
+		return nil, newPostgresError(err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"chat/internal/domain"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+)
+
+const loadUserRoleQuery = `SELECT role, token_hash FROM user_roles WHERE username = $1;`
+
+// LoadUserRole returns the role of the user, the not found error is returned for members.
+func (r *Repository) LoadUserRole(ctx context.Context, username string) (domain.UserRole, error) {
+	role := domain.UserRole{Username: username}
+	err := r.pool.QueryRow(ctx, loadUserRoleQuery, username).Scan(&role.Role, &role.TokenHash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.UserRole{}, newPostgresError(err)
+	}
+	if err != nil {
+		r.log.
+			WithError(err).
+			WithField("username", username).
+			Error("cannot load user role")
+		return domain.UserRole{}, newPostgresError(err)
+	}
+	return role, nil
+}
+
+const loadBanQuery = `SELECT reason, banned_by FROM bans WHERE username = $1;`
+
+// LoadBan returns the ban of the user, the not found error is returned if the user is not banned.
+func (r *Repository) LoadBan(ctx context.Context, username string) (domain.Ban, error) {
+	ban := domain.Ban{Username: username}
+	err := r.pool.QueryRow(ctx, loadBanQuery, username).Scan(&ban.Reason, &ban.BannedBy)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Ban{}, newPostgresError(err)
+	}
+	if err != nil {
+		r.log.
+			WithError(err).
+			WithField("username", username).
+			Error("cannot load ban")
+		return domain.Ban{}, newPostgresError(err)
+	}
+	return ban, nil
+}
+
+const saveBanQuery = `INSERT INTO bans (username, reason, banned_by) VALUES ($1, $2, $3)
+ON CONFLICT (username) DO UPDATE
+    SET reason = EXCLUDED.reason,
+        banned_by = EXCLUDED.banned_by,
+        created_at = now();`
+
+func (r *Repository) SaveBan(ctx context.Context, ban domain.Ban) error {
+	_, err := r.pool.Exec(ctx, saveBanQuery, ban.Username, ban.Reason, ban.BannedBy)
+	if err != nil {
+		r.log.
+			WithError(err).
+			WithField("ban", ban).
+			Error("cannot save ban")
+		return newPostgresError(err)
+	}
+	return nil
+}
+
+const deleteBanQuery = `DELETE FROM bans WHERE username = $1;`
+
+func (r *Repository) DeleteBan(ctx context.Context, username string) error {
+	_, err := r.pool.Exec(ctx, deleteBanQuery, username)
+	if err != nil {
+		r.log.
+			WithError(err).
+			WithField("username", username).
+			Error("cannot delete ban")
+		return newPostgresError(err)
+	}
+	return nil
+}
+
+const saveModerationQuery = `INSERT INTO moderation_log (moderator, action, target, room, duration, reason)
+VALUES ($1, $2, $3, $4, make_interval(secs => $5), $6);`
+
+// SaveModeration writes the moderator action to the audit log.
+func (r *Repository) SaveModeration(ctx context.Context, m domain.Moderation) error {
+	_, err := r.pool.Exec(ctx, saveModerationQuery, m.Moderator, m.Action, m.Target, m.Room, m.Duration.Seconds(), m.Reason)
+	if err != nil {
+		r.log.
+			WithError(err).
+			WithField("moderation", m).
+			Error("cannot save moderation")
+		return newPostgresError(err)
+	}
+	return nil
+}
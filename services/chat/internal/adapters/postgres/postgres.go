@@ -3,6 +3,7 @@ package postgres
 import (
 	"chat/internal/domain"
 	"context"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
 )
@@ -19,10 +20,20 @@ func NewRepository(conf *Config) *Repository {
 	}
 }
 
-const saveMessageQuery = `INSERT INTO messages (username, data) VALUES ($1, $2);`
+// Ping checks that a connection to the database can be acquired.
+func (r *Repository) Ping(ctx context.Context) error {
+	return r.pool.Ping(ctx)
+}
+
+// Close closes the connections of the pool, waiting for the acquired ones to be released.
+func (r *Repository) Close() {
+	r.pool.Close()
+}
+
+const saveMessageQuery = `INSERT INTO messages (id, username, data, room, reply_to) VALUES ($1, $2, $3, $4, NULLIF($5, 0));`
 
 func (r *Repository) SaveMessage(ctx context.Context, message domain.Message) error {
-	_, err := r.pool.Exec(ctx, saveMessageQuery, message.Username, message.Text)
+	_, err := r.pool.Exec(ctx, saveMessageQuery, message.ID, message.Username, message.Text, message.Room, message.ReplyTo)
 	if err != nil {
 		r.log.
 			WithError(err).
@@ -33,26 +44,219 @@ func (r *Repository) SaveMessage(ctx context.Context, message domain.Message) er
 	return nil
 }
 
-const loadMessagesQuery = `SELECT username, data FROM
+// messageColumns are the columns scanned by scanMessages.
+const messageColumns = `id, username, data, room, COALESCE(reply_to, 0)`
+
+const loadMessagesQuery = `SELECT ` + messageColumns + ` FROM
     (SELECT * FROM
         messages
-        ORDER BY id DESC LIMIT $1)
+        WHERE room = $1
+        ORDER BY id DESC LIMIT $2)
 ORDER BY id;`
 
-func (r *Repository) LoadMessages(ctx context.Context, count int) ([]domain.Message, error) {
-	rows, err := r.pool.Query(ctx, loadMessagesQuery, count)
+func (r *Repository) LoadMessages(ctx context.Context, room string, count int) ([]domain.Message, error) {
+	rows, err := r.pool.Query(ctx, loadMessagesQuery, room, count)
 	if err != nil {
 		r.log.
 			WithError(err).
 			Error("cannot load messages")
 		return nil, newPostgresError(err)
 	}
+	return r.scanMessages(rows)
+}
+
+const loadHistoryQuery = `SELECT ` + messageColumns + ` FROM
+    (SELECT * FROM
+        messages
+        WHERE room = $1 AND ($2::bigint = 0 OR id < $2)
+        ORDER BY id DESC LIMIT $3)
+ORDER BY id;`
+
+// LoadHistory returns the last messages of the room sent before the message with the given ID,
+// the last messages of the room are returned if before is 0.
+func (r *Repository) LoadHistory(ctx context.Context, room string, before int64, count int) ([]domain.Message, error) {
+	rows, err := r.pool.Query(ctx, loadHistoryQuery, room, before, count)
+	if err != nil {
+		r.log.
+			WithError(err).
+			Error("cannot load history")
+		return nil, newPostgresError(err)
+	}
+	return r.scanMessages(rows)
+}
+
+const loadMessagesAfterQuery = `SELECT ` + messageColumns + ` FROM
+    (SELECT * FROM
+        messages
+        WHERE room = $1 AND id > $2
+        ORDER BY id DESC LIMIT $3)
+ORDER BY id;`
+
+// LoadMessagesAfter returns the last messages of the room sent after the message with the given ID.
+func (r *Repository) LoadMessagesAfter(ctx context.Context, room string, after int64, count int) ([]domain.Message, error) {
+	rows, err := r.pool.Query(ctx, loadMessagesAfterQuery, room, after, count)
+	if err != nil {
+		r.log.
+			WithError(err).
+			Error("cannot load messages after the message")
+		return nil, newPostgresError(err)
+	}
+	return r.scanMessages(rows)
+}
+
+const loadMessageQuery = `SELECT ` + messageColumns + ` FROM messages WHERE id = $1;`
+
+func (r *Repository) LoadMessage(ctx context.Context, id int64) (domain.Message, error) {
+	rows, err := r.pool.Query(ctx, loadMessageQuery, id)
+	if err != nil {
+		r.log.
+			WithError(err).
+			Error("cannot load message")
+		return domain.Message{}, newPostgresError(err)
+	}
+
+	messages, err := r.scanMessages(rows)
+	if err != nil {
+		return domain.Message{}, err
+	}
+	if len(messages) == 0 {
+		return domain.Message{}, newPostgresError(pgx.ErrNoRows)
+	}
+	return messages[0], nil
+}
+
+const loadThreadQuery = `SELECT ` + messageColumns + ` FROM messages
+WHERE (id = $1 OR reply_to = $1) AND room = $2
+ORDER BY id;`
+
+// LoadThread returns the message of the room followed by the replies to it in the same room.
+func (r *Repository) LoadThread(ctx context.Context, room string, id int64) ([]domain.Message, error) {
+	rows, err := r.pool.Query(ctx, loadThreadQuery, id, room)
+	if err != nil {
+		r.log.
+			WithError(err).
+			Error("cannot load thread")
+		return nil, newPostgresError(err)
+	}
+
+	messages, err := r.scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 || messages[0].ID != id {
+		return nil, newPostgresError(pgx.ErrNoRows)
+	}
+	return messages, nil
+}
+
+const loadMentionsQuery = `SELECT ` + messageColumns + ` FROM
+    (SELECT messages.* FROM
+        mentions JOIN messages ON messages.id = mentions.message_id
+        WHERE mentions.username = $1
+        ORDER BY messages.id DESC LIMIT $2)
+ORDER BY id;`
+
+// LoadMentions returns the last messages mentioning the user in all rooms.
+func (r *Repository) LoadMentions(ctx context.Context, username string, count int) ([]domain.Message, error) {
+	rows, err := r.pool.Query(ctx, loadMentionsQuery, username, count)
+	if err != nil {
+		r.log.
+			WithError(err).
+			Error("cannot load mentions")
+		return nil, newPostgresError(err)
+	}
+	return r.scanMessages(rows)
+}
+
+const searchMessagesQuery = `SELECT ` + messageColumns + ` FROM messages
+WHERE search @@ websearch_to_tsquery('simple', $1) AND ($2::text = '' OR room = $2)
+ORDER BY ts_rank(search, websearch_to_tsquery('simple', $1)) DESC, id DESC
+LIMIT $3;`
+
+// SearchMessages returns the messages matching the full-text query, the best matches go first.
+func (r *Repository) SearchMessages(ctx context.Context, query string, room string, count int) ([]domain.Message, error) {
+	rows, err := r.pool.Query(ctx, searchMessagesQuery, query, room, count)
+	if err != nil {
+		r.log.
+			WithError(err).
+			Error("cannot search messages")
+		return nil, newPostgresError(err)
+	}
+	return r.scanMessages(rows)
+}
+
+const loadContextQuery = `SELECT ` + messageColumns + ` FROM (
+    (SELECT * FROM messages
+        WHERE room = (SELECT room FROM messages WHERE id = $1) AND id <= $1
+        ORDER BY id DESC LIMIT $2 + 1)
+    UNION ALL
+    (SELECT * FROM messages
+        WHERE room = (SELECT room FROM messages WHERE id = $1) AND id > $1
+        ORDER BY id LIMIT $2)
+)
+ORDER BY id;`
+
+// LoadContext returns the message surrounded by the messages sent before and after it in the same room.
+func (r *Repository) LoadContext(ctx context.Context, id int64, count int) ([]domain.Message, error) {
+	rows, err := r.pool.Query(ctx, loadContextQuery, id, count)
+	if err != nil {
+		r.log.
+			WithError(err).
+			Error("cannot load message context")
+		return nil, newPostgresError(err)
+	}
+
+	messages, err := r.scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, newPostgresError(pgx.ErrNoRows)
+	}
+	return messages, nil
+}
+
+const loadReplyCountsQuery = `SELECT replies.reply_to, count(*) FROM
+    messages AS replies JOIN messages ON messages.id = replies.reply_to AND messages.room = replies.room
+WHERE replies.reply_to = ANY($1)
+GROUP BY replies.reply_to;`
+
+// LoadReplyCounts returns the number of replies in the same room to the messages by message ID.
+func (r *Repository) LoadReplyCounts(ctx context.Context, ids []int64) (map[int64]int, error) {
+	rows, err := r.pool.Query(ctx, loadReplyCountsQuery, ids)
+	if err != nil {
+		r.log.
+			WithError(err).
+			Error("cannot load reply counts")
+		return nil, newPostgresError(err)
+	}
+	defer rows.Close()
+
+	res := make(map[int64]int)
+	for rows.Next() {
+		var (
+			id    int64
+			count int
+		)
+		err = rows.Scan(&id, &count)
+		if err != nil {
+			r.log.
+				WithError(err).
+				Error("cannot scan row")
+			return nil, newPostgresError(err)
+		}
+		res[id] = count
+	}
+	return res, nil
+}
+
+func (r *Repository) scanMessages(rows pgx.Rows) ([]domain.Message, error) {
 	defer rows.Close()
 
 	res := make([]domain.Message, 0)
 	for rows.Next() {
 		msg := domain.Message{}
-		err = rows.Scan(&msg.Username, &msg.Text)
+		err := rows.Scan(&msg.ID, &msg.Username, &msg.Text, &msg.Room, &msg.ReplyTo)
 		if err != nil {
 			r.log.
 				WithError(err).
@@ -61,5 +265,96 @@ func (r *Repository) LoadMessages(ctx context.Context, count int) ([]domain.Mess
 		}
 		res = append(res, msg)
 	}
+	if err := rows.Err(); err != nil {
+		r.log.
+			WithError(err).
+			Error("cannot read rows")
+		return nil, newPostgresError(err)
+	}
+	return res, nil
+}
+
+const saveReadMarkerQuery = `INSERT INTO read_markers (username, room, message_id) VALUES ($1, $2, $3)
+ON CONFLICT (username, room) DO UPDATE
+    SET message_id = GREATEST(read_markers.message_id, EXCLUDED.message_id),
+        updated_at = now();`
+
+func (r *Repository) SaveReadMarker(ctx context.Context, marker domain.ReadMarker) error {
+	_, err := r.pool.Exec(ctx, saveReadMarkerQuery, marker.Username, marker.Room, marker.MessageID)
+	if err != nil {
+		r.log.
+			WithError(err).
+			WithField("marker", marker).
+			Errorf("cannot save read marker")
+		return newPostgresError(err)
+	}
+	return nil
+}
+
+const loadReadMarkersQuery = `SELECT rm.room, rm.message_id, count(m.id) FROM
+    read_markers rm
+    LEFT JOIN messages m ON m.room = rm.room AND m.id > rm.message_id
+WHERE rm.username = $1
+GROUP BY rm.room, rm.message_id
+ORDER BY rm.room;`
+
+func (r *Repository) LoadReadMarkers(ctx context.Context, username string) ([]domain.ReadMarker, error) {
+	rows, err := r.pool.Query(ctx, loadReadMarkersQuery, username)
+	if err != nil {
+		r.log.
+			WithError(err).
+			Error("cannot load read markers")
+		return nil, newPostgresError(err)
+	}
+	defer rows.Close()
+
+	res := make([]domain.ReadMarker, 0)
+	for rows.Next() {
+		marker := domain.ReadMarker{Username: username}
+		err = rows.Scan(&marker.Room, &marker.MessageID, &marker.Unread)
+		if err != nil {
+			r.log.
+				WithError(err).
+				Error("cannot scan row")
+			return nil, newPostgresError(err)
+		}
+		res = append(res, marker)
+	}
+	return res, nil
+}
+
+const loadReactionsQuery = `SELECT message_id, emoji, count FROM message_reactions
+WHERE message_id = ANY($1) AND count > 0;`
+
+// LoadReactions returns reaction counts of the messages grouped by message ID.
+func (r *Repository) LoadReactions(ctx context.Context, ids []int64) (map[int64]map[string]int, error) {
+	rows, err := r.pool.Query(ctx, loadReactionsQuery, ids)
+	if err != nil {
+		r.log.
+			WithError(err).
+			Error("cannot load reactions")
+		return nil, newPostgresError(err)
+	}
+	defer rows.Close()
+
+	res := make(map[int64]map[string]int)
+	for rows.Next() {
+		var (
+			id    int64
+			emoji string
+			count int
+		)
+		err = rows.Scan(&id, &emoji, &count)
+		if err != nil {
+			r.log.
+				WithError(err).
+				Error("cannot scan row")
+			return nil, newPostgresError(err)
+		}
+		if res[id] == nil {
+			res[id] = make(map[string]int)
+		}
+		res[id][emoji] = count
+	}
 	return res, nil
 }
@@ -0,0 +1,104 @@
+package redis
+
+import (
+	"chat/internal/domain"
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+)
+
+// deleteUserMessagesScript removes the messages of the user ARGV[1] from the cached lists
+// and the recent sets KEYS, it returns the number of the removed messages.
+var deleteUserMessagesScript = redis.NewScript(`
+local removed = 0
+for _, key in ipairs(KEYS) do
+	local zset = redis.call("TYPE", key).ok == "zset"
+	local items
+	if zset then
+		items = redis.call("ZRANGE", key, 0, -1)
+	else
+		items = redis.call("LRANGE", key, 0, -1)
+	end
+	for _, item in ipairs(items) do
+		local ok, msg = pcall(cjson.decode, item)
+		if ok and type(msg) == "table" and msg["username"] == ARGV[1] then
+			if zset then
+				removed = removed + redis.call("ZREM", key, item)
+			else
+				removed = removed + redis.call("LREM", key, 0, item)
+			end
+		end
+	end
+end
+return removed
+`)
+
+// DeleteUserMessages removes the messages of the user from the cache and the recent sets of the rooms.
+func (r *Repository) DeleteUserMessages(ctx context.Context, username string, rooms []string) (int, error) {
+	if len(rooms) == 0 {
+		return 0, nil
+	}
+
+	keys := make([]string, 0, 2*len(rooms))
+	for _, room := range rooms {
+		keys = append(keys, r.roomKey(room), r.recentKey(room))
+	}
+	removed, err := deleteUserMessagesScript.Run(ctx, r.c, keys, username).Int()
+	if err != nil {
+		r.log.
+			WithError(err).
+			WithField("username", username).
+			Error("cannot delete cached messages")
+		return 0, err
+	}
+	return removed, nil
+}
+
+// SaveReadOnly makes the room read-only or writable again for all replicas.
+func (r *Repository) SaveReadOnly(ctx context.Context, room string, readOnly bool) error {
+	var err error
+	if readOnly {
+		err = r.c.SAdd(ctx, r.readOnlyKey(), room).Err()
+	} else {
+		err = r.c.SRem(ctx, r.readOnlyKey(), room).Err()
+	}
+	if err != nil {
+		r.log.
+			WithError(err).
+			WithField("room", room).
+			Error("cannot save read-only mode")
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) LoadReadOnly(ctx context.Context, room string) (bool, error) {
+	readOnly, err := r.c.SIsMember(ctx, r.readOnlyKey(), room).Result()
+	if err != nil {
+		r.log.
+			WithError(err).
+			WithField("room", room).
+			Error("cannot load read-only mode")
+		return false, err
+	}
+	return readOnly, nil
+}
+
+// PublishAnnouncement sends the announcement to all replicas of the service.
+func (r *Repository) PublishAnnouncement(ctx context.Context, a domain.Announcement) error {
+	return r.publish(ctx, r.announcementsKey(), "announcement", a)
+}
+
+// SubscribeAnnouncements returns the announcements published by all replicas,
+// the channel is closed when the context is done.
+func (r *Repository) SubscribeAnnouncements(ctx context.Context) (<-chan domain.Announcement, error) {
+	return subscribe[domain.Announcement](ctx, r, r.announcementsKey(), "announcement")
+}
+
+func (r *Repository) readOnlyKey() string {
+	return fmt.Sprintf("%s:readonly", r.adminKey)
+}
+
+func (r *Repository) announcementsKey() string {
+	return fmt.Sprintf("%s:announcements", r.adminKey)
+}
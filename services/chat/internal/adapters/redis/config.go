@@ -6,7 +6,13 @@ import (
 )
 
 type Config struct {
-	Opt    *redis.Options
-	Key    string
-	Logger logrus.FieldLogger
+	Opt *redis.Options
+	Key string
+	// RateLimitKey is the prefix of the rate limiter buckets.
+	RateLimitKey string
+	// ModerationKey is the prefix of the mute keys and the channel of the moderator actions.
+	ModerationKey string
+	// AdminKey is the prefix of the read-only rooms set and the channel of the announcements.
+	AdminKey string
+	Logger   logrus.FieldLogger
 }
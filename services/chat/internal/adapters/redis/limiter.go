@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// tokenBucketScript takes a token from the bucket stored in the hash KEYS[1].
+// ARGV: refill rate in tokens per second, bucket size, current time in milliseconds.
+// The time is passed by the caller, so the replicas must have synchronized clocks.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate * 1000))
+return allowed
+`)
+
+// Limiter is a token bucket rate limiter shared by all replicas of the service.
+type Limiter struct {
+	c   *redis.Client
+	key string
+	log logrus.FieldLogger
+}
+
+func NewLimiter(cfg *Config) *Limiter {
+	return &Limiter{
+		c:   redis.NewClient(cfg.Opt),
+		key: cfg.RateLimitKey,
+		log: cfg.Logger,
+	}
+}
+
+// Allow takes a token from the bucket of the key. The bucket holds up to burst tokens
+// and is refilled at rate tokens per second, the buckets of idle keys expire.
+func (l *Limiter) Allow(ctx context.Context, key string, rate float64, burst int) (bool, error) {
+	res, err := tokenBucketScript.Run(
+		ctx, l.c,
+		[]string{fmt.Sprintf("%s:%s", l.key, key)},
+		rate, burst, time.Now().UnixMilli(),
+	).Int()
+	if err != nil {
+		l.log.
+			WithError(err).
+			WithField("key", key).
+			Error("cannot take a token from the bucket")
+		return false, err
+	}
+	return res == 1, nil
+}
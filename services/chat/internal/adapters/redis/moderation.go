@@ -0,0 +1,66 @@
+package redis
+
+import (
+	"chat/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// SaveMute mutes the user for the duration, the mute key expires with the mute.
+func (r *Repository) SaveMute(ctx context.Context, username string, d time.Duration) error {
+	err := r.c.Set(ctx, r.muteKey(username), time.Now().Add(d).UnixMilli(), d).Err()
+	if err != nil {
+		r.log.
+			WithError(err).
+			WithField("username", username).
+			Error("cannot save mute")
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) DeleteMute(ctx context.Context, username string) error {
+	err := r.c.Del(ctx, r.muteKey(username)).Err()
+	if err != nil {
+		r.log.
+			WithError(err).
+			WithField("username", username).
+			Error("cannot delete mute")
+		return err
+	}
+	return nil
+}
+
+// LoadMute returns the time the mute of the user expires at, zero time if the user is not muted.
+func (r *Repository) LoadMute(ctx context.Context, username string) (time.Time, error) {
+	ms, err := r.c.Get(ctx, r.muteKey(username)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		r.log.
+			WithError(err).
+			WithField("username", username).
+			Error("cannot load mute")
+		return time.Time{}, err
+	}
+	return time.UnixMilli(ms), nil
+}
+
+// PublishModeration sends the moderator action to all replicas of the service.
+func (r *Repository) PublishModeration(ctx context.Context, m domain.Moderation) error {
+	return r.publish(ctx, r.moderationKey, "moderation", m)
+}
+
+// SubscribeModeration returns the moderator actions published by all replicas,
+// the channel is closed when the context is done.
+func (r *Repository) SubscribeModeration(ctx context.Context) (<-chan domain.Moderation, error) {
+	return subscribe[domain.Moderation](ctx, r, r.moderationKey, "moderation")
+}
+
+func (r *Repository) muteKey(username string) string {
+	return fmt.Sprintf("%s:mute:%s", r.moderationKey, username)
+}
@@ -0,0 +1,75 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// publish sends the value as JSON to all subscribers of the channel, name describes the value in the logs.
+func (r *Repository) publish(ctx context.Context, channel string, name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		r.log.
+			WithError(err).
+			WithField(name, v).
+			Errorf("cannot marshal %s", name)
+		return err
+	}
+
+	err = r.c.Publish(ctx, channel, data).Err()
+	if err != nil {
+		r.log.
+			WithError(err).
+			WithField(name, v).
+			Errorf("cannot publish %s", name)
+		return err
+	}
+	return nil
+}
+
+// subscribe returns the values published to the channel, the returned channel is closed when the context is done.
+func subscribe[T any](ctx context.Context, r *Repository, channel string, name string) (<-chan T, error) {
+	sub := r.c.Subscribe(ctx, channel)
+	// wait for the confirmation, so the values published after the return are received
+	_, err := sub.Receive(ctx)
+	if err != nil {
+		r.log.
+			WithError(err).
+			Errorf("cannot subscribe to %s", name)
+		_ = sub.Close()
+		return nil, err
+	}
+
+	ch := make(chan T)
+	go func() {
+		defer close(ch)
+		defer sub.Close()
+
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var v T
+				err := json.Unmarshal([]byte(msg.Payload), &v)
+				if err != nil {
+					r.log.
+						WithError(err).
+						WithField("payload", msg.Payload).
+						Errorf("cannot unmarshal %s", name)
+					continue
+				}
+				select {
+				case ch <- v:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
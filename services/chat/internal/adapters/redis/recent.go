@@ -0,0 +1,112 @@
+package redis
+
+import (
+	"chat/internal/domain"
+	errs "chat/internal/repository/errs"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+const (
+	// recentMessages is the number of the last messages of the room kept in the recent set,
+	// it covers the messages produced to kafka but not yet saved to postgres by the storage service.
+	recentMessages = 200
+	// recentTTL removes the recent sets of the inactive rooms.
+	recentTTL = 24 * time.Hour
+)
+
+// SaveRecentMessage adds the message to the recent set of its room as soon as it is produced,
+// the cached lists are filled by the storage service only after the message is saved to postgres.
+// The members are scored by the ID, so the set is ordered like the messages.
+func (r *Repository) SaveRecentMessage(ctx context.Context, message domain.Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		r.log.
+			WithError(err).
+			WithField("message", message).
+			Error("cannot marshal message")
+		return err
+	}
+
+	key := r.recentKey(message.Room)
+	_, err = r.c.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.ZAdd(ctx, key, redis.Z{Score: float64(message.ID), Member: data})
+		p.ZRemRangeByRank(ctx, key, 0, -recentMessages-1)
+		p.Expire(ctx, key, recentTTL)
+		return nil
+	})
+	if err != nil {
+		r.log.
+			WithError(err).
+			WithField("message", message).
+			Error("cannot save recent message")
+		return err
+	}
+	return nil
+}
+
+// LoadRecentMessagesAfter returns the recent messages of the room sent after the message with the given ID.
+func (r *Repository) LoadRecentMessagesAfter(ctx context.Context, room string, after int64) ([]domain.Message, error) {
+	// the float scores of the large IDs are rounded, so the bound is inclusive and the IDs are compared exactly
+	messages, err := r.loadRecent(ctx, room, strconv.FormatFloat(float64(after), 'f', -1, 64), "+inf")
+	if err != nil {
+		return nil, err
+	}
+
+	res := messages[:0]
+	for _, m := range messages {
+		if m.ID > after {
+			res = append(res, m)
+		}
+	}
+	return res, nil
+}
+
+// LoadRecentMessage returns the recent message of the room with the given ID.
+func (r *Repository) LoadRecentMessage(ctx context.Context, room string, id int64) (domain.Message, error) {
+	score := strconv.FormatFloat(float64(id), 'f', -1, 64)
+	messages, err := r.loadRecent(ctx, room, score, score)
+	if err != nil {
+		return domain.Message{}, err
+	}
+
+	for _, m := range messages {
+		if m.ID == id {
+			return m, nil
+		}
+	}
+	return domain.Message{}, fmt.Errorf("recent message %d: %w", id, errs.ErrNotFound)
+}
+
+func (r *Repository) loadRecent(ctx context.Context, room string, min string, max string) ([]domain.Message, error) {
+	data, err := r.c.ZRangeByScore(ctx, r.recentKey(room), &redis.ZRangeBy{Min: min, Max: max}).Result()
+	if err != nil {
+		r.log.
+			WithError(err).
+			WithField("room", room).
+			Error("cannot load recent messages")
+		return nil, err
+	}
+
+	messages := make([]domain.Message, len(data))
+	for i, v := range data {
+		err = json.Unmarshal([]byte(v), &messages[i])
+		if err != nil {
+			r.log.
+				WithError(err).
+				WithField("room", room).
+				Error("cannot unmarshall message")
+			return nil, err
+		}
+	}
+	return messages, nil
+}
+
+// recentKey returns the key of the sorted set with the recent messages of the room.
+func (r *Repository) recentKey(room string) string {
+	return fmt.Sprintf("%s:recent:%s", r.key, room)
+}
@@ -2,8 +2,10 @@ package redis
 
 import (
 	"chat/internal/domain"
+	"chat/internal/tracing"
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 )
@@ -11,19 +13,36 @@ import (
 type Repository struct {
 	c   *redis.Client
 	key string
-	log logrus.FieldLogger
+	// moderationKey is the prefix of the mute keys and the channel of the moderator actions.
+	moderationKey string
+	// adminKey is the prefix of the read-only rooms set and the channel of the announcements.
+	adminKey string
+	log      logrus.FieldLogger
 }
 
 func NewRepository(cfg *Config) *Repository {
+	c := redis.NewClient(cfg.Opt)
+	c.AddHook(tracing.RedisHook{})
 	return &Repository{
-		c:   redis.NewClient(cfg.Opt),
-		key: cfg.Key,
-		log: cfg.Logger,
+		c:             c,
+		key:           cfg.Key,
+		moderationKey: cfg.ModerationKey,
+		adminKey:      cfg.AdminKey,
+		log:           cfg.Logger,
 	}
 }
 
-func (r *Repository) LoadMessages(ctx context.Context, count int) ([]domain.Message, error) {
-	res := r.c.LRange(ctx, r.key, 0, max(0, int64(count-1)))
+// Ping checks that the server responds.
+func (r *Repository) Ping(ctx context.Context) error {
+	return r.c.Ping(ctx).Err()
+}
+
+func (r *Repository) Close() error {
+	return r.c.Close()
+}
+
+func (r *Repository) LoadMessages(ctx context.Context, room string, count int) ([]domain.Message, error) {
+	res := r.c.LRange(ctx, r.roomKey(room), 0, max(0, int64(count-1)))
 	data, err := res.Result()
 	if err != nil {
 		r.log.
@@ -45,3 +64,8 @@ func (r *Repository) LoadMessages(ctx context.Context, count int) ([]domain.Mess
 	}
 	return messages, nil
 }
+
+// roomKey returns the key of the list with cached messages of the room.
+func (r *Repository) roomKey(room string) string {
+	return fmt.Sprintf("%s:%s", r.key, room)
+}
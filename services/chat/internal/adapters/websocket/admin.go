@@ -0,0 +1,195 @@
+package websocket
+
+import (
+	"chat/internal/adapters/websocket/syncmap"
+	"chat/internal/app"
+	"chat/internal/domain"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// adminConnection describes the connection to the replica for the operators.
+type adminConnection struct {
+	ID             string    `json:"id"`
+	Username       string    `json:"username"`
+	Room           string    `json:"room"`
+	Role           string    `json:"role"`
+	Addr           string    `json:"addr"`
+	ConnectedSince time.Time `json:"connected_since"`
+}
+
+type adminConnectionsResponse struct {
+	Connections []adminConnection `json:"connections"`
+}
+
+type adminAnnouncement struct {
+	Message string `json:"message"`
+	Room    string `json:"room,omitempty"`
+}
+
+type adminPurgeResponse struct {
+	Deleted int `json:"deleted"`
+}
+
+type adminReadOnly struct {
+	ReadOnly bool `json:"read_only"`
+}
+
+// adminAuth passes only the requests with the "Authorization: Bearer <token>" header.
+func adminAuth(token string, next http.Handler, log logrus.FieldLogger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(bearerToken(r)), []byte(token)) != 1 {
+			log.WithField("addr", clientIP(r)).
+				WithField("path", r.URL.Path).
+				Warn("unauthorized admin request")
+			writeError(w, http.StatusUnauthorized, errors.New("a valid admin token is required"), log)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// listConnections handles GET /admin/v1/connections, the response contains the connections to this replica.
+func listConnections(c *syncmap.ConnectionsMap, log logrus.FieldLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		infos := c.Infos()
+		sort.Slice(infos, func(i, j int) bool {
+			return infos[i].ConnectedAt.Before(infos[j].ConnectedAt)
+		})
+
+		res := adminConnectionsResponse{Connections: make([]adminConnection, 0, len(infos))}
+		for _, info := range infos {
+			res.Connections = append(res.Connections, adminConnection{
+				ID:             info.ID,
+				Username:       info.Username,
+				Room:           info.Room,
+				Role:           string(info.Role),
+				Addr:           info.Addr,
+				ConnectedSince: info.ConnectedAt,
+			})
+		}
+		writeJSON(w, http.StatusOK, res, log)
+	}
+}
+
+// disconnect handles DELETE /admin/v1/connections/{id}, the client may connect again.
+func disconnect(c *syncmap.ConnectionsMap, log logrus.FieldLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		conn, ok := c.LoadConnection(id)
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Errorf("connection %s not found", id), log)
+			return
+		}
+
+		closeData := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "disconnected by the operator")
+		closeConnection(conn, closeData, c, log)
+		log.WithField("uuid", id).Info("the connection is closed by the operator")
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// announce handles POST /admin/v1/announcements, the announcement is sent to the clients of all replicas.
+func announce(a App, log logrus.FieldLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body := adminAnnouncement{}
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize)).Decode(&body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("cannot decode announcement: %w", err), log)
+			return
+		}
+		if body.Room != "" && !roomNameRegexp.MatchString(body.Room) {
+			writeError(w, http.StatusBadRequest, errInvalidRoom, log)
+			return
+		}
+
+		err = a.Announce(r.Context(), domain.Announcement{Text: body.Message, Room: body.Room})
+		if errors.Is(err, app.ErrInvalidMessage) {
+			writeError(w, http.StatusBadRequest, err, log)
+			return
+		}
+		if err != nil {
+			log.WithError(err).Error("cannot send announcement")
+			writeError(w, http.StatusInternalServerError, errors.New("cannot send announcement"), log)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// purgeMessages handles DELETE /admin/v1/users/{username}/messages,
+// the response contains the number of the deleted messages.
+func purgeMessages(a App, log logrus.FieldLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := r.PathValue("username")
+		deleted, err := a.PurgeMessages(r.Context(), username)
+		if err != nil {
+			log.WithError(err).WithField("username", username).Error("cannot purge messages")
+			writeError(w, http.StatusInternalServerError, errors.New("cannot purge messages"), log)
+			return
+		}
+
+		log.WithField("username", username).
+			WithField("deleted", deleted).
+			Info("the messages of the user are purged")
+		writeJSON(w, http.StatusOK, adminPurgeResponse{Deleted: deleted}, log)
+	}
+}
+
+// setReadOnly handles PUT /admin/v1/rooms/{room}/read-only.
+func setReadOnly(a App, log logrus.FieldLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		room := r.PathValue("room")
+		if !roomNameRegexp.MatchString(room) {
+			writeError(w, http.StatusBadRequest, errInvalidRoom, log)
+			return
+		}
+
+		body := adminReadOnly{}
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize)).Decode(&body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("cannot decode read-only mode: %w", err), log)
+			return
+		}
+
+		err = a.SetReadOnly(r.Context(), room, body.ReadOnly)
+		if err != nil {
+			log.WithError(err).WithField("room", room).Error("cannot set read-only mode")
+			writeError(w, http.StatusInternalServerError, errors.New("cannot set read-only mode"), log)
+			return
+		}
+
+		log.WithField("room", room).
+			WithField("read_only", body.ReadOnly).
+			Info("read-only mode of the room is changed")
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// sendAnnouncement sends the announcement to the clients in its room, or to all clients if the room is not set.
+func sendAnnouncement(ann domain.Announcement, c *syncmap.ConnectionsMap, l logrus.FieldLogger) {
+	frame := announcementFrame{Type: frameTypeAnnouncement, Username: announcementUsername, Text: ann.Text, Room: ann.Room}
+	data, err := json.Marshal(frame)
+	if err != nil {
+		l.WithError(err).WithField("announcement", ann).Error("cannot marshal data to json")
+		return
+	}
+
+	ch := c.LoadAllConnections()
+	if ann.Room != "" {
+		ch = c.LoadRoomConnections(ann.Room)
+	}
+	for conn := range ch {
+		err = c.WriteMessage(conn, websocket.TextMessage, data)
+		if err != nil {
+			l.WithError(err).WithField("data", string(data)).Error("cannot send the announcement")
+		}
+	}
+}
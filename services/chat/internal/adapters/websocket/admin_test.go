@@ -0,0 +1,202 @@
+package websocket
+
+import (
+	"bytes"
+	"chat/internal/adapters/websocket/mocks"
+	"chat/internal/adapters/websocket/syncmap"
+	"chat/internal/app"
+	"chat/internal/domain"
+	"context"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const adminAPIPath = "../../../../../api/admin.yaml"
+
+func TestAdmin_ResponsesMatchOpenAPI(t *testing.T) {
+	type testcase struct {
+		name   string
+		method string
+		url    string
+		token  string
+		body   string
+		setup  func(a *mocks.App)
+		status int
+	}
+
+	const token = "secret"
+
+	tests := []testcase{
+		{
+			name:   "connections",
+			method: http.MethodGet,
+			url:    "/admin/v1/connections",
+			token:  token,
+			setup:  func(*mocks.App) {},
+			status: http.StatusOK,
+		},
+		{
+			name:   "connections without token",
+			method: http.MethodGet,
+			url:    "/admin/v1/connections",
+			setup:  func(*mocks.App) {},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "connections with wrong token",
+			method: http.MethodGet,
+			url:    "/admin/v1/connections",
+			token:  "wrong",
+			setup:  func(*mocks.App) {},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "metrics",
+			method: http.MethodGet,
+			url:    "/metrics",
+			token:  token,
+			setup:  func(*mocks.App) {},
+			status: http.StatusOK,
+		},
+		{
+			name:   "metrics without token",
+			method: http.MethodGet,
+			url:    "/metrics",
+			setup:  func(*mocks.App) {},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "disconnect unknown connection",
+			method: http.MethodDelete,
+			url:    "/admin/v1/connections/0b6f9a4e-6d1c-4b8e-9a57-0c2e5e2d7f10",
+			token:  token,
+			setup:  func(*mocks.App) {},
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "announcement",
+			method: http.MethodPost,
+			url:    "/admin/v1/announcements",
+			token:  token,
+			body:   `{"message": "maintenance at 22:00", "room": "general"}`,
+			setup: func(a *mocks.App) {
+				a.On("Announce", mock.Anything, domain.Announcement{Text: "maintenance at 22:00", Room: "general"}).Return(nil)
+			},
+			status: http.StatusAccepted,
+		},
+		{
+			name:   "empty announcement",
+			method: http.MethodPost,
+			url:    "/admin/v1/announcements",
+			token:  token,
+			body:   `{"message": " "}`,
+			setup: func(a *mocks.App) {
+				a.On("Announce", mock.Anything, domain.Announcement{Text: " "}).Return(app.ErrInvalidMessage)
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "purge messages",
+			method: http.MethodDelete,
+			url:    "/admin/v1/users/danil/messages",
+			token:  token,
+			setup: func(a *mocks.App) {
+				a.On("PurgeMessages", mock.Anything, "danil").Return(42, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "purge messages with repo error",
+			method: http.MethodDelete,
+			url:    "/admin/v1/users/danil/messages",
+			token:  token,
+			setup: func(a *mocks.App) {
+				a.On("PurgeMessages", mock.Anything, "danil").Return(0, app.ErrInternal)
+			},
+			status: http.StatusInternalServerError,
+		},
+		{
+			name:   "read-only",
+			method: http.MethodPut,
+			url:    "/admin/v1/rooms/news/read-only",
+			token:  token,
+			body:   `{"read_only": true}`,
+			setup: func(a *mocks.App) {
+				a.On("SetReadOnly", mock.Anything, "news", true).Return(nil)
+			},
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "read-only without body",
+			method: http.MethodPut,
+			url:    "/admin/v1/rooms/news/read-only",
+			token:  token,
+			setup:  func(*mocks.App) {},
+			status: http.StatusBadRequest,
+		},
+	}
+
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	doc, err := loader.LoadFromFile(adminAPIPath)
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(context.Background()))
+	router, err := legacy.NewRouter(doc)
+	require.NoError(t, err)
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	connections := syncmap.New()
+	connections.StoreWithInfo(new(websocket.Conn), syncmap.Info{
+		ID:          "3f1d2c4b-5a6e-4f70-8b9c-0d1e2f3a4b5c",
+		Username:    "danil",
+		Room:        domain.DefaultRoom,
+		Role:        domain.RoleMember,
+		Addr:        "127.0.0.1",
+		ConnectedAt: time.Now(),
+	})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := mocks.NewApp(t)
+			test.setup(a)
+			handler := newAdminRouter(a, connections, &Config{AdminToken: token}, log)
+
+			req := httptest.NewRequest(test.method, "http://localhost:8081"+test.url, bytes.NewBufferString(test.body))
+			if test.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if test.token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, test.status, rec.Code)
+
+			route, params, err := router.FindRoute(req)
+			require.NoError(t, err)
+			err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: &openapi3filter.RequestValidationInput{
+					Request:    req,
+					PathParams: params,
+					Route:      route,
+				},
+				Status: rec.Code,
+				Header: rec.Header(),
+				Body:   io.NopCloser(rec.Body),
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package websocket
+
+import (
+	"chat/internal/adapters/websocket/syncmap"
+	"chat/internal/domain"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"sort"
+	"strings"
+)
+
+// command is a slash command handled by the server.
+type command struct {
+	name  string
+	usage string
+	help  string
+	// role is the lowest role allowed to run the command.
+	role domain.Role
+	// run handles the command, the returned error is sent back to the sender.
+	run func(cc commandContext, args []string) error
+}
+
+// commandContext is the connection the command is received from.
+type commandContext struct {
+	// ctx is the context of the connection, it is done when the connection is closed.
+	ctx    context.Context
+	sender *websocket.Conn
+	info   syncmap.Info
+	c      *syncmap.ConnectionsMap
+	a      App
+	l      logrus.FieldLogger
+}
+
+// reply sends the text back to the sender of the command.
+func (cc commandContext) reply(name string, text string) {
+	data, err := json.Marshal(commandFrame{Type: frameTypeCommand, Command: name, Text: text})
+	if err != nil {
+		cc.l.WithError(err).WithField("command", name).Error("cannot marshal data to json")
+		return
+	}
+
+	err = cc.c.WriteMessage(cc.sender, websocket.TextMessage, data)
+	if err != nil {
+		cc.l.WithError(err).WithField("command", name).Error("cannot send the command reply")
+	}
+}
+
+// commandRegistry dispatches the commands received in command frames or in message texts.
+type commandRegistry struct {
+	commands map[string]command
+}
+
+// newCommandRegistry creates the registry with the help and moderation commands.
+func newCommandRegistry() *commandRegistry {
+	r := &commandRegistry{commands: make(map[string]command)}
+	r.register(command{
+		name:  "help",
+		usage: "/help [command]",
+		help:  "show the commands available to you",
+		role:  domain.RoleMember,
+		run:   r.runHelp,
+	})
+	for _, cmd := range moderationCommands() {
+		r.register(cmd)
+	}
+	return r
+}
+
+func (r *commandRegistry) register(cmd command) {
+	r.commands[cmd.name] = cmd
+}
+
+// has reports whether the command with the name is registered.
+func (r *commandRegistry) has(name string) bool {
+	_, ok := r.commands[name]
+	return ok
+}
+
+// dispatch runs the command if the role of the sender allows it,
+// errors are sent back to the sender as error frames.
+func (r *commandRegistry) dispatch(cc commandContext, name string, args []string) {
+	cmd, ok := r.commands[name]
+	if !ok {
+		_ = sendError(cc.sender, fmt.Errorf("unknown command /%s, see /help", name), cc.c, cc.l)
+		return
+	}
+	if !cc.info.Role.AtLeast(cmd.role) {
+		cc.l.WithField("command", name).
+			WithField("username", cc.info.Username).
+			WithField("role", cc.info.Role).
+			Info("the command is forbidden for the user")
+		_ = sendError(cc.sender, fmt.Errorf("/%s is available only to %ss", name, cmd.role), cc.c, cc.l)
+		return
+	}
+
+	err := cmd.run(cc, args)
+	if err != nil {
+		_ = sendError(cc.sender, err, cc.c, cc.l)
+	}
+}
+
+// help describes the commands available to the role, one command per line.
+func (r *commandRegistry) help(role domain.Role) string {
+	cmds := make([]command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		if role.AtLeast(cmd.role) {
+			cmds = append(cmds, cmd)
+		}
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].name < cmds[j].name })
+
+	lines := make([]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		lines = append(lines, fmt.Sprintf("%s - %s", cmd.usage, cmd.help))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *commandRegistry) runHelp(cc commandContext, args []string) error {
+	if len(args) == 0 {
+		cc.reply("help", r.help(cc.info.Role))
+		return nil
+	}
+
+	name := strings.TrimPrefix(args[0], "/")
+	cmd, ok := r.commands[name]
+	if !ok || !cc.info.Role.AtLeast(cmd.role) {
+		return fmt.Errorf("unknown command /%s, see /help", name)
+	}
+	cc.reply("help", fmt.Sprintf("%s - %s", cmd.usage, cmd.help))
+	return nil
+}
+
+// parseCommand splits the slash command in the message text into the name and the arguments,
+// ok is false if the text is not a slash command.
+func parseCommand(text string) (name string, args []string, ok bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", nil, false
+	}
+	name, ok = strings.CutPrefix(fields[0], "/")
+	if !ok || name == "" {
+		return "", nil, false
+	}
+	return name, fields[1:], true
+}
+
+// moderationCommands returns the commands of the moderators.
+func moderationCommands() []command {
+	cmds := []struct {
+		action string
+		usage  string
+		help   string
+	}{
+		{domain.ModerationMute, "/mute <username> <duration> [reason]", "forbid the user to send messages, e.g. for 10m, 2h or 1d"},
+		{domain.ModerationUnmute, "/unmute <username>", "allow the muted user to send messages"},
+		{domain.ModerationKick, "/kick <username> [reason]", "disconnect the user"},
+		{domain.ModerationBan, "/ban <username> [reason]", "disconnect the user and forbid to connect"},
+		{domain.ModerationUnban, "/unban <username>", "allow the banned user to connect"},
+	}
+
+	res := make([]command, 0, len(cmds))
+	for _, cmd := range cmds {
+		action, usage := cmd.action, cmd.usage
+		res = append(res, command{
+			name:  action,
+			usage: usage,
+			help:  cmd.help,
+			role:  domain.RoleModerator,
+			run: func(cc commandContext, args []string) error {
+				m, err := parseModeration(action, args)
+				if err != nil {
+					return fmt.Errorf("%w, usage: %s", err, usage)
+				}
+				moderate(cc.ctx, cc.sender, m, cc.c, cc.a, cc.l)
+				return nil
+			},
+		})
+	}
+	return res
+}
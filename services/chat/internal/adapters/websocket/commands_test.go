@@ -0,0 +1,57 @@
+package websocket
+
+import (
+	"chat/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestParseCommand(t *testing.T) {
+	type testcase struct {
+		text      string
+		name      string
+		args      []string
+		isCommand bool
+	}
+
+	tests := []testcase{
+		{text: "/help", name: "help", args: []string{}, isCommand: true},
+		{text: "  /help   mute ", name: "help", args: []string{"mute"}, isCommand: true},
+		{text: "/me waves", name: "me", args: []string{"waves"}, isCommand: true},
+		{text: "/"},
+		{text: "/ help"},
+		{text: "hello /help"},
+		{text: ""},
+	}
+
+	for _, test := range tests {
+		name, args, isCommand := parseCommand(test.text)
+		assert.Equal(t, test.isCommand, isCommand, test.text)
+		assert.Equal(t, test.name, name, test.text)
+		assert.Equal(t, test.args, args, test.text)
+	}
+}
+
+func TestCommandRegistry_Help(t *testing.T) {
+	type testcase struct {
+		role     domain.Role
+		commands []string
+	}
+
+	tests := []testcase{
+		{role: domain.RoleMember, commands: []string{"/help"}},
+		{role: domain.RoleModerator, commands: []string{"/ban", "/help", "/kick", "/mute", "/unban", "/unmute"}},
+		{role: domain.RoleAdmin, commands: []string{"/ban", "/help", "/kick", "/mute", "/unban", "/unmute"}},
+	}
+
+	commands := newCommandRegistry()
+	for _, test := range tests {
+		lines := strings.Split(commands.help(test.role), "\n")
+		names := make([]string, 0, len(lines))
+		for _, line := range lines {
+			names = append(names, strings.Fields(line)[0])
+		}
+		assert.Equal(t, test.commands, names, test.role)
+	}
+}
@@ -1,7 +1,36 @@
 package websocket
 
+import "time"
+
 type Config struct {
 	Port            string
 	WriteBufferSize int
 	ReadBufferSize  int
+	// ReadLimit is the maximal size of a frame received from a client in bytes.
+	ReadLimit int64
+
+	// ConnectionLimit limits the frames of a single connection.
+	ConnectionLimit RateLimit
+	// UserLimit and IPLimit limit the frames of a user and of an IP address
+	// across all connections and replicas.
+	UserLimit RateLimit
+	IPLimit   RateLimit
+	// MaxConnectionsPerIP is the maximal number of open connections of an IP address
+	// to the replica, 0 disables the limit.
+	MaxConnectionsPerIP int
+
+	// AdminPort is the port of the admin API, the requests to it must have the AdminToken bearer token.
+	AdminPort  string
+	AdminToken string
+
+	// ShutdownDelay is the time between failing the readiness probe and closing the listeners on shutdown.
+	ShutdownDelay time.Duration
+	// ShutdownTimeout limits the time of draining the connections after the delay.
+	ShutdownTimeout time.Duration
+}
+
+// RateLimit is a token bucket refilled at Rate tokens per second, up to Burst tokens.
+type RateLimit struct {
+	Rate  float64
+	Burst int
 }
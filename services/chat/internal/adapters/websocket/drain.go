@@ -0,0 +1,71 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// goingAwayReason is sent in the close frames on shutdown, the clients may reconnect to another replica.
+const goingAwayReason = "server is shutting down, reconnect"
+
+var errShuttingDown = errors.New("server is shutting down")
+
+// drain tracks the frames handled in the background, so the messages received before the shutdown
+// are saved and broadcast before the connections are closed.
+type drain struct {
+	mx      sync.Mutex
+	closing bool
+	pending int
+	// idle is closed when the drain is closing and there are no pending frames.
+	idle chan struct{}
+}
+
+func newDrain() *drain {
+	return &drain{idle: make(chan struct{})}
+}
+
+// run handles the frame in the background, the frames received after the drain began are rejected.
+func (d *drain) run(handle func()) error {
+	d.mx.Lock()
+	if d.closing {
+		d.mx.Unlock()
+		return errShuttingDown
+	}
+	d.pending++
+	d.mx.Unlock()
+
+	go func() {
+		defer d.release()
+		handle()
+	}()
+	return nil
+}
+
+func (d *drain) release() {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.pending--
+	if d.closing && d.pending == 0 {
+		close(d.idle)
+	}
+}
+
+// wait rejects the new frames and waits for the pending ones until ctx is done.
+func (d *drain) wait(ctx context.Context) error {
+	d.mx.Lock()
+	if !d.closing {
+		d.closing = true
+		if d.pending == 0 {
+			close(d.idle)
+		}
+	}
+	d.mx.Unlock()
+
+	select {
+	case <-d.idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,58 @@
+package websocket
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDrain(t *testing.T) {
+	type testcase struct {
+		name    string
+		frames  int
+		timeout time.Duration
+		// blocked frames are not finished until the drain is waited for
+		blocked bool
+		err     error
+	}
+
+	tests := []testcase{
+		{name: "no frames", frames: 0, timeout: time.Second},
+		{name: "pending frames", frames: 3, timeout: time.Second},
+		{name: "deadline", frames: 1, timeout: 10 * time.Millisecond, blocked: true, err: context.DeadlineExceeded},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := newDrain()
+			release := make(chan struct{})
+			var handled atomic.Int32
+			for range test.frames {
+				err := d.run(func() {
+					<-release
+					handled.Add(1)
+				})
+				require.NoError(t, err)
+			}
+			if !test.blocked {
+				close(release)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), test.timeout)
+			defer cancel()
+			err := d.wait(ctx)
+			assert.ErrorIs(t, err, test.err)
+			if test.err == nil {
+				assert.Equal(t, int32(test.frames), handled.Load())
+			} else {
+				close(release)
+			}
+
+			// the frames received after the drain began are rejected
+			assert.ErrorIs(t, d.run(func() {}), errShuttingDown)
+		})
+	}
+}
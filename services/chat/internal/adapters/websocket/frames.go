@@ -0,0 +1,169 @@
+package websocket
+
+import (
+	"chat/internal/domain"
+	"errors"
+)
+
+const (
+	frameTypeMessage      = "message"
+	frameTypeTyping       = "typing"
+	frameTypeRead         = "read"
+	frameTypeUnread       = "unread"
+	frameTypeGap          = "gap"
+	frameTypeReaction     = "reaction"
+	frameTypeThread       = "thread"
+	frameTypeMention      = "mention"
+	frameTypeMentions     = "mentions"
+	frameTypeError        = "error"
+	frameTypeModeration   = "moderation"
+	frameTypeAnnouncement = "announcement"
+	frameTypeCommand      = "command"
+)
+
+// errorUsername is the username of the error frames,
+// so clients unaware of the error frames show them as messages.
+const errorUsername = "WRONG MESSAGE ERROR"
+
+// announcementUsername is the username of the announcement frames,
+// so clients unaware of the announcements show them as messages.
+const announcementUsername = "ANNOUNCEMENT"
+
+// messageFrame is a chat message sent to clients.
+type messageFrame struct {
+	Type string `json:"type"`
+	domain.Message
+	// History is set for messages sent from the chat history on connect.
+	History bool `json:"history,omitempty"`
+}
+
+// typingFrame notifies room members that the user is typing a message.
+// Typing frames are relayed as is and never persisted.
+type typingFrame struct {
+	Type     string `json:"type"`
+	Username string `json:"username"`
+	Room     string `json:"room"`
+}
+
+// unreadFrame is sent to a client on connect, it contains the last message read
+// by the user in the current room and the number of unread messages in every room.
+type unreadFrame struct {
+	Type     string         `json:"type"`
+	Room     string         `json:"room"`
+	LastRead int64          `json:"last_read"`
+	Unread   map[string]int `json:"unread"`
+}
+
+// gapFrame is sent to the client resuming the connection before the missed messages if not all of them
+// are sent, the messages of the room between After and Before can be loaded with the history API.
+type gapFrame struct {
+	Type   string `json:"type"`
+	Room   string `json:"room"`
+	After  int64  `json:"after"`
+	Before int64  `json:"before"`
+}
+
+// reactionFrame adds the reaction to the message or removes it,
+// it is received from clients and relayed to the room members.
+type reactionFrame struct {
+	Type string `json:"type"`
+	domain.Reaction
+}
+
+// threadFrame is a request for the thread of the message with ID,
+// the same frame with the loaded messages is sent back.
+type threadFrame struct {
+	Type     string           `json:"type"`
+	ID       int64            `json:"id"`
+	Messages []domain.Message `json:"messages"`
+}
+
+// mentionsFrame is a request for the last messages mentioning the user,
+// the same frame with the loaded messages is sent back.
+type mentionsFrame struct {
+	Type     string           `json:"type"`
+	Messages []domain.Message `json:"messages"`
+}
+
+// errorFrame is sent to the client when its frame cannot be handled,
+// Errors describe the fields of the frame that don't match the schema.
+type errorFrame struct {
+	Type     string       `json:"type"`
+	Username string       `json:"username"`
+	Text     string       `json:"message"`
+	Errors   []fieldError `json:"errors,omitempty"`
+}
+
+// moderationFrame notifies the room and the target user about the moderator action,
+// Duration is the mute duration in seconds.
+type moderationFrame struct {
+	Type      string `json:"type"`
+	Action    string `json:"action"`
+	Moderator string `json:"moderator"`
+	Target    string `json:"target"`
+	Room      string `json:"room"`
+	Duration  int64  `json:"duration,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// announcementFrame is the system announcement of the operators.
+type announcementFrame struct {
+	Type     string `json:"type"`
+	Username string `json:"username"`
+	Text     string `json:"message"`
+	Room     string `json:"room,omitempty"`
+}
+
+// commandFrame is the reply to the command of the client, it is sent only to the sender.
+type commandFrame struct {
+	Type    string `json:"type"`
+	Command string `json:"command"`
+	Text    string `json:"message"`
+}
+
+func newErrorFrame(err error) errorFrame {
+	frame := errorFrame{Type: frameTypeError, Username: errorUsername, Text: err.Error()}
+	var fErr *frameError
+	if errors.As(err, &fErr) {
+		frame.Text = fErr.msg
+		frame.Errors = fErr.fields
+	}
+	return frame
+}
+
+func newMessageFrame(msg domain.Message) messageFrame {
+	return messageFrame{Type: frameTypeMessage, Message: msg}
+}
+
+// newMentionFrame notifies the mentioned user about the message,
+// it is sent to the user's connections in the other rooms.
+func newMentionFrame(msg domain.Message) messageFrame {
+	return messageFrame{Type: frameTypeMention, Message: msg}
+}
+
+func newUnreadFrame(room string, markers []domain.ReadMarker) unreadFrame {
+	frame := unreadFrame{
+		Type:   frameTypeUnread,
+		Room:   room,
+		Unread: make(map[string]int, len(markers)),
+	}
+	for _, m := range markers {
+		frame.Unread[m.Room] = m.Unread
+		if m.Room == room {
+			frame.LastRead = m.MessageID
+		}
+	}
+	return frame
+}
+
+func newModerationFrame(m domain.Moderation) moderationFrame {
+	return moderationFrame{
+		Type:      frameTypeModeration,
+		Action:    m.Action,
+		Moderator: m.Moderator,
+		Target:    m.Target,
+		Room:      m.Room,
+		Duration:  int64(m.Duration.Seconds()),
+		Reason:    m.Reason,
+	}
+}
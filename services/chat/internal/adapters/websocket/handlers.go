@@ -2,30 +2,95 @@ package websocket
 
 import (
 	"chat/internal/adapters/websocket/syncmap"
+	"chat/internal/app"
 	"chat/internal/domain"
+	"chat/internal/logging"
+	"chat/internal/metrics"
+	"chat/internal/tracing"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+	"unicode"
 )
 
-func createConnection(a App, u *websocket.Upgrader, c *syncmap.ConnectionsMap, log logrus.FieldLogger) http.HandlerFunc {
+const (
+	minUsernameLength = 3
+	// maxEmojiLength fits emoji sequences with modifiers and joiners.
+	maxEmojiLength = 32
+)
+
+var (
+	roomNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+	errInvalidRoom = errors.New("room name must consist of 1-64 latin letters, digits, '_' or '-'")
+	errAnonymous   = errors.New("connect with the username parameter to send messages and reactions")
+)
+
+func createConnection(
+	a App, commands *commandRegistry, limits *sharedLimits, ips *ipConnections, d *drain,
+	u *websocket.Upgrader, c *syncmap.ConnectionsMap,
+	cfg *Config, log logrus.FieldLogger,
+) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// --- OPEN NEW CONNECTION
-		uid, conn, cancel, err := openNewConnection(log, u, w, r, c)
-		defer cancel()
+		addr := clientIP(r)
+		if !ips.acquire(addr) {
+			log.WithField("addr", addr).
+				Info("too many connections from the address")
+			http.Error(w, errTooManyConnections.Error(), http.StatusTooManyRequests)
+			return
+		}
+		defer ips.release(addr)
+
+		after, err := resumeAfter(r)
 		if err != nil {
+			log.WithError(err).
+				Info("wrong connection parameters")
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+
+		uid, conn, cancel, err := openNewConnection(r.Context(), a, log, u, w, r, c)
+		if err != nil {
+			return
+		}
+		defer cancel()
+		// frames over the limit close the connection with the "message too big" status
+		conn.SetReadLimit(cfg.ReadLimit)
+		info, _ := c.Info(conn)
+		// the frames of the connection are handled with its fields in the context, the context
+		// is cancelled when the connection is closed, so the loads for the closed connection are
+		// stopped while the messages and reactions already received are still saved
+		ctx := logging.WithFields(r.Context(), logrus.Fields{
+			"connection_id": info.ID,
+			"username":      info.Username,
+			"room":          info.Room,
+		})
+		log := logging.FromContext(ctx, log)
+		limiter := newFrameLimiter(limits, cfg.ConnectionLimit, addr)
+		cc := commandContext{ctx: ctx, sender: conn, info: info, c: c, a: a, l: log}
 		// --- OPEN NEW CONNECTION
 
+		// --- SENDING UNREAD COUNTS
+		err = sendUnreadCounts(ctx, a, log, uid, conn, c)
+		if err != nil {
+			return
+		}
+		// --- SENDING UNREAD COUNTS
+
 		// --- LOADING LAST MESSAGES
 		log.WithField("uuid", uid.ID()).
 			Info("start loading last messages")
-		err = loadLastMessages(a, log, uid, conn)
+		err = loadLastMessages(ctx, a, log, uid, conn, c, after)
 		if err != nil {
 			return
 		}
@@ -38,9 +103,8 @@ func createConnection(a App, u *websocket.Upgrader, c *syncmap.ConnectionsMap, l
 			Info("start listening messages")
 		for {
 			messageType, data, err := conn.ReadMessage()
-			log.WithField("uuid", uid.ID()).
-				WithField("data", string(data)).
-				Info("got message")
+			log.WithField("data", string(data)).
+				Debug("got message")
 
 			if err != nil || messageType == websocket.CloseMessage {
 				log.WithError(err).
@@ -50,50 +114,127 @@ func createConnection(a App, u *websocket.Upgrader, c *syncmap.ConnectionsMap, l
 				return
 			}
 
-			err = validateMessage(data)
-			if err != nil {
-				log.WithError(err).
-					WithField("uuid", uid.ID()).
-					Info("message doesnt pass validation, receiving an error message")
-
-				msg := domain.Message{
-					Username: "WRONG MESSAGE ERROR",
-					Text:     err.Error(),
-				}
-
-				data, err := json.Marshal(msg)
-				if err != nil {
-					log.WithError(err).
-						WithField("uuid", uid.ID()).
-						WithField("msg", msg).
-						Error("cannot marshal data to json")
+			var frame inboundFrame
+			err = limiter.allowFrame()
+			if err == nil {
+				frame, err = decodeFrame(data)
+			}
+			if err == nil && frame.Type == frameTypeMessage {
+				metrics.MessagesReceived.WithLabelValues(metrics.TransportWebsocket).Inc()
+			}
+			if err == nil {
+				// the frames are sent on behalf of the user of the connection, whatever username they carry
+				frame.Username = info.Username
+				err = limiter.allow(ctx, frame.Type, info.Username)
+			}
+			if err == nil {
+				switch frame.Type {
+				case frameTypeTyping:
+					sendTyping(conn, c, log)
+					continue
+				case frameTypeRead:
+					markRead(ctx, conn, frame, c, a, log)
 					continue
+				case frameTypeThread:
+					err = d.run(func() { sendThread(ctx, conn, frame, c, a, log) })
+				case frameTypeMentions:
+					err = d.run(func() { sendMentions(ctx, conn, c, a, log) })
+				case frameTypeReaction:
+					err = checkSender(info)
+					if err == nil {
+						err = checkReaction(frame.reaction())
+					}
+					if err == nil {
+						err = d.run(func() {
+							ctx, _ := startFrameSpan(context.WithoutCancel(ctx), frame.Type, info)
+							saveAndSendReaction(ctx, conn, frame, c, a, log)
+						})
+					}
+				case frameTypeCommand:
+					err = d.run(func() { commands.dispatch(cc, frame.Command, frame.Args) })
+				default:
+					// the commands typed as messages are dispatched for the clients without command frames
+					name, args, isCommand := parseCommand(frame.Text)
+					if isCommand && commands.has(name) {
+						err = d.run(func() { commands.dispatch(cc, name, args) })
+						break
+					}
+					err = checkSender(info)
+					if err == nil {
+						err = checkMessage(frame.message())
+					}
+					if err == nil {
+						err = d.run(func() {
+							ctx, _ := startFrameSpan(context.WithoutCancel(ctx), frame.Type, info)
+							saveAndSendMessage(ctx, conn, frame, c, a, log)
+						})
+					}
 				}
-
-				err = conn.WriteMessage(websocket.TextMessage, data)
-				if err != nil {
-					log.WithError(err).
-						WithField("uuid", uid.ID()).
-						Error("cannot send message to client")
-					return
+				if err == nil {
+					continue
 				}
-				continue
 			}
 
-			go saveAndSendMessage(data, c, a, log)
+			if errors.Is(err, errShuttingDown) {
+				log.WithField("uuid", uid.ID()).
+					Info("server is shutting down, the frame is rejected")
+			} else if errors.Is(err, errRateLimited) {
+				metrics.MessagesRejected.WithLabelValues(metrics.ReasonRateLimited).Inc()
+				log.WithField("uuid", uid.ID()).
+					WithField("addr", addr).
+					Info("frame is rate limited, receiving an error message")
+			} else if errors.Is(err, errAnonymous) {
+				metrics.MessagesRejected.WithLabelValues(metrics.ReasonUnauthorized).Inc()
+				log.WithField("uuid", uid.ID()).
+					Info("client without username cannot send the frame, receiving an error message")
+			} else {
+				metrics.MessagesRejected.WithLabelValues(metrics.ReasonInvalid).Inc()
+				log.WithError(err).
+					WithField("uuid", uid.ID()).
+					Info("message doesnt pass validation, receiving an error message")
+			}
+			err = sendError(conn, err, c, log)
+			if err != nil {
+				return
+			}
 		}
 		// --- LISTENING MESSAGES
 	}
 }
 
 func openNewConnection(
-	log logrus.FieldLogger, u *websocket.Upgrader,
+	ctx context.Context, a App, log logrus.FieldLogger, u *websocket.Upgrader,
 	w http.ResponseWriter, r *http.Request,
 	c *syncmap.ConnectionsMap,
 ) (uid uuid.UUID, conn *websocket.Conn, cancelFunc func(), err error) {
 	uid = uuid.New()
 	log.WithField("uuid", uid.ID()).
 		Info("trying to open new websocket connection")
+	info, err := connectionInfo(r)
+	if err != nil {
+		log.WithError(err).
+			WithField("uuid", uid.ID()).
+			Info("wrong connection parameters")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	info.Role, err = a.Authenticate(ctx, info.Username, bearerToken(r))
+	if err != nil {
+		status := authStatus(err)
+		log.WithError(err).
+			WithField("uuid", uid.ID()).
+			WithField("username", info.Username).
+			Info("cannot authenticate the user")
+		if status == http.StatusInternalServerError {
+			http.Error(w, "cannot authenticate the user", status)
+		} else {
+			http.Error(w, err.Error(), status)
+		}
+		return
+	}
+	info.ID = uid.String()
+	info.Addr = clientIP(r)
+	info.ConnectedAt = time.Now()
 	conn, err = u.Upgrade(w, r, nil)
 	if err != nil {
 		log.WithError(err).
@@ -101,8 +242,11 @@ func openNewConnection(
 			Error("cannot upgrade connection to websocket")
 		return
 	}
-	c.Store(conn)
+	c.StoreWithInfo(conn, info)
 	log.WithField("uuid", uid.ID()).
+		WithField("username", info.Username).
+		WithField("room", info.Room).
+		WithField("role", info.Role).
 		Info("store the connection")
 	return uid, conn, func() {
 		c.Delete(conn)
@@ -117,11 +261,66 @@ func openNewConnection(
 	}, nil
 }
 
-func loadLastMessages(a App, log logrus.FieldLogger, uid uuid.UUID, conn *websocket.Conn) error {
-	messages, err := a.LoadLastMessages()
+// connectionInfo reads the client parameters passed in the query of the connection request.
+func connectionInfo(r *http.Request) (syncmap.Info, error) {
+	query := r.URL.Query()
+	info := syncmap.Info{
+		Username: query.Get("username"),
+		Room:     query.Get("room"),
+	}
+
+	if info.Room == "" {
+		info.Room = domain.DefaultRoom
+	}
+	if !roomNameRegexp.MatchString(info.Room) {
+		return syncmap.Info{}, errInvalidRoom
+	}
+
+	if info.Username != "" && len(info.Username) < minUsernameLength {
+		return syncmap.Info{}, fmt.Errorf("username length must be at least %d characters", minUsernameLength)
+	}
+	err := app.CheckUsername(info.Username)
+	if err != nil {
+		return syncmap.Info{}, err
+	}
+	return info, nil
+}
+
+// resumeAfter reads the ID of the last message received by the client before it reconnected,
+// it is 0 for the new clients.
+func resumeAfter(r *http.Request) (int64, error) {
+	value := r.URL.Query().Get("after")
+	if value == "" {
+		return 0, nil
+	}
+	after, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || after < 0 {
+		return 0, errors.New("after must be a non-negative message ID")
+	}
+	return after, nil
+}
+
+// loadLastMessages sends the last messages of the room to the client,
+// the client resuming the connection gets only the messages sent after the given one.
+func loadLastMessages(
+	ctx context.Context, a App, log logrus.FieldLogger, uid uuid.UUID,
+	conn *websocket.Conn, c *syncmap.ConnectionsMap, after int64,
+) error {
+	info, _ := c.Info(conn)
+	var (
+		messages  []domain.Message
+		truncated bool
+		err       error
+	)
+	if after > 0 {
+		messages, truncated, err = a.LoadMissedMessages(ctx, info.Room, after)
+	} else {
+		messages, err = a.LoadLastMessages(ctx, info.Room)
+	}
 	if err != nil {
 		defer func() {
-			err = conn.WriteMessage(
+			err = c.WriteMessage(
+				conn,
 				websocket.CloseInternalServerErr,
 				[]byte(fmt.Sprintf("cannot load last messages: %s", err.Error())),
 			)
@@ -138,24 +337,55 @@ func loadLastMessages(a App, log logrus.FieldLogger, uid uuid.UUID, conn *websoc
 		return err
 	}
 
+	if truncated {
+		err = sendGap(gapFrame{Type: frameTypeGap, Room: info.Room, After: after, Before: messages[0].ID}, log, uid, conn, c)
+		if err != nil {
+			return err
+		}
+	}
+
 	log.WithField("uuid", uid.ID()).
 		Info("start sending last messages")
-	err = saveLastMessages(messages, log, uid, conn)
+	err = saveLastMessages(messages, log, uid, conn, c)
 	log.WithField("uuid", uid.ID()).
 		Info("last messages have been sent")
 	return err
 }
 
-func saveLastMessages(messages []domain.Message, log logrus.FieldLogger, uid uuid.UUID, conn *websocket.Conn) error {
+// sendGap tells the client that the older missed messages are not sent.
+func sendGap(frame gapFrame, log logrus.FieldLogger, uid uuid.UUID, conn *websocket.Conn, c *syncmap.ConnectionsMap) error {
+	data, err := json.Marshal(frame)
+	if err != nil {
+		log.WithError(err).
+			WithField("uuid", uid.ID()).
+			Error("cannot marshal data to json")
+		return nil
+	}
+
+	err = c.WriteMessage(conn, websocket.TextMessage, data)
+	if err != nil {
+		log.WithError(err).
+			WithField("uuid", uid.ID()).
+			Error("cannot send message to client")
+	}
+	return err
+}
+
+func saveLastMessages(
+	messages []domain.Message, log logrus.FieldLogger,
+	uid uuid.UUID, conn *websocket.Conn, c *syncmap.ConnectionsMap,
+) error {
 	for _, m := range messages {
-		data, err := json.Marshal(m)
+		frame := newMessageFrame(m)
+		frame.History = true
+		data, err := json.Marshal(frame)
 		if err != nil {
 			log.WithError(err).
 				WithField("uuid", uid.ID()).
 				Info("cannot marshal data to json")
 			continue
 		}
-		err = conn.WriteMessage(websocket.TextMessage, data)
+		err = c.WriteMessage(conn, websocket.TextMessage, data)
 		if err != nil {
 			log.WithError(err).
 				WithField("uuid", uid.ID()).
@@ -166,42 +396,356 @@ func saveLastMessages(messages []domain.Message, log logrus.FieldLogger, uid uui
 	return nil
 }
 
-func saveAndSendMessage(data []byte, c *syncmap.ConnectionsMap, a App, l logrus.FieldLogger) {
-	msg := domain.Message{}
-	err := json.Unmarshal(data, &msg)
+// startFrameSpan starts the span of the frame received from the connection,
+// the span is ended by the handler of the frame.
+func startFrameSpan(ctx context.Context, frameType string, info syncmap.Info) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "websocket "+frameType,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("chat.connection_id", info.ID),
+			attribute.String("chat.room", info.Room),
+		),
+	)
+}
+
+func saveAndSendMessage(
+	ctx context.Context, sender *websocket.Conn, frame inboundFrame,
+	c *syncmap.ConnectionsMap, a App, l logrus.FieldLogger,
+) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+	l = logging.FromContext(ctx, l)
+
+	msg := frame.message()
+	info, _ := c.Info(sender)
+	msg.Username = info.Username
+	msg.Room = info.Room
+
+	msg, err := a.SaveMessage(ctx, msg)
 	if err != nil {
-		l.WithError(err).WithField("data", string(data)).Error("cannot unmarshal data")
+		metrics.MessagesRejected.WithLabelValues(rejectReason(err)).Inc()
+		tracing.RecordError(span, err)
+	}
+	if errors.Is(err, app.ErrInvalidMessage) || errors.Is(err, app.ErrMuted) ||
+		errors.Is(err, app.ErrForbidden) || errors.Is(err, app.ErrBanned) {
+		_ = sendError(sender, err, c, l)
 		return
 	}
-
-	err = a.SaveMessage(msg.Text, msg.Username)
 	if err != nil {
 		l.WithError(err).WithField("message", msg).Error("cannot save message")
 		return
 	}
+	span.SetAttributes(attribute.Int64("chat.message_id", msg.ID))
+
+	sendMessage(ctx, msg, c, l)
+}
+
+// sendMessage sends the saved message to the clients in its room and notifies the mentioned users.
+// Shadow-banned messages are sent only to the sender's clients in the room, so the sender doesn't notice the ban.
+func sendMessage(ctx context.Context, msg domain.Message, c *syncmap.ConnectionsMap, l logrus.FieldLogger) {
+	_, span := tracing.Tracer().Start(ctx, "broadcast", trace.WithAttributes(attribute.String("chat.room", msg.Room)))
+	defer span.End()
+
+	data, err := json.Marshal(newMessageFrame(msg))
+	if err != nil {
+		l.WithError(err).WithField("message", msg).Error("cannot marshal data to json")
+		return
+	}
 
-	ch := c.LoadAllConnections()
+	start := time.Now()
+	ch := c.LoadRoomConnections(msg.Room)
+	if msg.Shadowed {
+		ch = c.LoadUserRoomConnections(msg.Username, msg.Room)
+	}
 	for conn := range ch {
-		err = conn.WriteMessage(websocket.TextMessage, data)
+		err = c.WriteMessage(conn, websocket.TextMessage, data)
 		if err != nil {
 			l.WithError(err).WithField("data", string(data)).Error("cannot send the message")
 		}
 	}
+	metrics.BroadcastDuration.Observe(time.Since(start).Seconds())
+	metrics.MessagesBroadcast.Inc()
+
+	notifyMentioned(msg, c, l)
 }
 
-func validateMessage(data []byte) error {
-	msg := domain.Message{}
-	err := json.Unmarshal(data, &msg)
+// notifyMentioned sends the mention notification to the connections of the mentioned
+// users in the other rooms, the users in the message room have already got the message.
+func notifyMentioned(msg domain.Message, c *syncmap.ConnectionsMap, l logrus.FieldLogger) {
+	if len(msg.Mentions) == 0 || msg.Shadowed {
+		return
+	}
+
+	data, err := json.Marshal(newMentionFrame(msg))
 	if err != nil {
-		return err
+		l.WithError(err).WithField("message", msg).Error("cannot marshal data to json")
+		return
+	}
+
+	for _, username := range msg.Mentions {
+		if username == msg.Username {
+			continue
+		}
+		ch := c.LoadUserConnections(username)
+		for conn := range ch {
+			info, _ := c.Info(conn)
+			if info.Room == msg.Room {
+				continue
+			}
+			err = c.WriteMessage(conn, websocket.TextMessage, data)
+			if err != nil {
+				l.WithError(err).WithField("data", string(data)).Error("cannot send the mention")
+			}
+		}
 	}
+}
+
+func saveAndSendReaction(
+	ctx context.Context, sender *websocket.Conn, f inboundFrame,
+	c *syncmap.ConnectionsMap, a App, l logrus.FieldLogger,
+) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+	l = logging.FromContext(ctx, l)
 
+	frame := reactionFrame{Type: frameTypeReaction, Reaction: f.reaction()}
+	info, _ := c.Info(sender)
+	frame.Username = info.Username
+	frame.Room = info.Room
+
+	err := a.React(ctx, frame.Reaction)
+	if err != nil {
+		tracing.RecordError(span, err)
+	}
+	// the message doesn't exist or belongs to another room, or the user is banned
+	if errors.Is(err, app.ErrNotFound) || errors.Is(err, app.ErrBanned) {
+		_ = sendError(sender, err, c, l)
+		return
+	}
+	if err != nil {
+		l.WithError(err).WithField("reaction", frame.Reaction).Error("cannot save reaction")
+		return
+	}
+
+	data, err := json.Marshal(frame)
+	if err != nil {
+		l.WithError(err).WithField("reaction", frame.Reaction).Error("cannot marshal data to json")
+		return
+	}
+
+	ch := c.LoadRoomConnections(frame.Room)
+	for conn := range ch {
+		err = c.WriteMessage(conn, websocket.TextMessage, data)
+		if err != nil {
+			l.WithError(err).WithField("data", string(data)).Error("cannot send the reaction")
+		}
+	}
+}
+
+// sendThread sends the requested thread back to the sender.
+func sendThread(ctx context.Context, sender *websocket.Conn, f inboundFrame, c *syncmap.ConnectionsMap, a App, l logrus.FieldLogger) {
+	info, _ := c.Info(sender)
+	frame := threadFrame{Type: frameTypeThread, ID: f.ID}
+	var err error
+	frame.Messages, err = a.LoadThread(ctx, info.Room, frame.ID)
+	if errors.Is(err, app.ErrNotFound) {
+		_ = sendError(sender, fmt.Errorf("message %d not found in room %s", frame.ID, info.Room), c, l)
+		return
+	}
+	if err != nil {
+		l.WithError(err).WithField("id", frame.ID).Error("cannot load thread")
+		_ = sendError(sender, errors.New("cannot load thread"), c, l)
+		return
+	}
+
+	data, err := json.Marshal(frame)
+	if err != nil {
+		l.WithError(err).WithField("id", frame.ID).Error("cannot marshal data to json")
+		return
+	}
+
+	err = c.WriteMessage(sender, websocket.TextMessage, data)
+	if err != nil {
+		l.WithError(err).WithField("id", frame.ID).Error("cannot send the thread")
+	}
+}
+
+// sendMentions sends the last messages mentioning the sender back to the sender.
+func sendMentions(ctx context.Context, sender *websocket.Conn, c *syncmap.ConnectionsMap, a App, l logrus.FieldLogger) {
+	info, _ := c.Info(sender)
+	if info.Username == "" {
+		_ = sendError(sender, errors.New("mentions are available only for clients with username"), c, l)
+		return
+	}
+
+	messages, err := a.LoadMentions(ctx, info.Username)
+	if err != nil {
+		l.WithError(err).WithField("username", info.Username).Error("cannot load mentions")
+		_ = sendError(sender, errors.New("cannot load mentions"), c, l)
+		return
+	}
+
+	data, err := json.Marshal(mentionsFrame{Type: frameTypeMentions, Messages: messages})
+	if err != nil {
+		l.WithError(err).WithField("username", info.Username).Error("cannot marshal data to json")
+		return
+	}
+
+	err = c.WriteMessage(sender, websocket.TextMessage, data)
+	if err != nil {
+		l.WithError(err).WithField("username", info.Username).Error("cannot send the mentions")
+	}
+}
+
+// sendError sends the error frame to the client, frame errors are sent with the invalid fields.
+func sendError(conn *websocket.Conn, e error, c *syncmap.ConnectionsMap, l logrus.FieldLogger) error {
+	frame := newErrorFrame(e)
+	data, err := json.Marshal(frame)
+	if err != nil {
+		l.WithError(err).
+			WithField("frame", frame).
+			Error("cannot marshal data to json")
+		return nil
+	}
+
+	err = c.WriteMessage(conn, websocket.TextMessage, data)
+	if err != nil {
+		l.WithError(err).
+			Error("cannot send message to client")
+	}
+	return err
+}
+
+// sendTyping relays the typing frame to the other clients in the sender's room,
+// the frames of the clients without username are dropped.
+func sendTyping(sender *websocket.Conn, c *syncmap.ConnectionsMap, l logrus.FieldLogger) {
+	info, _ := c.Info(sender)
+	if info.Username == "" {
+		return
+	}
+	frame := typingFrame{Type: frameTypeTyping, Username: info.Username, Room: info.Room}
+
+	data, err := json.Marshal(frame)
+	if err != nil {
+		l.WithError(err).WithField("frame", frame).Error("cannot marshal data to json")
+		return
+	}
+
+	ch := c.LoadRoomConnections(frame.Room)
+	for conn := range ch {
+		if conn == sender {
+			continue
+		}
+		err = c.WriteMessage(conn, websocket.TextMessage, data)
+		if err != nil {
+			l.WithError(err).WithField("data", string(data)).Error("cannot send typing notification")
+		}
+	}
+}
+
+// markRead moves the read marker of the sender in its room.
+func markRead(ctx context.Context, sender *websocket.Conn, frame inboundFrame, c *syncmap.ConnectionsMap, a App, l logrus.FieldLogger) {
+	info, _ := c.Info(sender)
+	if info.Username == "" {
+		return
+	}
+
+	err := a.MarkRead(ctx, info.Username, info.Room, frame.ID)
+	if err != nil {
+		l.WithError(err).
+			WithField("username", info.Username).
+			WithField("room", info.Room).
+			Error("cannot mark messages as read")
+	}
+}
+
+// sendUnreadCounts sends the read markers of the user to the client,
+// clients connected without username have no markers.
+func sendUnreadCounts(ctx context.Context, a App, log logrus.FieldLogger, uid uuid.UUID, conn *websocket.Conn, c *syncmap.ConnectionsMap) error {
+	info, _ := c.Info(conn)
+	if info.Username == "" {
+		return nil
+	}
+
+	markers, err := a.LoadReadMarkers(ctx, info.Username)
+	if err != nil {
+		// unread counts are not critical for chatting, so keep the connection
+		log.WithError(err).
+			WithField("uuid", uid.ID()).
+			Error("cannot load read markers")
+		return nil
+	}
+
+	data, err := json.Marshal(newUnreadFrame(info.Room, markers))
+	if err != nil {
+		log.WithError(err).
+			WithField("uuid", uid.ID()).
+			Error("cannot marshal data to json")
+		return nil
+	}
+
+	err = c.WriteMessage(conn, websocket.TextMessage, data)
+	if err != nil {
+		log.WithError(err).
+			WithField("uuid", uid.ID()).
+			Error("cannot send message to client")
+	}
+	return err
+}
+
+// rejectReason returns the metrics reason of the message rejected with the error.
+func rejectReason(err error) string {
+	switch {
+	case errors.Is(err, app.ErrInvalidMessage):
+		return metrics.ReasonInvalid
+	case errors.Is(err, errRateLimited):
+		return metrics.ReasonRateLimited
+	case errors.Is(err, app.ErrUnauthorized):
+		return metrics.ReasonUnauthorized
+	case errors.Is(err, app.ErrMuted):
+		return metrics.ReasonMuted
+	case errors.Is(err, app.ErrForbidden), errors.Is(err, app.ErrBanned):
+		return metrics.ReasonForbidden
+	default:
+		return metrics.ReasonError
+	}
+}
+
+// checkSender checks that the client may send messages and reactions,
+// the clients connected without username may only read the room.
+func checkSender(info syncmap.Info) error {
+	if info.Username == "" {
+		return errAnonymous
+	}
+	return nil
+}
+
+// checkMessage checks the message fields set by a client.
+func checkMessage(msg domain.Message) error {
 	if msg.Text == "" {
 		return errors.New("message text must be non-empty")
 	}
 
-	if len(msg.Username) < 3 {
-		return errors.New("username length must be at least 3 characters")
+	if msg.ReplyTo < 0 {
+		return errors.New("reply_to must be an id of a message")
+	}
+
+	if len(msg.Username) < minUsernameLength {
+		return fmt.Errorf("username length must be at least %d characters", minUsernameLength)
+	}
+
+	return app.CheckUsername(msg.Username)
+}
+
+// checkReaction checks the reaction fields the schema cannot express.
+func checkReaction(reaction domain.Reaction) error {
+	if len(reaction.Emoji) > maxEmojiLength {
+		return fmt.Errorf("emoji length must be at most %d bytes", maxEmojiLength)
+	}
+	for _, r := range reaction.Emoji {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return errors.New("emoji must not contain spaces or control characters")
+		}
 	}
 
 	return nil
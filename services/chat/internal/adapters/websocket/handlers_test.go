@@ -0,0 +1,176 @@
+package websocket
+
+import (
+	"chat/internal/adapters/websocket/mocks"
+	"chat/internal/adapters/websocket/syncmap"
+	"chat/internal/domain"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConnection_Anonymous(t *testing.T) {
+	type testcase struct {
+		name  string
+		frame string
+	}
+
+	tests := []testcase{
+		{name: "message", frame: `{"type": "message", "username": "gleb", "message": "hello"}`},
+		{name: "message without type", frame: `{"username": "gleb", "message": "hello"}`},
+		{name: "reaction", frame: `{"type": "reaction", "id": 42, "username": "gleb", "emoji": "👍", "action": "add"}`},
+	}
+
+	a := mocks.NewApp(t)
+	a.On("Authenticate", mock.Anything, "", "").Return(domain.RoleMember, nil)
+	a.On("Authenticate", mock.Anything, "danil", "").Return(domain.RoleMember, nil)
+	a.On("LoadReadMarkers", mock.Anything, "danil").Return([]domain.ReadMarker{}, nil)
+	a.On("LoadLastMessages", mock.Anything, domain.DefaultRoom).Return([]domain.Message{}, nil)
+	l := mocks.NewLimiter(t)
+	// the frames of the clients without username are limited only by the address,
+	// the username of the frame is not used as the key
+	l.On("Allow", mock.Anything, "ip:127.0.0.1", mock.Anything, mock.Anything).Return(true, nil)
+	server := newTestServer(t, a, l)
+
+	// the unread counts are sent to the clients with username on connect
+	member := dialChat(t, server, "username=danil", 1)
+	anonymous := dialChat(t, server, "", 0)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.NoError(t, anonymous.WriteMessage(websocket.TextMessage, []byte(test.frame)))
+
+			var frame errorFrame
+			require.NoError(t, anonymous.ReadJSON(&frame))
+			assert.Equal(t, frameTypeError, frame.Type)
+			assert.Equal(t, errAnonymous.Error(), frame.Text)
+		})
+	}
+
+	t.Run("typing", func(t *testing.T) {
+		require.NoError(t, anonymous.WriteMessage(websocket.TextMessage, []byte(`{"type": "typing", "username": "gleb"}`)))
+
+		// neither the typing frame nor the rejected frames reach the room
+		require.NoError(t, member.SetReadDeadline(time.Now().Add(200*time.Millisecond)))
+		_, _, err := member.ReadMessage()
+		var netErr net.Error
+		require.ErrorAs(t, err, &netErr)
+		assert.True(t, netErr.Timeout())
+	})
+}
+
+func TestConnection_Username(t *testing.T) {
+	a := mocks.NewApp(t)
+	a.On("Authenticate", mock.Anything, "danil", "").Return(domain.RoleMember, nil)
+	a.On("LoadReadMarkers", mock.Anything, "danil").Return([]domain.ReadMarker{}, nil)
+	a.On("LoadLastMessages", mock.Anything, domain.DefaultRoom).Return([]domain.Message{}, nil)
+	// the message is saved with the username of the connection, not the one of the frame
+	a.On("SaveMessage", mock.Anything, domain.Message{Username: "danil", Text: "hello", Room: domain.DefaultRoom}).
+		Return(domain.Message{ID: 42, Username: "danil", Text: "hello", Room: domain.DefaultRoom}, nil)
+	l := mocks.NewLimiter(t)
+	l.On("Allow", mock.Anything, "user:danil", mock.Anything, mock.Anything).Return(true, nil)
+	l.On("Allow", mock.Anything, "ip:127.0.0.1", mock.Anything, mock.Anything).Return(true, nil)
+	server := newTestServer(t, a, l)
+
+	conn := dialChat(t, server, "username=danil", 1)
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "message", "username": "gleb", "message": "hello"}`)))
+
+	var frame messageFrame
+	require.NoError(t, conn.ReadJSON(&frame))
+	assert.Equal(t, frameTypeMessage, frame.Type)
+	assert.Equal(t, int64(42), frame.ID)
+	assert.Equal(t, "danil", frame.Username)
+}
+
+func TestConnection_UnsanitizedUsername(t *testing.T) {
+	// the names are rejected before the authentication, so they cannot pass for the names with a role
+	server := newTestServer(t, mocks.NewApp(t), mocks.NewLimiter(t))
+
+	for _, username := range []string{"danil%E2%80%8E", "danil%20", "%E2%80%AEdanil"} {
+		url := strings.Replace(server.URL, "http", "ws", 1) + "/api/v1/chat?username=" + username
+		_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+		require.Error(t, err, username)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, username)
+		_ = resp.Body.Close()
+	}
+}
+
+func TestConnection_Resume(t *testing.T) {
+	type testcase struct {
+		name      string
+		truncated bool
+	}
+
+	tests := []testcase{
+		{name: "all missed messages", truncated: false},
+		{name: "last missed messages", truncated: true},
+	}
+
+	missed := []domain.Message{
+		{ID: 44, Username: "gleb", Text: "Hello", Room: "random"},
+		{ID: 45, Username: "maks", Text: "Hi", Room: "random"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := mocks.NewApp(t)
+			a.On("Authenticate", mock.Anything, "danil", "").Return(domain.RoleMember, nil)
+			a.On("LoadReadMarkers", mock.Anything, "danil").Return([]domain.ReadMarker{}, nil)
+			a.On("LoadMissedMessages", mock.Anything, "random", int64(42)).Return(missed, test.truncated, nil)
+			server := newTestServer(t, a, mocks.NewLimiter(t))
+
+			conn := dialChat(t, server, "username=danil&room=random&after=42", 1)
+			if test.truncated {
+				var gap gapFrame
+				require.NoError(t, conn.ReadJSON(&gap))
+				assert.Equal(t, gapFrame{Type: frameTypeGap, Room: "random", After: 42, Before: 44}, gap)
+			}
+			for _, m := range missed {
+				var frame messageFrame
+				require.NoError(t, conn.ReadJSON(&frame))
+				assert.Equal(t, frameTypeMessage, frame.Type)
+				assert.Equal(t, m.ID, frame.ID)
+				assert.True(t, frame.History)
+			}
+		})
+	}
+}
+
+// newTestServer serves the chat router with the app and the limiter, the server is closed with the test.
+func newTestServer(t *testing.T, a App, l Limiter) *httptest.Server {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	cfg := &Config{
+		ReadLimit:       1024,
+		ConnectionLimit: RateLimit{Rate: 100, Burst: 100},
+		UserLimit:       RateLimit{Rate: 100, Burst: 100},
+		IPLimit:         RateLimit{Rate: 100, Burst: 100},
+	}
+
+	server := httptest.NewServer(newRouter(a, l, newHealth(mocks.NewHealthChecker(t)), newDrain(), &websocket.Upgrader{}, syncmap.New(), cfg, log))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// dialChat connects to the chat with the query and skips the frames sent on connect.
+func dialChat(t *testing.T, server *httptest.Server, query string, skip int) *websocket.Conn {
+	url := strings.Replace(server.URL, "http", "ws", 1) + "/api/v1/chat?" + query
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	for i := 0; i < skip; i++ {
+		_, _, err = conn.ReadMessage()
+		require.NoError(t, err)
+	}
+	return conn
+}
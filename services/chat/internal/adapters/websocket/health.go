@@ -0,0 +1,69 @@
+package websocket
+
+import (
+	"chat/internal/adapters/websocket/openapi"
+	"context"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// readinessTimeout limits the checks of the dependencies in a readiness probe.
+const readinessTimeout = 2 * time.Second
+
+//go:generate go run github.com/vektra/mockery/v2@v2.42.0 --name=HealthChecker
+type HealthChecker interface {
+	// CheckHealth checks the dependencies of the service, the result contains
+	// the error of every dependency by its name, nil if the dependency is up.
+	CheckHealth(ctx context.Context) map[string]error
+}
+
+// health serves the liveness and readiness probes, the server stops being ready on shutdown.
+type health struct {
+	checker HealthChecker
+	ready   atomic.Bool
+}
+
+func newHealth(checker HealthChecker) *health {
+	h := &health{checker: checker}
+	h.ready.Store(true)
+	return h
+}
+
+// healthz handles GET /healthz, the service is alive while it serves the requests.
+func (h *health) healthz(log logrus.FieldLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, openapi.Health{Status: openapi.Ok}, log)
+	}
+}
+
+// readyz handles GET /readyz, the service is ready if all dependencies are up and it is not shutting down.
+func (h *health) readyz(log logrus.FieldLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.ready.Load() {
+			writeJSON(w, http.StatusServiceUnavailable, openapi.Health{Status: openapi.ShuttingDown}, log)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+
+		res := openapi.Health{Status: openapi.Ready, Checks: make(map[string]openapi.DependencyStatus)}
+		for name, err := range h.checker.CheckHealth(ctx) {
+			if err == nil {
+				res.Checks[name] = openapi.DependencyStatus{Status: openapi.Up}
+				continue
+			}
+			log.WithError(err).WithField("dependency", name).Warn("the dependency is down")
+			res.Checks[name] = openapi.DependencyStatus{Status: openapi.Down, Error: err.Error()}
+			res.Status = openapi.NotReady
+		}
+
+		status := http.StatusOK
+		if res.Status != openapi.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, res, log)
+	}
+}
@@ -0,0 +1,126 @@
+package websocket
+
+import (
+	"chat/internal/adapters/websocket/mocks"
+	"chat/internal/adapters/websocket/openapi"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealth_ResponsesMatchOpenAPI(t *testing.T) {
+	type testcase struct {
+		name  string
+		url   string
+		setup func(c *mocks.HealthChecker)
+		// shutdown marks the server as shutting down
+		shutdown bool
+		status   int
+		expected openapi.HealthStatus
+	}
+
+	tests := []testcase{
+		{
+			name:     "liveness",
+			url:      "/healthz",
+			setup:    func(*mocks.HealthChecker) {},
+			status:   http.StatusOK,
+			expected: openapi.Ok,
+		},
+		{
+			name:     "liveness on shutdown",
+			url:      "/healthz",
+			setup:    func(*mocks.HealthChecker) {},
+			shutdown: true,
+			status:   http.StatusOK,
+			expected: openapi.Ok,
+		},
+		{
+			name: "ready",
+			url:  "/readyz",
+			setup: func(c *mocks.HealthChecker) {
+				c.On("CheckHealth", mock.Anything).Return(map[string]error{"postgres": nil, "redis": nil, "kafka": nil})
+			},
+			status:   http.StatusOK,
+			expected: openapi.Ready,
+		},
+		{
+			name: "dependency is down",
+			url:  "/readyz",
+			setup: func(c *mocks.HealthChecker) {
+				c.On("CheckHealth", mock.Anything).Return(map[string]error{
+					"postgres": nil,
+					"redis":    errors.New("connection refused"),
+					"kafka":    nil,
+				})
+			},
+			status:   http.StatusServiceUnavailable,
+			expected: openapi.NotReady,
+		},
+		{
+			name:     "shutting down",
+			url:      "/readyz",
+			setup:    func(*mocks.HealthChecker) {},
+			shutdown: true,
+			status:   http.StatusServiceUnavailable,
+			expected: openapi.ShuttingDown,
+		},
+	}
+
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	doc, err := loader.LoadFromFile(openAPIPath)
+	require.NoError(t, err)
+	router, err := legacy.NewRouter(doc)
+	require.NoError(t, err)
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := mocks.NewHealthChecker(t)
+			test.setup(c)
+			h := newHealth(c)
+			if test.shutdown {
+				h.ready.Store(false)
+			}
+			handler := newRouter(mocks.NewApp(t), mocks.NewLimiter(t), h, newDrain(), &websocket.Upgrader{}, nil, &Config{}, log)
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost:8080"+test.url, nil)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, test.status, rec.Code)
+
+			var res openapi.Health
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Equal(t, test.expected, res.Status)
+
+			route, params, err := router.FindRoute(req)
+			require.NoError(t, err)
+			err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: &openapi3filter.RequestValidationInput{
+					Request:    req,
+					PathParams: params,
+					Route:      route,
+				},
+				Status: rec.Code,
+				Header: rec.Header(),
+				Body:   io.NopCloser(rec.Body),
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
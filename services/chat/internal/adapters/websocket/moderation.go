@@ -0,0 +1,171 @@
+package websocket
+
+import (
+	"chat/internal/adapters/websocket/syncmap"
+	"chat/internal/app"
+	"chat/internal/domain"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxCloseReasonLength is the size of the close frame payload left for the reason.
+const maxCloseReasonLength = 123
+
+// parseModeration parses the arguments of the moderation command with the action:
+//
+//	/mute <user> <duration> [reason]
+//	/unmute <user>
+//	/kick <user> [reason]
+//	/ban <user> [reason]
+//	/unban <user>
+func parseModeration(action string, args []string) (domain.Moderation, error) {
+	m := domain.Moderation{Action: action}
+	if len(args) == 0 {
+		return domain.Moderation{}, errors.New("username is required")
+	}
+	m.Target = strings.TrimPrefix(args[0], "@")
+	args = args[1:]
+
+	if m.Action == domain.ModerationMute {
+		if len(args) == 0 {
+			return domain.Moderation{}, errors.New("duration is required")
+		}
+		var err error
+		m.Duration, err = parseDuration(args[0])
+		if err != nil {
+			return domain.Moderation{}, err
+		}
+		args = args[1:]
+	}
+	m.Reason = strings.Join(args, " ")
+	return m, nil
+}
+
+// parseDuration parses the mute duration, it accepts the days along with the Go durations, e.g. 1d, 10m or 1h30m.
+func parseDuration(s string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q, use e.g. 10m, 2h or 1d", s)
+	}
+	return d, nil
+}
+
+// moderate applies the moderation command of the sender, the moderator is the user of the connection.
+// The action is applied even if the connection of the moderator is closed meanwhile.
+func moderate(ctx context.Context, sender *websocket.Conn, m domain.Moderation, c *syncmap.ConnectionsMap, a App, l logrus.FieldLogger) {
+	info, _ := c.Info(sender)
+	m.Moderator = info.Username
+	m.Room = info.Room
+
+	err := a.Moderate(context.WithoutCancel(ctx), m, info.Role)
+	if errors.Is(err, app.ErrForbidden) || errors.Is(err, app.ErrInvalidMessage) {
+		_ = sendError(sender, err, c, l)
+		return
+	}
+	if err != nil {
+		l.WithError(err).WithField("moderation", m).Error("cannot moderate the user")
+		_ = sendError(sender, errors.New("cannot apply the command"), c, l)
+		return
+	}
+
+	l.WithField("moderation", m).Info("the user is moderated")
+}
+
+// applyModeration notifies the room and the target about the moderator action,
+// the connections of the kicked or banned user are closed.
+func applyModeration(m domain.Moderation, c *syncmap.ConnectionsMap, l logrus.FieldLogger) {
+	data, err := json.Marshal(newModerationFrame(m))
+	if err != nil {
+		l.WithError(err).WithField("moderation", m).Error("cannot marshal data to json")
+		return
+	}
+
+	for conn := range c.LoadRoomConnections(m.Room) {
+		err = c.WriteMessage(conn, websocket.TextMessage, data)
+		if err != nil {
+			l.WithError(err).WithField("data", string(data)).Error("cannot send the moderation")
+		}
+	}
+
+	disconnect := m.Action == domain.ModerationKick || m.Action == domain.ModerationBan
+	closeData := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, closeReason(m))
+	for conn := range c.LoadUserConnections(m.Target) {
+		info, _ := c.Info(conn)
+		if info.Room != m.Room {
+			err = c.WriteMessage(conn, websocket.TextMessage, data)
+			if err != nil {
+				l.WithError(err).WithField("data", string(data)).Error("cannot send the moderation")
+			}
+		}
+		if !disconnect {
+			continue
+		}
+
+		closeConnection(conn, closeData, c, l)
+	}
+}
+
+// closeConnection sends the close message and makes the connection handler close the connection.
+func closeConnection(conn *websocket.Conn, closeData []byte, c *syncmap.ConnectionsMap, l logrus.FieldLogger) {
+	info, _ := c.Info(conn)
+	err := c.WriteMessage(conn, websocket.CloseMessage, closeData)
+	if err != nil {
+		l.WithError(err).WithField("uuid", info.ID).Error("cannot send close message")
+	}
+	// the reading goroutine of the connection gets the timeout error and closes the connection
+	err = conn.SetReadDeadline(time.Now())
+	if err != nil {
+		l.WithError(err).WithField("uuid", info.ID).Error("cannot interrupt the connection")
+	}
+}
+
+// closeReason describes the moderator action in the close frame, which fits at most 123 bytes.
+func closeReason(m domain.Moderation) string {
+	reason := fmt.Sprintf("%s by %s", m.Action, m.Moderator)
+	if m.Reason != "" {
+		reason = fmt.Sprintf("%s: %s", reason, m.Reason)
+	}
+	for len(reason) > maxCloseReasonLength {
+		_, size := utf8.DecodeLastRuneInString(reason)
+		reason = reason[:len(reason)-size]
+	}
+	return reason
+}
+
+// bearerToken returns the token of the "Authorization: Bearer <token>" header.
+func bearerToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// authStatus returns the HTTP status of the authentication error.
+func authStatus(err error) int {
+	switch {
+	case errors.Is(err, app.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, app.ErrBanned):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
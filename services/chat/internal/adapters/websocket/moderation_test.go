@@ -0,0 +1,116 @@
+package websocket
+
+import (
+	"chat/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseModeration(t *testing.T) {
+	type testcase struct {
+		text       string
+		moderation domain.Moderation
+		isCommand  bool
+		valid      bool
+	}
+
+	tests := []testcase{
+		{
+			text:       "/mute danil 10m",
+			moderation: domain.Moderation{Action: domain.ModerationMute, Target: "danil", Duration: 10 * time.Minute},
+			isCommand:  true,
+			valid:      true,
+		},
+		{
+			text:       "/mute @danil 2d stop flooding",
+			moderation: domain.Moderation{Action: domain.ModerationMute, Target: "danil", Duration: 48 * time.Hour, Reason: "stop flooding"},
+			isCommand:  true,
+			valid:      true,
+		},
+		{
+			text:       "/unmute danil",
+			moderation: domain.Moderation{Action: domain.ModerationUnmute, Target: "danil"},
+			isCommand:  true,
+			valid:      true,
+		},
+		{
+			text:       "/kick danil",
+			moderation: domain.Moderation{Action: domain.ModerationKick, Target: "danil"},
+			isCommand:  true,
+			valid:      true,
+		},
+		{
+			text:       "  /ban   danil  spam links ",
+			moderation: domain.Moderation{Action: domain.ModerationBan, Target: "danil", Reason: "spam links"},
+			isCommand:  true,
+			valid:      true,
+		},
+		{
+			text:       "/unban danil",
+			moderation: domain.Moderation{Action: domain.ModerationUnban, Target: "danil"},
+			isCommand:  true,
+			valid:      true,
+		},
+		{text: "/mute danil", isCommand: true},
+		{text: "/mute danil forever", isCommand: true},
+		{text: "/mute danil -5m", isCommand: true},
+		{text: "/mute danil 0d", isCommand: true},
+		{text: "/ban", isCommand: true},
+		{text: "hello /ban danil"},
+		{text: "/banana"},
+		{text: ""},
+	}
+
+	commands := newCommandRegistry()
+	for _, test := range tests {
+		name, args, isCommand := parseCommand(test.text)
+		isCommand = isCommand && commands.has(name)
+		assert.Equal(t, test.isCommand, isCommand, test.text)
+		if !isCommand {
+			continue
+		}
+
+		m, err := parseModeration(name, args)
+		if test.valid {
+			assert.NoError(t, err, test.text)
+			assert.Equal(t, test.moderation, m)
+		} else if test.isCommand {
+			assert.Error(t, err, test.text)
+		}
+	}
+}
+
+func TestCloseReason(t *testing.T) {
+	m := domain.Moderation{Action: domain.ModerationBan, Moderator: "gleb", Reason: "spam"}
+	assert.Equal(t, "ban by gleb: spam", closeReason(m))
+
+	m.Reason = strings.Repeat("я", 100)
+	reason := closeReason(m)
+	assert.LessOrEqual(t, len(reason), maxCloseReasonLength)
+	assert.True(t, strings.HasSuffix(reason, "я"))
+}
+
+func TestBearerToken(t *testing.T) {
+	type testcase struct {
+		header string
+		token  string
+	}
+
+	tests := []testcase{
+		{header: "Bearer secret", token: "secret"},
+		{header: "Basic c2VjcmV0", token: ""},
+		{header: "", token: ""},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/chat", nil)
+		if test.header != "" {
+			r.Header.Set("Authorization", test.header)
+		}
+		assert.Equal(t, test.token, bearerToken(r))
+	}
+}
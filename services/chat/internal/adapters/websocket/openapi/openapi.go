@@ -0,0 +1,4 @@
+// Package openapi contains the types of the HTTP API generated from api/openapi.yaml.
+package openapi
+
+//go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen@v2.3.0 -config config.yaml ../../../../../../api/openapi.yaml
@@ -0,0 +1,142 @@
+// Package openapi provides primitives to interact with the openapi HTTP API.
+//
+// Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.3.0 DO NOT EDIT.
+package openapi
+
+const (
+	BearerAuthScopes = "bearerAuth.Scopes"
+)
+
+// Defines values for DependencyStatusStatus.
+const (
+	Down DependencyStatusStatus = "down"
+	Up   DependencyStatusStatus = "up"
+)
+
+// Defines values for HealthStatus.
+const (
+	NotReady     HealthStatus = "not_ready"
+	Ok           HealthStatus = "ok"
+	Ready        HealthStatus = "ready"
+	ShuttingDown HealthStatus = "shutting_down"
+)
+
+// DependencyStatus defines model for DependencyStatus.
+type DependencyStatus struct {
+	// Error Ошибка проверки недоступной зависимости
+	Error  string                 `json:"error,omitempty"`
+	Status DependencyStatusStatus `json:"status"`
+}
+
+// DependencyStatusStatus defines model for DependencyStatus.Status.
+type DependencyStatusStatus string
+
+// Error defines model for Error.
+type Error struct {
+	// Error Описание ошибки
+	Error string `json:"error"`
+}
+
+// Health defines model for Health.
+type Health struct {
+	// Checks Состояние зависимостей по имени, только для /readyz
+	Checks map[string]DependencyStatus `json:"checks,omitempty"`
+	Status HealthStatus                `json:"status"`
+}
+
+// HealthStatus defines model for Health.Status.
+type HealthStatus string
+
+// Message defines model for Message.
+type Message struct {
+	// Id ID сообщения, присваивается chat сервисом
+	Id int64 `json:"id"`
+
+	// Mentions Упомянутые в тексте пользователи
+	Mentions []string `json:"mentions,omitempty"`
+
+	// Message Текст сообщения
+	Message string `json:"message"`
+
+	// Reactions Количество пользователей, поставивших каждую реакцию
+	Reactions map[string]int `json:"reactions,omitempty"`
+
+	// Replies Количество ответов на сообщение
+	Replies int `json:"replies,omitempty"`
+
+	// ReplyTo ID сообщения, на которое отвечает сообщение
+	ReplyTo int64 `json:"reply_to,omitempty"`
+
+	// Room Комната
+	Room string `json:"room"`
+
+	// Username Имя пользователя
+	Username string `json:"username"`
+}
+
+// MessagesResponse defines model for MessagesResponse.
+type MessagesResponse struct {
+	Messages []Message `json:"messages"`
+}
+
+// NewMessage defines model for NewMessage.
+type NewMessage struct {
+	// Message Текст сообщения
+	Message string `json:"message"`
+
+	// ReplyTo ID сообщения, на которое отвечает сообщение
+	ReplyTo int64 `json:"reply_to,omitempty"`
+
+	// Room Комната, по умолчанию general
+	Room string `json:"room,omitempty"`
+
+	// Username Имя пользователя
+	Username string `json:"username"`
+}
+
+// MessageID defines model for MessageID.
+type MessageID = int64
+
+// Room defines model for Room.
+type Room = string
+
+// Messages defines model for Messages.
+type Messages = MessagesResponse
+
+// ConnectParams defines parameters for Connect.
+type ConnectParams struct {
+	// Username Имя пользователя, без него клиент может только читать сообщения
+	Username *string `form:"username,omitempty" json:"username,omitempty"`
+
+	// Room Комната, по умолчанию general
+	Room *Room `form:"room,omitempty" json:"room,omitempty"`
+}
+
+// LoadHistoryParams defines parameters for LoadHistory.
+type LoadHistoryParams struct {
+	// Room Комната, по умолчанию general
+	Room *Room `form:"room,omitempty" json:"room,omitempty"`
+
+	// Before ID сообщения, до которого загружается история. Без него загружаются последние сообщения
+	Before *int64 `form:"before,omitempty" json:"before,omitempty"`
+	Limit  *int   `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// SearchMessagesParams defines parameters for SearchMessages.
+type SearchMessagesParams struct {
+	// Q Поисковый запрос в синтаксисе websearch_to_tsquery
+	Q string `form:"q" json:"q"`
+
+	// Room Комната, без неё поиск идёт по всем комнатам
+	Room  *string `form:"room,omitempty" json:"room,omitempty"`
+	Limit *int    `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// LoadContextParams defines parameters for LoadContext.
+type LoadContextParams struct {
+	// Limit Количество сообщений до и после найденного
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// PostMessageJSONRequestBody defines body for PostMessage for application/json ContentType.
+type PostMessageJSONRequestBody = NewMessage
@@ -0,0 +1,138 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+	"net"
+	"net/http"
+	"sync"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.42.0 --name=Limiter
+type Limiter interface {
+	// Allow takes a token from the bucket of the key, the bucket holds up to burst tokens
+	// and is refilled at rate tokens per second.
+	Allow(ctx context.Context, key string, rate float64, burst int) (bool, error)
+}
+
+var (
+	errRateLimited        = errors.New("rate limit exceeded, slow down")
+	errTooManyConnections = errors.New("too many connections from the address")
+)
+
+// sharedLimits limits the requests of users and IP addresses across all replicas.
+type sharedLimits struct {
+	l    Limiter
+	user RateLimit
+	ip   RateLimit
+	log  logrus.FieldLogger
+}
+
+func newSharedLimits(l Limiter, cfg *Config, log logrus.FieldLogger) *sharedLimits {
+	return &sharedLimits{l: l, user: cfg.UserLimit, ip: cfg.IPLimit, log: log}
+}
+
+// allow takes a token from the buckets of the user and the address,
+// clients without username are limited only by the address.
+func (s *sharedLimits) allow(ctx context.Context, username string, addr string) error {
+	if username != "" && !s.take(ctx, "user:"+username, s.user) {
+		return errRateLimited
+	}
+	if !s.take(ctx, "ip:"+addr, s.ip) {
+		return errRateLimited
+	}
+	return nil
+}
+
+// take takes a token from the bucket. The limiter errors are logged and the request
+// is allowed, so the chat keeps working while the limiter storage is down.
+func (s *sharedLimits) take(ctx context.Context, key string, limit RateLimit) bool {
+	ok, err := s.l.Allow(ctx, key, limit.Rate, limit.Burst)
+	if err != nil {
+		s.log.WithError(err).
+			WithField("key", key).
+			Warn("cannot check the rate limit, allowing the request")
+		return true
+	}
+	return ok
+}
+
+// frameLimiter limits the frames received by a connection. Frames of every type
+// are limited per connection, the frames making the server query the storage
+// are also limited per user and IP address.
+type frameLimiter struct {
+	conn   *rate.Limiter
+	shared *sharedLimits
+	addr   string
+}
+
+func newFrameLimiter(shared *sharedLimits, limit RateLimit, addr string) *frameLimiter {
+	return &frameLimiter{
+		conn:   rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst),
+		shared: shared,
+		addr:   addr,
+	}
+}
+
+// allowFrame checks the connection limit, it is checked before the frame is decoded.
+func (l *frameLimiter) allowFrame() error {
+	if !l.conn.Allow() {
+		return errRateLimited
+	}
+	return nil
+}
+
+// allow checks the limits of the user and the address for the decoded frame.
+func (l *frameLimiter) allow(ctx context.Context, frameType string, username string) error {
+	switch frameType {
+	case frameTypeTyping, frameTypeRead:
+		// cheap frames are handled in the read loop, so the connection limit is enough
+		return nil
+	}
+	return l.shared.allow(ctx, username, l.addr)
+}
+
+// ipConnections counts the open connections of every IP address.
+type ipConnections struct {
+	mx     sync.Mutex
+	max    int
+	counts map[string]int
+}
+
+func newIPConnections(max int) *ipConnections {
+	return &ipConnections{max: max, counts: make(map[string]int)}
+}
+
+// acquire counts the new connection of the address,
+// it returns false if the address has too many connections.
+func (c *ipConnections) acquire(addr string) bool {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.max > 0 && c.counts[addr] >= c.max {
+		return false
+	}
+	c.counts[addr]++
+	return true
+}
+
+// release forgets the closed connection of the address.
+func (c *ipConnections) release(addr string) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.counts[addr]--
+	if c.counts[addr] <= 0 {
+		delete(c.counts, addr)
+	}
+}
+
+// clientIP returns the IP address of the client. The server is expected to be reached
+// directly, so the forwarding headers set by the client are not trusted.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
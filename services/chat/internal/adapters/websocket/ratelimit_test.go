@@ -0,0 +1,130 @@
+package websocket
+
+import (
+	"chat/internal/adapters/websocket/mocks"
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"testing"
+)
+
+func TestFrameLimiter_Allow(t *testing.T) {
+	type testcase struct {
+		name      string
+		frameType string
+		username  string
+		setup     func(l *mocks.Limiter)
+		err       error
+	}
+
+	user := RateLimit{Rate: 5, Burst: 10}
+	ip := RateLimit{Rate: 20, Burst: 40}
+
+	tests := []testcase{
+		{
+			name:      "message",
+			frameType: frameTypeMessage,
+			username:  "danil",
+			setup: func(l *mocks.Limiter) {
+				l.On("Allow", mock.Anything, "user:danil", user.Rate, user.Burst).Return(true, nil)
+				l.On("Allow", mock.Anything, "ip:127.0.0.1", ip.Rate, ip.Burst).Return(true, nil)
+			},
+		},
+		{
+			name:      "user limit",
+			frameType: frameTypeReaction,
+			username:  "danil",
+			setup: func(l *mocks.Limiter) {
+				l.On("Allow", mock.Anything, "user:danil", user.Rate, user.Burst).Return(false, nil)
+			},
+			err: errRateLimited,
+		},
+		{
+			name:      "address limit",
+			frameType: frameTypeThread,
+			username:  "danil",
+			setup: func(l *mocks.Limiter) {
+				l.On("Allow", mock.Anything, "user:danil", user.Rate, user.Burst).Return(true, nil)
+				l.On("Allow", mock.Anything, "ip:127.0.0.1", ip.Rate, ip.Burst).Return(false, nil)
+			},
+			err: errRateLimited,
+		},
+		{
+			name:      "without username",
+			frameType: frameTypeMentions,
+			setup: func(l *mocks.Limiter) {
+				l.On("Allow", mock.Anything, "ip:127.0.0.1", ip.Rate, ip.Burst).Return(false, nil)
+			},
+			err: errRateLimited,
+		},
+		{
+			name:      "limiter error",
+			frameType: frameTypeMessage,
+			username:  "danil",
+			setup: func(l *mocks.Limiter) {
+				l.On("Allow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(false, errors.New("connection refused"))
+			},
+		},
+		{
+			name:      "typing",
+			frameType: frameTypeTyping,
+			username:  "danil",
+			setup:     func(*mocks.Limiter) {},
+		},
+		{
+			name:      "read",
+			frameType: frameTypeRead,
+			username:  "danil",
+			setup:     func(*mocks.Limiter) {},
+		},
+	}
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := mocks.NewLimiter(t)
+			test.setup(l)
+			shared := newSharedLimits(l, &Config{UserLimit: user, IPLimit: ip}, log)
+			limiter := newFrameLimiter(shared, RateLimit{Rate: 1, Burst: 1}, "127.0.0.1")
+
+			err := limiter.allow(context.Background(), test.frameType, test.username)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+func TestFrameLimiter_AllowFrame(t *testing.T) {
+	limiter := newFrameLimiter(nil, RateLimit{Rate: 0.001, Burst: 3}, "127.0.0.1")
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, limiter.allowFrame())
+	}
+	assert.ErrorIs(t, limiter.allowFrame(), errRateLimited)
+}
+
+func TestIPConnections(t *testing.T) {
+	c := newIPConnections(2)
+
+	assert.True(t, c.acquire("127.0.0.1"))
+	assert.True(t, c.acquire("127.0.0.1"))
+	assert.False(t, c.acquire("127.0.0.1"))
+	assert.True(t, c.acquire("10.0.0.1"))
+
+	c.release("127.0.0.1")
+	assert.True(t, c.acquire("127.0.0.1"))
+
+	c.release("127.0.0.1")
+	c.release("127.0.0.1")
+	_, ok := c.counts["127.0.0.1"]
+	assert.False(t, ok)
+
+	unlimited := newIPConnections(0)
+	for i := 0; i < 100; i++ {
+		assert.True(t, unlimited.acquire("127.0.0.1"))
+	}
+}
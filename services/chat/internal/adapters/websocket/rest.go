@@ -0,0 +1,310 @@
+package websocket
+
+import (
+	"chat/internal/adapters/websocket/openapi"
+	"chat/internal/adapters/websocket/syncmap"
+	"chat/internal/app"
+	"chat/internal/domain"
+	"chat/internal/logging"
+	"chat/internal/metrics"
+	"chat/internal/tracing"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 200
+
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+
+	defaultContextLimit = 10
+	maxContextLimit     = 50
+
+	// maxRequestBodySize limits the size of the messages posted via the REST API.
+	maxRequestBodySize = 64 << 10
+)
+
+// loadHistory handles GET /api/v1/messages?room=<room>&before=<id>&limit=<limit>,
+// the response contains the messages of the room sent before the message with the given ID.
+func loadHistory(a App, log logrus.FieldLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		room := query.Get("room")
+		if room != "" && !roomNameRegexp.MatchString(room) {
+			writeError(w, http.StatusBadRequest, errInvalidRoom, log)
+			return
+		}
+
+		before := int64(0)
+		if s := query.Get("before"); s != "" {
+			var err error
+			before, err = strconv.ParseInt(s, 10, 64)
+			if err != nil || before <= 0 {
+				writeError(w, http.StatusBadRequest, errors.New("before must be a positive message id"), log)
+				return
+			}
+		}
+
+		limit, err := queryLimit(r, defaultHistoryLimit, maxHistoryLimit)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err, log)
+			return
+		}
+
+		messages, err := a.LoadHistory(r.Context(), room, before, limit)
+		if err != nil {
+			log.WithError(err).WithField("room", room).Error("cannot load history")
+			writeError(w, http.StatusInternalServerError, errors.New("cannot load history"), log)
+			return
+		}
+		writeJSON(w, http.StatusOK, newMessagesResponse(messages), log)
+	}
+}
+
+// loadMessage handles GET /api/v1/messages/{id}.
+func loadMessage(a App, log logrus.FieldLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := pathID(w, r, log)
+		if !ok {
+			return
+		}
+
+		msg, err := a.LoadMessage(r.Context(), id)
+		if errors.Is(err, app.ErrNotFound) {
+			writeError(w, http.StatusNotFound, fmt.Errorf("message %d not found", id), log)
+			return
+		}
+		if err != nil {
+			log.WithError(err).WithField("id", id).Error("cannot load message")
+			writeError(w, http.StatusInternalServerError, errors.New("cannot load message"), log)
+			return
+		}
+		writeJSON(w, http.StatusOK, newAPIMessage(msg), log)
+	}
+}
+
+// postMessage handles POST /api/v1/messages, the saved message is sent
+// to the clients connected to its room the same way as messages sent via websocket.
+func postMessage(a App, limits *sharedLimits, c *syncmap.ConnectionsMap, log logrus.FieldLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body := openapi.NewMessage{}
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize)).Decode(&body)
+		if err != nil {
+			metrics.MessagesRejected.WithLabelValues(metrics.ReasonInvalid).Inc()
+			writeError(w, http.StatusBadRequest, fmt.Errorf("cannot decode message: %w", err), log)
+			return
+		}
+		metrics.MessagesReceived.WithLabelValues(metrics.TransportREST).Inc()
+
+		msg := domain.Message{Username: body.Username, Text: body.Message, Room: body.Room, ReplyTo: body.ReplyTo}
+
+		if msg.Room == "" {
+			msg.Room = domain.DefaultRoom
+		}
+		if !roomNameRegexp.MatchString(msg.Room) {
+			metrics.MessagesRejected.WithLabelValues(metrics.ReasonInvalid).Inc()
+			writeError(w, http.StatusBadRequest, errInvalidRoom, log)
+			return
+		}
+		err = checkMessage(msg)
+		if err != nil {
+			metrics.MessagesRejected.WithLabelValues(metrics.ReasonInvalid).Inc()
+			writeError(w, http.StatusBadRequest, err, log)
+			return
+		}
+
+		err = limits.allow(r.Context(), msg.Username, clientIP(r))
+		if err != nil {
+			metrics.MessagesRejected.WithLabelValues(metrics.ReasonRateLimited).Inc()
+			writeError(w, http.StatusTooManyRequests, err, log)
+			return
+		}
+
+		_, err = a.Authenticate(r.Context(), msg.Username, bearerToken(r))
+		if err != nil {
+			metrics.MessagesRejected.WithLabelValues(rejectReason(err)).Inc()
+			status := authStatus(err)
+			if status == http.StatusInternalServerError {
+				log.WithError(err).WithField("username", msg.Username).Error("cannot authenticate the user")
+				err = errors.New("cannot authenticate the user")
+			}
+			writeError(w, status, err, log)
+			return
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, "POST /api/v1/messages",
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("chat.room", msg.Room)),
+		)
+		defer span.End()
+		ctx = logging.WithFields(ctx, logrus.Fields{"username": msg.Username, "room": msg.Room})
+		log := logging.FromContext(ctx, log)
+
+		msg, err = a.SaveMessage(ctx, msg)
+		if err != nil {
+			metrics.MessagesRejected.WithLabelValues(rejectReason(err)).Inc()
+			tracing.RecordError(span, err)
+		}
+		if errors.Is(err, app.ErrInvalidMessage) {
+			writeError(w, http.StatusBadRequest, err, log)
+			return
+		}
+		if errors.Is(err, app.ErrMuted) || errors.Is(err, app.ErrForbidden) || errors.Is(err, app.ErrBanned) {
+			writeError(w, http.StatusForbidden, err, log)
+			return
+		}
+		if err != nil {
+			log.WithError(err).WithField("message", msg).Error("cannot save message")
+			writeError(w, http.StatusInternalServerError, errors.New("cannot save message"), log)
+			return
+		}
+
+		sendMessage(ctx, msg, c, log)
+		writeJSON(w, http.StatusCreated, newAPIMessage(msg), log)
+	}
+}
+
+// searchMessages handles GET /api/v1/messages/search?q=<query>&room=<room>&limit=<limit>,
+// the messages are searched in all rooms if the room is not set.
+func searchMessages(a App, limits *sharedLimits, log logrus.FieldLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		q := query.Get("q")
+		if q == "" {
+			writeError(w, http.StatusBadRequest, errors.New("search query 'q' must be non-empty"), log)
+			return
+		}
+
+		room := query.Get("room")
+		if room != "" && !roomNameRegexp.MatchString(room) {
+			writeError(w, http.StatusBadRequest, errInvalidRoom, log)
+			return
+		}
+
+		limit, err := queryLimit(r, defaultSearchLimit, maxSearchLimit)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err, log)
+			return
+		}
+
+		// the search is the most expensive query, so it is limited by the address like the posted messages
+		err = limits.allow(r.Context(), "", clientIP(r))
+		if err != nil {
+			writeError(w, http.StatusTooManyRequests, err, log)
+			return
+		}
+
+		messages, err := a.SearchMessages(r.Context(), q, room, limit)
+		if err != nil {
+			log.WithError(err).WithField("query", q).Error("cannot search messages")
+			writeError(w, http.StatusInternalServerError, errors.New("cannot search messages"), log)
+			return
+		}
+		writeJSON(w, http.StatusOK, newMessagesResponse(messages), log)
+	}
+}
+
+// loadContext handles GET /api/v1/messages/{id}/context?limit=<limit>, the response
+// contains the message with limit messages before and after it in the same room.
+func loadContext(a App, log logrus.FieldLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := pathID(w, r, log)
+		if !ok {
+			return
+		}
+
+		limit, err := queryLimit(r, defaultContextLimit, maxContextLimit)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err, log)
+			return
+		}
+
+		messages, err := a.LoadContext(r.Context(), id, limit)
+		if errors.Is(err, app.ErrNotFound) {
+			writeError(w, http.StatusNotFound, fmt.Errorf("message %d not found", id), log)
+			return
+		}
+		if err != nil {
+			log.WithError(err).WithField("id", id).Error("cannot load message context")
+			writeError(w, http.StatusInternalServerError, errors.New("cannot load message context"), log)
+			return
+		}
+		writeJSON(w, http.StatusOK, newMessagesResponse(messages), log)
+	}
+}
+
+// pathID reads the message ID from the request path, the error response is written if the ID is invalid.
+func pathID(w http.ResponseWriter, r *http.Request, log logrus.FieldLogger) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, errors.New("message id must be a positive integer"), log)
+		return 0, false
+	}
+	return id, true
+}
+
+// queryLimit reads the "limit" query parameter, def is returned if the parameter is not set.
+func queryLimit(r *http.Request, def int, maxLimit int) (int, error) {
+	s := r.URL.Query().Get("limit")
+	if s == "" {
+		return def, nil
+	}
+
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit < 1 || limit > maxLimit {
+		return 0, fmt.Errorf("limit must be an integer between 1 and %d", maxLimit)
+	}
+	return limit, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any, log logrus.FieldLogger) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.WithError(err).Error("cannot marshal data to json")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(data)
+	if err != nil {
+		log.WithError(err).Error("cannot write response")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, e error, log logrus.FieldLogger) {
+	writeJSON(w, status, openapi.Error{Error: e.Error()}, log)
+}
+
+func newMessagesResponse(messages []domain.Message) openapi.MessagesResponse {
+	res := openapi.MessagesResponse{Messages: make([]openapi.Message, 0, len(messages))}
+	for _, msg := range messages {
+		res.Messages = append(res.Messages, newAPIMessage(msg))
+	}
+	return res
+}
+
+func newAPIMessage(msg domain.Message) openapi.Message {
+	return openapi.Message{
+		Id:        msg.ID,
+		Username:  msg.Username,
+		Message:   msg.Text,
+		Room:      msg.Room,
+		ReplyTo:   msg.ReplyTo,
+		Replies:   msg.Replies,
+		Mentions:  msg.Mentions,
+		Reactions: msg.Reactions,
+	}
+}
@@ -0,0 +1,283 @@
+package websocket
+
+import (
+	"bytes"
+	"chat/internal/adapters/websocket/mocks"
+	"chat/internal/adapters/websocket/syncmap"
+	"chat/internal/app"
+	"chat/internal/domain"
+	"context"
+	"errors"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const openAPIPath = "../../../../../api/openapi.yaml"
+
+func TestRest_ResponsesMatchOpenAPI(t *testing.T) {
+	type testcase struct {
+		name   string
+		method string
+		url    string
+		body   string
+		setup  func(a *mocks.App)
+		// limited makes the limiter reject the requests
+		limited bool
+		status  int
+	}
+
+	messages := []domain.Message{
+		{ID: 42, Username: "danil", Text: "Hello, @gleb", Room: domain.DefaultRoom, Mentions: []string{"gleb"}, Replies: 1},
+		{ID: 43, Username: "gleb", Text: "Hello", Room: domain.DefaultRoom, ReplyTo: 42, Reactions: map[string]int{"👍": 2}},
+	}
+
+	tests := []testcase{
+		{
+			name:   "history",
+			method: http.MethodGet,
+			url:    "/api/v1/messages?room=general&before=45&limit=2",
+			setup: func(a *mocks.App) {
+				a.On("LoadHistory", mock.Anything, "general", int64(45), 2).Return(messages, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "history without messages",
+			method: http.MethodGet,
+			url:    "/api/v1/messages",
+			setup: func(a *mocks.App) {
+				a.On("LoadHistory", mock.Anything, "", int64(0), defaultHistoryLimit).Return([]domain.Message{}, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "history with wrong limit",
+			method: http.MethodGet,
+			url:    "/api/v1/messages?limit=1000",
+			setup:  func(*mocks.App) {},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "history with repo error",
+			method: http.MethodGet,
+			url:    "/api/v1/messages?room=random",
+			setup: func(a *mocks.App) {
+				a.On("LoadHistory", mock.Anything, "random", int64(0), defaultHistoryLimit).Return(nil, app.ErrInternal)
+			},
+			status: http.StatusInternalServerError,
+		},
+		{
+			name:   "message",
+			method: http.MethodGet,
+			url:    "/api/v1/messages/43",
+			setup: func(a *mocks.App) {
+				a.On("LoadMessage", mock.Anything, int64(43)).Return(messages[1], nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "message not found",
+			method: http.MethodGet,
+			url:    "/api/v1/messages/7",
+			setup: func(a *mocks.App) {
+				a.On("LoadMessage", mock.Anything, int64(7)).Return(domain.Message{}, app.ErrNotFound)
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "post message",
+			method: http.MethodPost,
+			url:    "/api/v1/messages",
+			body:   `{"username": "danil", "message": "Hello, @gleb", "reply_to": 43}`,
+			setup: func(a *mocks.App) {
+				a.On("Authenticate", mock.Anything, "danil", "").Return(domain.RoleMember, nil)
+				a.On("SaveMessage", mock.Anything, domain.Message{Username: "danil", Text: "Hello, @gleb", Room: domain.DefaultRoom, ReplyTo: 43}).
+					Return(domain.Message{ID: 44, Username: "danil", Text: "Hello, @gleb", Room: domain.DefaultRoom, ReplyTo: 43, Mentions: []string{"gleb"}}, nil)
+			},
+			status: http.StatusCreated,
+		},
+		{
+			name:   "post empty message",
+			method: http.MethodPost,
+			url:    "/api/v1/messages",
+			body:   `{"username": "danil", "message": ""}`,
+			setup:  func(*mocks.App) {},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "post message with unsanitized username",
+			method: http.MethodPost,
+			url:    "/api/v1/messages",
+			body:   `{"username": "danil\u200e", "message": "Hello"}`,
+			setup:  func(*mocks.App) {},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "post too long message",
+			method: http.MethodPost,
+			url:    "/api/v1/messages",
+			body:   `{"username": "danil", "message": "Hello, World"}`,
+			setup: func(a *mocks.App) {
+				a.On("Authenticate", mock.Anything, "danil", "").Return(domain.RoleMember, nil)
+				a.On("SaveMessage", mock.Anything, mock.Anything).Return(domain.Message{}, app.ErrInvalidMessage)
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "post message muted",
+			method: http.MethodPost,
+			url:    "/api/v1/messages",
+			body:   `{"username": "danil", "message": "Hello"}`,
+			setup: func(a *mocks.App) {
+				a.On("Authenticate", mock.Anything, "danil", "").Return(domain.RoleMember, nil)
+				a.On("SaveMessage", mock.Anything, mock.Anything).Return(domain.Message{}, app.ErrMuted)
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "post message banned",
+			method: http.MethodPost,
+			url:    "/api/v1/messages",
+			body:   `{"username": "danil", "message": "Hello"}`,
+			setup: func(a *mocks.App) {
+				a.On("Authenticate", mock.Anything, "danil", "").Return(domain.Role(""), app.ErrBanned)
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "post message without token",
+			method: http.MethodPost,
+			url:    "/api/v1/messages",
+			body:   `{"username": "gleb", "message": "Hello"}`,
+			setup: func(a *mocks.App) {
+				a.On("Authenticate", mock.Anything, "gleb", "").Return(domain.Role(""), app.ErrUnauthorized)
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:    "post message rate limited",
+			method:  http.MethodPost,
+			url:     "/api/v1/messages",
+			body:    `{"username": "danil", "message": "Hello"}`,
+			setup:   func(*mocks.App) {},
+			limited: true,
+			status:  http.StatusTooManyRequests,
+		},
+		{
+			name:   "post message with repo error",
+			method: http.MethodPost,
+			url:    "/api/v1/messages",
+			body:   `{"username": "danil", "message": "Hello", "room": "random"}`,
+			setup: func(a *mocks.App) {
+				a.On("Authenticate", mock.Anything, "danil", "").Return(domain.RoleMember, nil)
+				a.On("SaveMessage", mock.Anything, mock.Anything).Return(domain.Message{}, app.ErrInternal)
+			},
+			status: http.StatusInternalServerError,
+		},
+		{
+			name:   "search",
+			method: http.MethodGet,
+			url:    "/api/v1/messages/search?q=hello",
+			setup: func(a *mocks.App) {
+				a.On("SearchMessages", mock.Anything, "hello", "", defaultSearchLimit).Return(messages, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "search without query",
+			method: http.MethodGet,
+			url:    "/api/v1/messages/search?q=",
+			setup:  func(*mocks.App) {},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:    "search rate limited",
+			method:  http.MethodGet,
+			url:     "/api/v1/messages/search?q=hello",
+			setup:   func(*mocks.App) {},
+			limited: true,
+			status:  http.StatusTooManyRequests,
+		},
+		{
+			name:   "context",
+			method: http.MethodGet,
+			url:    "/api/v1/messages/42/context?limit=1",
+			setup: func(a *mocks.App) {
+				a.On("LoadContext", mock.Anything, int64(42), 1).Return(messages, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "context not found",
+			method: http.MethodGet,
+			url:    "/api/v1/messages/7/context",
+			setup: func(a *mocks.App) {
+				a.On("LoadContext", mock.Anything, int64(7), defaultContextLimit).Return(nil, app.ErrNotFound)
+			},
+			status: http.StatusNotFound,
+		},
+	}
+
+	doc, err := openapi3.NewLoader().LoadFromFile(openAPIPath)
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(context.Background()))
+	router, err := legacy.NewRouter(doc)
+	require.NoError(t, err)
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	cfg := &Config{
+		ReadLimit:       1024,
+		ConnectionLimit: RateLimit{Rate: 1, Burst: 1},
+		UserLimit:       RateLimit{Rate: 1, Burst: 1},
+		IPLimit:         RateLimit{Rate: 1, Burst: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := mocks.NewApp(t)
+			test.setup(a)
+			l := mocks.NewLimiter(t)
+			l.On("Allow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+				Return(!test.limited, nil).
+				Maybe()
+			handler := newRouter(a, l, newHealth(mocks.NewHealthChecker(t)), newDrain(), &websocket.Upgrader{}, syncmap.New(), cfg, log)
+
+			req := httptest.NewRequest(test.method, "http://localhost:8080"+test.url, bytes.NewBufferString(test.body))
+			if test.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, test.status, rec.Code)
+
+			route, params, err := router.FindRoute(req)
+			require.NoError(t, err)
+			err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: &openapi3filter.RequestValidationInput{
+					Request:    req,
+					PathParams: params,
+					Route:      route,
+				},
+				Status: rec.Code,
+				Header: rec.Header(),
+				Body:   io.NopCloser(rec.Body),
+			})
+			var schemaErr *openapi3.SchemaError
+			if errors.As(err, &schemaErr) {
+				t.Log(schemaErr.JSONPointer())
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -2,13 +2,41 @@ package websocket
 
 import (
 	"chat/internal/adapters/websocket/syncmap"
+	"chat/internal/metrics"
 	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 	"net/http"
 )
 
-func newRouter(a App, u *websocket.Upgrader, connections *syncmap.ConnectionsMap, log logrus.FieldLogger) *http.ServeMux {
+func newRouter(
+	a App, l Limiter, h *health, d *drain, u *websocket.Upgrader,
+	connections *syncmap.ConnectionsMap, cfg *Config, log logrus.FieldLogger,
+) *http.ServeMux {
+	limits := newSharedLimits(l, cfg, log)
+	ips := newIPConnections(cfg.MaxConnectionsPerIP)
+	commands := newCommandRegistry()
+
 	r := &http.ServeMux{}
-	r.HandleFunc("/api/v1/chat", createConnection(a, u, connections, log))
+	r.HandleFunc("/api/v1/chat", createConnection(a, commands, limits, ips, d, u, connections, cfg, log))
+	r.HandleFunc("GET /api/v1/messages", loadHistory(a, log))
+	r.HandleFunc("POST /api/v1/messages", postMessage(a, limits, connections, log))
+	r.HandleFunc("GET /api/v1/messages/{id}", loadMessage(a, log))
+	r.HandleFunc("GET /api/v1/messages/search", searchMessages(a, limits, log))
+	r.HandleFunc("GET /api/v1/messages/{id}/context", loadContext(a, log))
+	r.HandleFunc("GET /healthz", h.healthz(log))
+	r.HandleFunc("GET /readyz", h.readyz(log))
 	return r
 }
+
+// newAdminRouter creates the router of the admin API, all requests must have the admin token.
+// The metrics are served here since they expose the load and the errors of the replica.
+func newAdminRouter(a App, connections *syncmap.ConnectionsMap, cfg *Config, log logrus.FieldLogger) http.Handler {
+	r := &http.ServeMux{}
+	r.HandleFunc("GET /admin/v1/connections", listConnections(connections, log))
+	r.HandleFunc("DELETE /admin/v1/connections/{id}", disconnect(connections, log))
+	r.HandleFunc("POST /admin/v1/announcements", announce(a, log))
+	r.HandleFunc("DELETE /admin/v1/users/{username}/messages", purgeMessages(a, log))
+	r.HandleFunc("PUT /admin/v1/rooms/{room}/read-only", setReadOnly(a, log))
+	r.Handle("GET /metrics", metrics.Handler())
+	return adminAuth(cfg.AdminToken, r, log)
+}
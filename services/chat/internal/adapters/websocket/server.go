@@ -4,35 +4,75 @@ import (
 	"chat/internal/adapters/websocket/syncmap"
 	"chat/internal/domain"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 	"net/http"
+	"time"
 )
 
+//go:generate go run github.com/vektra/mockery/v2@v2.42.0 --name=App
 type App interface {
-	SaveMessage(msg string, user string) error
-	LoadLastMessages() ([]domain.Message, error)
+	SaveMessage(ctx context.Context, msg domain.Message) (domain.Message, error)
+	LoadLastMessages(ctx context.Context, room string) ([]domain.Message, error)
+	LoadMissedMessages(ctx context.Context, room string, after int64) ([]domain.Message, bool, error)
+	LoadHistory(ctx context.Context, room string, before int64, limit int) ([]domain.Message, error)
+	LoadMessage(ctx context.Context, id int64) (domain.Message, error)
+	MarkRead(ctx context.Context, username string, room string, messageID int64) error
+	LoadReadMarkers(ctx context.Context, username string) ([]domain.ReadMarker, error)
+	React(ctx context.Context, reaction domain.Reaction) error
+	LoadThread(ctx context.Context, room string, id int64) ([]domain.Message, error)
+	LoadMentions(ctx context.Context, username string) ([]domain.Message, error)
+	SearchMessages(ctx context.Context, query string, room string, limit int) ([]domain.Message, error)
+	LoadContext(ctx context.Context, id int64, limit int) ([]domain.Message, error)
+	Authenticate(ctx context.Context, username string, token string) (domain.Role, error)
+	Moderate(ctx context.Context, m domain.Moderation, role domain.Role) error
+	SubscribeModeration(ctx context.Context) (<-chan domain.Moderation, error)
+	Announce(ctx context.Context, ann domain.Announcement) error
+	SubscribeAnnouncements(ctx context.Context) (<-chan domain.Announcement, error)
+	PurgeMessages(ctx context.Context, username string) (int, error)
+	SetReadOnly(ctx context.Context, room string, readOnly bool) error
 }
 
 type Server struct {
-	srv http.Server
+	srv         http.Server
+	admin       http.Server
+	a           App
+	health      *health
+	drain       *drain
+	connections *syncmap.ConnectionsMap
+	// shutdownDelay is the time between failing the readiness probe and closing the listeners.
+	shutdownDelay time.Duration
+	log           logrus.FieldLogger
 }
 
-func NewServer(a App, cfg *Config, log logrus.FieldLogger) *Server {
+func NewServer(a App, l Limiter, checker HealthChecker, cfg *Config, log logrus.FieldLogger) *Server {
 	upgrader := &websocket.Upgrader{
 		ReadBufferSize:  cfg.ReadBufferSize,
 		WriteBufferSize: cfg.WriteBufferSize,
 	}
 	connections := syncmap.New()
+	h := newHealth(checker)
+	d := newDrain()
 
-	router := newRouter(a, upgrader, connections, log)
+	router := newRouter(a, l, h, d, upgrader, connections, cfg, log)
 
 	return &Server{
 		srv: http.Server{
 			Addr:    fmt.Sprintf(":%s", cfg.Port),
 			Handler: router,
 		},
+		admin: http.Server{
+			Addr:    fmt.Sprintf(":%s", cfg.AdminPort),
+			Handler: newAdminRouter(a, connections, cfg, log),
+		},
+		a:             a,
+		health:        h,
+		drain:         d,
+		connections:   connections,
+		shutdownDelay: cfg.ShutdownDelay,
+		log:           log,
 	}
 }
 
@@ -40,6 +80,56 @@ func (s *Server) ListenAndServe() error {
 	return s.srv.ListenAndServe()
 }
 
+// ListenModeration applies the moderator actions of all replicas to the connections of the server until ctx is done.
+func (s *Server) ListenModeration(ctx context.Context) error {
+	ch, err := s.a.SubscribeModeration(ctx)
+	if err != nil {
+		return err
+	}
+	for m := range ch {
+		applyModeration(m, s.connections, s.log)
+	}
+	return nil
+}
+
+// ListenAndServeAdmin serves the admin API on its own port.
+func (s *Server) ListenAndServeAdmin() error {
+	return s.admin.ListenAndServe()
+}
+
+// ListenAnnouncements sends the announcements of all replicas to the connections of the server until ctx is done.
+func (s *Server) ListenAnnouncements(ctx context.Context) error {
+	ch, err := s.a.SubscribeAnnouncements(ctx)
+	if err != nil {
+		return err
+	}
+	for ann := range ch {
+		sendAnnouncement(ann, s.connections, s.log)
+	}
+	return nil
+}
+
+// GracefulShutdown fails the readiness probe, gives the load balancer the shutdown delay
+// to stop sending new connections to the server, and then closes the listeners.
+// The websocket connections are hijacked, so they are not closed by the listeners: the frames
+// received from them are saved and broadcast, and then the clients get the going away close frame.
 func (s *Server) GracefulShutdown(ctx context.Context) error {
-	return s.srv.Shutdown(ctx)
+	s.health.ready.Store(false)
+
+	select {
+	case <-time.After(s.shutdownDelay):
+	case <-ctx.Done():
+	}
+	err := errors.Join(s.srv.Shutdown(ctx), s.admin.Shutdown(ctx))
+
+	if drainErr := s.drain.wait(ctx); drainErr != nil {
+		s.log.WithError(drainErr).Warn("the received frames are not handled until the shutdown deadline")
+		err = errors.Join(err, drainErr)
+	}
+
+	closeData := websocket.FormatCloseMessage(websocket.CloseGoingAway, goingAwayReason)
+	for conn := range s.connections.LoadAllConnections() {
+		closeConnection(conn, closeData, s.connections, s.log)
+	}
+	return err
 }
@@ -1,33 +1,98 @@
 package syncmap
 
 import (
+	"chat/internal/domain"
+	"chat/internal/metrics"
 	"github.com/gorilla/websocket"
 	"sync"
+	"time"
 )
 
+// Info describes the client behind a stored connection.
+type Info struct {
+	// ID identifies the connection for the operators.
+	ID       string
+	Username string
+	Room     string
+	Role     domain.Role
+	// Addr is the IP address of the client.
+	Addr        string
+	ConnectedAt time.Time
+}
+
+type entry struct {
+	info Info
+	// wmx serializes writes, websocket.Conn supports only one concurrent writer.
+	wmx *sync.Mutex
+}
+
 type ConnectionsMap struct {
 	mx *sync.RWMutex
-	m  map[*websocket.Conn]struct{}
+	m  map[*websocket.Conn]*entry
 }
 
 func New() *ConnectionsMap {
 	return &ConnectionsMap{
 		mx: &sync.RWMutex{},
-		m:  make(map[*websocket.Conn]struct{}),
+		m:  make(map[*websocket.Conn]*entry),
 	}
 }
 
 func (c *ConnectionsMap) LoadAllConnections() <-chan *websocket.Conn {
-	c.mx.Lock()
+	return c.load(func(Info) bool { return true })
+}
+
+// LoadRoomConnections returns connections of the clients joined to the room.
+func (c *ConnectionsMap) LoadRoomConnections(room string) <-chan *websocket.Conn {
+	return c.load(func(info Info) bool { return info.Room == room })
+}
+
+// LoadUserConnections returns connections of the user in all rooms.
+func (c *ConnectionsMap) LoadUserConnections(username string) <-chan *websocket.Conn {
+	return c.load(func(info Info) bool { return info.Username == username })
+}
+
+// LoadUserRoomConnections returns connections of the user joined to the room.
+func (c *ConnectionsMap) LoadUserRoomConnections(username string, room string) <-chan *websocket.Conn {
+	return c.load(func(info Info) bool { return info.Username == username && info.Room == room })
+}
+
+// LoadConnection returns the connection with the ID.
+func (c *ConnectionsMap) LoadConnection(id string) (*websocket.Conn, bool) {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	for conn, e := range c.m {
+		if e.info.ID == id {
+			return conn, true
+		}
+	}
+	return nil, false
+}
+
+// Infos returns the info about the clients of all connections.
+func (c *ConnectionsMap) Infos() []Info {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	infos := make([]Info, 0, len(c.m))
+	for _, e := range c.m {
+		infos = append(infos, e.info)
+	}
+	return infos
+}
+
+func (c *ConnectionsMap) load(filter func(Info) bool) <-chan *websocket.Conn {
+	c.mx.RLock()
 
 	ch := make(chan *websocket.Conn, len(c.m))
 	go func() {
 		defer func() {
-			c.mx.Unlock()
+			c.mx.RUnlock()
 			close(ch)
 		}()
-		for conn := range c.m {
-			ch <- conn
+		for conn, e := range c.m {
+			if filter(e.info) {
+				ch <- conn
+			}
 		}
 	}()
 
@@ -35,13 +100,50 @@ func (c *ConnectionsMap) LoadAllConnections() <-chan *websocket.Conn {
 }
 
 func (c *ConnectionsMap) Store(key *websocket.Conn) {
+	c.StoreWithInfo(key, Info{})
+}
+
+// StoreWithInfo stores the connection along with the info about its client.
+func (c *ConnectionsMap) StoreWithInfo(key *websocket.Conn, info Info) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if _, ok := c.m[key]; !ok {
+		metrics.ActiveConnections.Inc()
+	}
+	c.m[key] = &entry{info: info, wmx: &sync.Mutex{}}
+}
+
+// Info returns the info about the client of the connection.
+func (c *ConnectionsMap) Info(key *websocket.Conn) (Info, bool) {
 	c.mx.RLock()
 	defer c.mx.RUnlock()
-	c.m[key] = struct{}{}
+	e, ok := c.m[key]
+	if !ok {
+		return Info{}, false
+	}
+	return e.info, true
 }
 
-func (c *ConnectionsMap) Delete(key *websocket.Conn) {
+// WriteMessage writes the message to the connection,
+// serializing it with the other writes to the same connection.
+func (c *ConnectionsMap) WriteMessage(key *websocket.Conn, messageType int, data []byte) error {
 	c.mx.RLock()
-	defer c.mx.RUnlock()
+	e, ok := c.m[key]
+	c.mx.RUnlock()
+	if !ok {
+		return key.WriteMessage(messageType, data)
+	}
+
+	e.wmx.Lock()
+	defer e.wmx.Unlock()
+	return key.WriteMessage(messageType, data)
+}
+
+func (c *ConnectionsMap) Delete(key *websocket.Conn) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if _, ok := c.m[key]; ok {
+		metrics.ActiveConnections.Dec()
+	}
 	delete(c.m, key)
 }
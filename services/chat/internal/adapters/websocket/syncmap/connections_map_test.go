@@ -1,7 +1,9 @@
 package syncmap
 
 import (
+	"chat/internal/metrics"
 	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -90,3 +92,186 @@ func TestConnectionsMap_Delete(t *testing.T) {
 		assert.Equal(t, 0, count)
 	}
 }
+
+func TestConnectionsMap_LoadRoomConnections(t *testing.T) {
+	type testcase struct {
+		rooms    []string
+		room     string
+		expected int
+	}
+
+	tests := []testcase{
+		{
+			rooms:    []string{"general", "general", "random"},
+			room:     "general",
+			expected: 2,
+		}, {
+			rooms:    []string{"general", "general", "random"},
+			room:     "random",
+			expected: 1,
+		}, {
+			rooms:    []string{"general"},
+			room:     "random",
+			expected: 0,
+		}, {
+			rooms:    []string{},
+			room:     "general",
+			expected: 0,
+		},
+	}
+
+	for _, test := range tests {
+		connMap := New()
+		for _, room := range test.rooms {
+			connMap.StoreWithInfo(new(websocket.Conn), Info{Username: "danil", Room: room})
+		}
+
+		count := 0
+		for conn := range connMap.LoadRoomConnections(test.room) {
+			info, ok := connMap.Info(conn)
+			assert.True(t, ok)
+			assert.Equal(t, test.room, info.Room)
+			count++
+		}
+		assert.Equal(t, test.expected, count)
+	}
+}
+
+func TestConnectionsMap_LoadUserConnections(t *testing.T) {
+	type testcase struct {
+		infos    []Info
+		username string
+		expected int
+	}
+
+	tests := []testcase{
+		{
+			infos: []Info{
+				{Username: "danil", Room: "general"},
+				{Username: "danil", Room: "random"},
+				{Username: "gleb", Room: "general"},
+			},
+			username: "danil",
+			expected: 2,
+		}, {
+			infos: []Info{
+				{Username: "danil", Room: "general"},
+				{Username: "", Room: "general"},
+			},
+			username: "gleb",
+			expected: 0,
+		}, {
+			infos:    []Info{},
+			username: "danil",
+			expected: 0,
+		},
+	}
+
+	for _, test := range tests {
+		connMap := New()
+		for _, info := range test.infos {
+			connMap.StoreWithInfo(new(websocket.Conn), info)
+		}
+
+		count := 0
+		for conn := range connMap.LoadUserConnections(test.username) {
+			info, ok := connMap.Info(conn)
+			assert.True(t, ok)
+			assert.Equal(t, test.username, info.Username)
+			count++
+		}
+		assert.Equal(t, test.expected, count)
+	}
+}
+
+func TestConnectionsMap_LoadUserRoomConnections(t *testing.T) {
+	type testcase struct {
+		infos    []Info
+		username string
+		room     string
+		expected int
+	}
+
+	tests := []testcase{
+		{
+			infos: []Info{
+				{Username: "danil", Room: "general"},
+				{Username: "danil", Room: "general"},
+				{Username: "danil", Room: "random"},
+				{Username: "gleb", Room: "general"},
+			},
+			username: "danil",
+			room:     "general",
+			expected: 2,
+		}, {
+			infos: []Info{
+				{Username: "danil", Room: "random"},
+				{Username: "gleb", Room: "general"},
+			},
+			username: "danil",
+			room:     "general",
+			expected: 0,
+		}, {
+			infos:    []Info{},
+			username: "danil",
+			room:     "general",
+			expected: 0,
+		},
+	}
+
+	for _, test := range tests {
+		connMap := New()
+		for _, info := range test.infos {
+			connMap.StoreWithInfo(new(websocket.Conn), info)
+		}
+
+		count := 0
+		for conn := range connMap.LoadUserRoomConnections(test.username, test.room) {
+			info, ok := connMap.Info(conn)
+			assert.True(t, ok)
+			assert.Equal(t, test.username, info.Username)
+			assert.Equal(t, test.room, info.Room)
+			count++
+		}
+		assert.Equal(t, test.expected, count)
+	}
+}
+
+func TestConnectionsMap_LoadConnection(t *testing.T) {
+	connMap := New()
+	first, second := new(websocket.Conn), new(websocket.Conn)
+	connMap.StoreWithInfo(first, Info{ID: "first", Username: "danil", Room: "general"})
+	connMap.StoreWithInfo(second, Info{ID: "second", Username: "gleb", Room: "random"})
+
+	conn, ok := connMap.LoadConnection("second")
+	assert.True(t, ok)
+	assert.Same(t, second, conn)
+
+	_, ok = connMap.LoadConnection("third")
+	assert.False(t, ok)
+
+	assert.ElementsMatch(t, []Info{
+		{ID: "first", Username: "danil", Room: "general"},
+		{ID: "second", Username: "gleb", Room: "random"},
+	}, connMap.Infos())
+
+	connMap.Delete(first)
+	_, ok = connMap.LoadConnection("first")
+	assert.False(t, ok)
+	assert.Len(t, connMap.Infos(), 1)
+}
+
+func TestConnectionsMap_ActiveConnections(t *testing.T) {
+	connMap := New()
+	before := testutil.ToFloat64(metrics.ActiveConnections)
+	first, second := new(websocket.Conn), new(websocket.Conn)
+
+	connMap.Store(first)
+	connMap.StoreWithInfo(first, Info{Username: "danil"})
+	connMap.Store(second)
+	assert.Equal(t, before+2, testutil.ToFloat64(metrics.ActiveConnections))
+
+	connMap.Delete(first)
+	connMap.Delete(first)
+	assert.Equal(t, before+1, testutil.ToFloat64(metrics.ActiveConnections))
+}
@@ -0,0 +1,157 @@
+package websocket
+
+import (
+	"bytes"
+	"chat/internal/domain"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"sort"
+	"strings"
+)
+
+//go:generate cp ../../../../../api/schema.json schema.json
+
+// schemaJSON is a copy of api/schema.json, the copy is kept in sync by go generate.
+//
+//go:embed schema.json
+var schemaJSON string
+
+var frameSchema = jsonschema.MustCompileString("schema.json", schemaJSON)
+
+// inboundFrame is a frame received from a client. Frames of all types share
+// the fields, the fields not used by the frame type are left empty.
+type inboundFrame struct {
+	Type     string
+	ID       int64
+	Username string
+	Text     string
+	Emoji    string
+	Action   string
+	ReplyTo  int64
+	Command  string
+	Args     []string
+}
+
+// fieldError describes the field of the frame that is not valid,
+// the field is a JSON pointer, it is empty for errors of the whole frame.
+type fieldError struct {
+	Field string `json:"field"`
+	Error string `json:"error"`
+}
+
+// frameError is returned for frames that don't match the schema.
+type frameError struct {
+	msg    string
+	fields []fieldError
+}
+
+func (e *frameError) Error() string {
+	if len(e.fields) == 0 {
+		return e.msg
+	}
+
+	parts := make([]string, 0, len(e.fields))
+	for _, f := range e.fields {
+		if f.Field == "" {
+			parts = append(parts, f.Error)
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", f.Field, f.Error))
+	}
+	return fmt.Sprintf("%s: %s", e.msg, strings.Join(parts, "; "))
+}
+
+// decodeFrame decodes the frame and validates it against the schema.
+func decodeFrame(data []byte) (inboundFrame, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	var v any
+	err := d.Decode(&v)
+	if err != nil {
+		return inboundFrame{}, &frameError{msg: fmt.Sprintf("malformed frame: %s", err.Error())}
+	}
+
+	err = frameSchema.Validate(v)
+	var validationErr *jsonschema.ValidationError
+	if errors.As(err, &validationErr) {
+		return inboundFrame{}, &frameError{msg: "frame doesn't match the schema", fields: fieldErrors(validationErr)}
+	}
+	if err != nil {
+		return inboundFrame{}, &frameError{msg: err.Error()}
+	}
+
+	// the schema guarantees the frame is an object with the fields of the expected types
+	fields := v.(map[string]any)
+	frame := inboundFrame{
+		Type:     stringField(fields, "type"),
+		ID:       intField(fields, "id"),
+		Username: stringField(fields, "username"),
+		Text:     stringField(fields, "message"),
+		Emoji:    stringField(fields, "emoji"),
+		Action:   stringField(fields, "action"),
+		ReplyTo:  intField(fields, "reply_to"),
+		Command:  stringField(fields, "command"),
+		Args:     stringsField(fields, "args"),
+	}
+	if frame.Type == "" {
+		frame.Type = frameTypeMessage
+	}
+	return frame, nil
+}
+
+// fieldErrors returns the errors of the innermost failed schema keywords.
+func fieldErrors(err *jsonschema.ValidationError) []fieldError {
+	if len(err.Causes) == 0 {
+		return []fieldError{{Field: err.InstanceLocation, Error: err.Message}}
+	}
+
+	res := make([]fieldError, 0, len(err.Causes))
+	seen := make(map[fieldError]struct{})
+	for _, cause := range err.Causes {
+		for _, f := range fieldErrors(cause) {
+			if _, ok := seen[f]; ok {
+				continue
+			}
+			seen[f] = struct{}{}
+			res = append(res, f)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Field < res[j].Field })
+	return res
+}
+
+func stringField(fields map[string]any, name string) string {
+	s, _ := fields[name].(string)
+	return s
+}
+
+func stringsField(fields map[string]any, name string) []string {
+	items, _ := fields[name].([]any)
+	if items == nil {
+		return nil
+	}
+	res := make([]string, 0, len(items))
+	for _, item := range items {
+		s, _ := item.(string)
+		res = append(res, s)
+	}
+	return res
+}
+
+func intField(fields map[string]any, name string) int64 {
+	n, _ := fields[name].(json.Number)
+	i, _ := n.Int64()
+	return i
+}
+
+func (f inboundFrame) message() domain.Message {
+	return domain.Message{Username: f.Username, Text: f.Text, ReplyTo: f.ReplyTo}
+}
+
+func (f inboundFrame) reaction() domain.Reaction {
+	return domain.Reaction{MessageID: f.ID, Username: f.Username, Emoji: f.Emoji, Action: f.Action}
+}
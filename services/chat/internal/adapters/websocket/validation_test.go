@@ -0,0 +1,135 @@
+package websocket
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+const schemaPath = "../../../../../api/schema.json"
+
+func TestSchema_MatchesAPI(t *testing.T) {
+	published, err := os.ReadFile(schemaPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(published), schemaJSON, "schema.json is outdated, run go generate")
+}
+
+func TestDecodeFrame(t *testing.T) {
+	type testcase struct {
+		data     string
+		expected inboundFrame
+		fields   []string
+	}
+
+	tests := []testcase{
+		{
+			data:     `{"username": "danil", "message": "hello"}`,
+			expected: inboundFrame{Type: frameTypeMessage, Username: "danil", Text: "hello"},
+		},
+		{
+			data:     `{"type": "message", "username": "danil", "message": "hi", "reply_to": 1786453129217441793}`,
+			expected: inboundFrame{Type: frameTypeMessage, Username: "danil", Text: "hi", ReplyTo: 1786453129217441793},
+		},
+		{
+			data:     `{"type": "reaction", "id": 42, "username": "danil", "emoji": "👍", "action": "add"}`,
+			expected: inboundFrame{Type: frameTypeReaction, ID: 42, Username: "danil", Emoji: "👍", Action: "add"},
+		},
+		{
+			data:     `{"type": "typing", "username": "danil"}`,
+			expected: inboundFrame{Type: frameTypeTyping, Username: "danil"},
+		},
+		{
+			data:     `{"type": "mentions"}`,
+			expected: inboundFrame{Type: frameTypeMentions},
+		},
+		{
+			data:     `{"type": "command", "command": "mute", "args": ["danil", "10m"]}`,
+			expected: inboundFrame{Type: frameTypeCommand, Command: "mute", Args: []string{"danil", "10m"}},
+		},
+		{
+			data:     `{"type": "command", "command": "help"}`,
+			expected: inboundFrame{Type: frameTypeCommand, Command: "help"},
+		},
+		{
+			data:   `{"type": "command", "command": "/Mute", "args": [1]}`,
+			fields: []string{"/args/0", "/command"},
+		},
+		{
+			data:   `{"username": "danil", "message": "hello", "room": "random"}`,
+			fields: []string{""},
+		},
+		{
+			data:   `{"username": "da", "message": ""}`,
+			fields: []string{"/message", "/username"},
+		},
+		{
+			data:   `{"type": "reaction", "id": 0, "username": "danil", "emoji": "👍", "action": "like"}`,
+			fields: []string{"/action", "/id"},
+		},
+		{
+			data:   `{"type": "thread"}`,
+			fields: []string{""},
+		},
+		{
+			data:   `{"type": "unknown"}`,
+			fields: []string{"/type"},
+		},
+		{
+			data:   `["hello"]`,
+			fields: []string{""},
+		},
+		{
+			data:   `{"username": "danil",`,
+			fields: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		frame, err := decodeFrame([]byte(test.data))
+		if test.expected.Type != "" {
+			assert.NoError(t, err, test.data)
+			assert.Equal(t, test.expected, frame, test.data)
+			continue
+		}
+
+		var fErr *frameError
+		require.True(t, errors.As(err, &fErr), test.data)
+		fields := make([]string, 0, len(fErr.fields))
+		for _, f := range fErr.fields {
+			if len(fields) == 0 || fields[len(fields)-1] != f.Field {
+				fields = append(fields, f.Field)
+			}
+		}
+		assert.Equal(t, test.fields, fields, test.data)
+	}
+}
+
+func TestResumeAfter(t *testing.T) {
+	type testcase struct {
+		query string
+		after int64
+		err   bool
+	}
+
+	tests := []testcase{
+		{query: "", after: 0},
+		{query: "after=42", after: 42},
+		{query: "after=-1", err: true},
+		{query: "after=abc", err: true},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/chat?"+test.query, nil)
+		after, err := resumeAfter(r)
+		if test.err {
+			assert.Error(t, err, test.query)
+			continue
+		}
+		assert.NoError(t, err, test.query)
+		assert.Equal(t, test.after, after, test.query)
+	}
+}
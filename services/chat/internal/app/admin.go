@@ -0,0 +1,57 @@
+package app
+
+import (
+	"chat/internal/domain"
+	"context"
+)
+
+// Announce sends the system announcement to the clients of all replicas.
+func (a *App) Announce(ctx context.Context, ann domain.Announcement) error {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	ann.Text = sanitizeText(ann.Text)
+	if ann.Text == "" {
+		return newInvalidMessageError("announcement text must be non-empty")
+	}
+
+	err := a.repo.PublishAnnouncement(ctx, ann)
+	if err != nil {
+		return newAppError(err)
+	}
+	return nil
+}
+
+// SubscribeAnnouncements returns the announcements sent by all replicas.
+func (a *App) SubscribeAnnouncements(ctx context.Context) (<-chan domain.Announcement, error) {
+	ch, err := a.repo.SubscribeAnnouncements(ctx)
+	if err != nil {
+		return nil, newAppError(err)
+	}
+	return ch, nil
+}
+
+// PurgeMessages deletes all messages of the user and returns their number.
+// The messages still in the queue to the storage are saved after the purge.
+func (a *App) PurgeMessages(ctx context.Context, username string) (int, error) {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	deleted, err := a.repo.DeleteUserMessages(ctx, username)
+	if err != nil {
+		return 0, newAppError(err)
+	}
+	return deleted, nil
+}
+
+// SetReadOnly makes the room read-only or writable again, nobody can send messages to a read-only room.
+func (a *App) SetReadOnly(ctx context.Context, room string, readOnly bool) error {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	err := a.repo.SaveReadOnly(ctx, room, readOnly)
+	if err != nil {
+		return newAppError(err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"chat/internal/app/mocks"
+	"chat/internal/domain"
+	"chat/internal/repository/errs"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestApp_Announce(t *testing.T) {
+	type testcase struct {
+		announcement domain.Announcement
+		published    domain.Announcement
+		repoErr      error
+		err          error
+	}
+
+	tests := []testcase{
+		{
+			announcement: domain.Announcement{Text: "maintenance at 22:00"},
+			published:    domain.Announcement{Text: "maintenance at 22:00"},
+		},
+		{
+			announcement: domain.Announcement{Text: "\x1b[31mwelcome\x1b[0m", Room: "random"},
+			published:    domain.Announcement{Text: "welcome", Room: "random"},
+		},
+		{
+			announcement: domain.Announcement{Text: " \x07 "},
+			err:          ErrInvalidMessage,
+		},
+		{
+			announcement: domain.Announcement{Text: "maintenance at 22:00"},
+			published:    domain.Announcement{Text: "maintenance at 22:00"},
+			repoErr:      errs.ErrInternal,
+			err:          ErrInternal,
+		},
+	}
+
+	for _, test := range tests {
+		repo := mocks.NewLoadSaver(t)
+		if test.published.Text != "" {
+			repo.On("PublishAnnouncement", mock.Anything, test.published).Return(test.repoErr)
+		}
+
+		app := New(repo, nil, &Config{MessagesToLoad: 10})
+		err := app.Announce(context.Background(), test.announcement)
+		if test.err != nil {
+			assert.ErrorIs(t, err, test.err)
+			continue
+		}
+		assert.NoError(t, err)
+	}
+}
+
+func TestApp_PurgeMessages(t *testing.T) {
+	repo := mocks.NewLoadSaver(t)
+	repo.On("DeleteUserMessages", mock.Anything, "danil").Return(42, nil).Once()
+	repo.On("DeleteUserMessages", mock.Anything, "gleb").Return(0, errs.ErrInternal).Once()
+
+	app := New(repo, nil, &Config{MessagesToLoad: 10})
+	deleted, err := app.PurgeMessages(context.Background(), "danil")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, deleted)
+
+	_, err = app.PurgeMessages(context.Background(), "gleb")
+	assert.ErrorIs(t, err, ErrInternal)
+}
+
+func TestApp_SaveMessage_ReadOnly(t *testing.T) {
+	repo := mocks.NewLoadSaver(t)
+	repo.On("LoadBan", mock.Anything, "danil").Return(domain.Ban{}, errs.ErrNotFound)
+	repo.On("LoadMute", mock.Anything, "danil").Return(time.Time{}, nil)
+	repo.On("LoadReadOnly", mock.Anything, "news").Return(true, nil)
+
+	app := New(repo, nil, &Config{MessagesToLoad: 10})
+	_, err := app.SaveMessage(context.Background(), domain.Message{Username: "danil", Text: "hello", Room: "news"})
+	assert.ErrorIs(t, err, ErrForbidden)
+}
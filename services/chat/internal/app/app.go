@@ -2,31 +2,268 @@ package app
 
 import (
 	"chat/internal/domain"
+	"chat/internal/filter"
+	errs "chat/internal/repository/errs"
 	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
 )
 
+// maxMissedMessages limits the messages sent to the client resuming the connection.
+const maxMissedMessages = 100
+
 //go:generate go run github.com/vektra/mockery/v2@v2.42.0 --name=LoadSaver
 type LoadSaver interface {
 	SaveMessage(ctx context.Context, message domain.Message) error
-	LoadMessages(ctx context.Context, count int) ([]domain.Message, error)
+	LoadMessages(ctx context.Context, room string, count int) ([]domain.Message, error)
+	SaveReadMarker(ctx context.Context, marker domain.ReadMarker) error
+	LoadReadMarkers(ctx context.Context, username string) ([]domain.ReadMarker, error)
+	SaveReaction(ctx context.Context, reaction domain.Reaction) error
+	LoadThread(ctx context.Context, room string, id int64) ([]domain.Message, error)
+	LoadMentions(ctx context.Context, username string, count int) ([]domain.Message, error)
+	SearchMessages(ctx context.Context, query string, room string, count int) ([]domain.Message, error)
+	LoadContext(ctx context.Context, id int64, count int) ([]domain.Message, error)
+	LoadHistory(ctx context.Context, room string, before int64, count int) ([]domain.Message, error)
+	LoadMessagesAfter(ctx context.Context, room string, after int64, count int) ([]domain.Message, error)
+	LoadMessage(ctx context.Context, id int64) (domain.Message, error)
+	LoadRoomMessage(ctx context.Context, room string, id int64) (domain.Message, error)
+	LoadUserRole(ctx context.Context, username string) (domain.UserRole, error)
+	LoadBan(ctx context.Context, username string) (domain.Ban, error)
+	SaveBan(ctx context.Context, ban domain.Ban) error
+	DeleteBan(ctx context.Context, username string) error
+	SaveMute(ctx context.Context, username string, d time.Duration) error
+	DeleteMute(ctx context.Context, username string) error
+	LoadMute(ctx context.Context, username string) (time.Time, error)
+	SaveModeration(ctx context.Context, m domain.Moderation) error
+	PublishModeration(ctx context.Context, m domain.Moderation) error
+	SubscribeModeration(ctx context.Context) (<-chan domain.Moderation, error)
+	DeleteUserMessages(ctx context.Context, username string) (int, error)
+	SaveReadOnly(ctx context.Context, room string, readOnly bool) error
+	LoadReadOnly(ctx context.Context, room string) (bool, error)
+	PublishAnnouncement(ctx context.Context, a domain.Announcement) error
+	SubscribeAnnouncements(ctx context.Context) (<-chan domain.Announcement, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.42.0 --name=MessageFilter
+type MessageFilter interface {
+	// Check checks the message before it is saved, the returned message may have the text masked.
+	Check(msg domain.Message) (domain.Message, filter.Verdict)
 }
 
 type App struct {
-	messagesToLoad int
-	repo           LoadSaver
+	messagesToLoad   int
+	maxMessageLength int
+	repo             LoadSaver
+	filter           MessageFilter
+	ids              *idGenerator
+	operationTimeout time.Duration
 }
 
-func New(r LoadSaver, conf *Config) *App {
+// New creates the app, the messages are not filtered if f is nil.
+func New(r LoadSaver, f MessageFilter, conf *Config) *App {
 	return &App{
-		repo:           r,
-		messagesToLoad: conf.MessagesToLoad,
+		repo:             r,
+		filter:           f,
+		messagesToLoad:   conf.MessagesToLoad,
+		maxMessageLength: conf.MaxMessageLength,
+		ids:              newIDGenerator(conf.NodeID),
+		operationTimeout: conf.OperationTimeout,
+	}
+}
+
+// withTimeout limits the repository calls of the operation by the operation timeout,
+// the subscriptions are not limited since they last until their context is done.
+func (a *App) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if a.operationTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, a.operationTimeout)
+}
+
+// SaveMessage sanitizes and filters the text of the message, assigns an ID to it, finds the users mentioned in it and saves it.
+// Shadow-banned messages are returned with Shadowed set and are not saved,
+// banned and muted users and users in read-only rooms cannot send messages.
+// The reply must be to a message of the same room.
+func (a *App) SaveMessage(ctx context.Context, msg domain.Message) (domain.Message, error) {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	if msg.Room == "" {
+		msg.Room = domain.DefaultRoom
+	}
+	err := CheckUsername(msg.Username)
+	if err != nil {
+		return domain.Message{}, err
+	}
+	msg.Text = sanitizeText(msg.Text)
+	if msg.Text == "" {
+		return domain.Message{}, newInvalidMessageError("message text must be non-empty")
+	}
+	if a.maxMessageLength > 0 && utf8.RuneCountInString(msg.Text) > a.maxMessageLength {
+		return domain.Message{}, newInvalidMessageError(fmt.Sprintf("message text must be at most %d characters", a.maxMessageLength))
+	}
+
+	err = a.checkBan(ctx, msg.Username)
+	if err != nil {
+		return domain.Message{}, err
+	}
+
+	until, err := a.repo.LoadMute(ctx, msg.Username)
+	if err != nil {
+		return domain.Message{}, newAppError(err)
+	}
+	if !until.IsZero() {
+		return domain.Message{}, &Error{err: ErrMuted, msg: fmt.Sprintf("you are muted until %s", until.UTC().Format(time.RFC3339))}
+	}
+
+	readOnly, err := a.repo.LoadReadOnly(ctx, msg.Room)
+	if err != nil {
+		return domain.Message{}, newAppError(err)
+	}
+	if readOnly {
+		return domain.Message{}, newForbiddenError(fmt.Sprintf("room %s is read-only", msg.Room))
+	}
+
+	if msg.ReplyTo != 0 {
+		_, err = a.loadRoomMessage(ctx, msg.Room, msg.ReplyTo)
+		if errors.Is(err, ErrNotFound) {
+			return domain.Message{}, newInvalidMessageError(fmt.Sprintf("reply_to: message %d not found in room %s", msg.ReplyTo, msg.Room))
+		}
+		if err != nil {
+			return domain.Message{}, err
+		}
+	}
+
+	if a.filter != nil {
+		var verdict filter.Verdict
+		msg, verdict = a.filter.Check(msg)
+		switch verdict.Action {
+		case filter.ActionReject:
+			return domain.Message{}, newInvalidMessageError(verdict.Reason)
+		case filter.ActionShadowBan:
+			msg.Shadowed = true
+		}
+	}
+
+	msg.ID = a.ids.Next()
+	msg.Mentions = parseMentions(msg.Text)
+	if msg.Shadowed {
+		return msg, nil
+	}
+
+	err = a.repo.SaveMessage(
+		ctx,
+		msg,
+	)
+
+	if err != nil {
+		return domain.Message{}, newAppError(err)
+	}
+	return msg, nil
+}
+
+func (a *App) LoadLastMessages(ctx context.Context, room string) ([]domain.Message, error) {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	messages, err := a.repo.LoadMessages(
+		ctx,
+		room,
+		a.messagesToLoad,
+	)
+
+	if err != nil {
+		return nil, newAppError(err)
+	}
+	return messages, nil
+}
+
+// LoadMissedMessages returns the last messages of the room sent after the message with the given ID,
+// at most maxMissedMessages of them. The result is truncated if more messages were missed,
+// the older missed messages can be loaded with LoadHistory.
+func (a *App) LoadMissedMessages(ctx context.Context, room string, after int64) ([]domain.Message, bool, error) {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	// one more message shows that the result is truncated
+	messages, err := a.repo.LoadMessagesAfter(
+		ctx,
+		room,
+		after,
+		maxMissedMessages+1,
+	)
+
+	if err != nil {
+		return nil, false, newAppError(err)
+	}
+	if len(messages) > maxMissedMessages {
+		return messages[len(messages)-maxMissedMessages:], true, nil
+	}
+	return messages, false, nil
+}
+
+// LoadHistory returns at most limit messages of the room sent before the message with the given ID,
+// the last messages of the room are returned if before is 0.
+func (a *App) LoadHistory(ctx context.Context, room string, before int64, limit int) ([]domain.Message, error) {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	if room == "" {
+		room = domain.DefaultRoom
+	}
+
+	messages, err := a.repo.LoadHistory(
+		ctx,
+		room,
+		before,
+		limit,
+	)
+
+	if err != nil {
+		return nil, newAppError(err)
+	}
+	return messages, nil
+}
+
+// LoadMessage returns the message with the given ID.
+func (a *App) LoadMessage(ctx context.Context, id int64) (domain.Message, error) {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	msg, err := a.repo.LoadMessage(
+		ctx,
+		id,
+	)
+
+	if err != nil {
+		return domain.Message{}, newAppError(err)
+	}
+	return msg, nil
+}
+
+// loadRoomMessage returns the message of the room, the messages of the other rooms are not found,
+// so their IDs cannot be used to reach them.
+func (a *App) loadRoomMessage(ctx context.Context, room string, id int64) (domain.Message, error) {
+	msg, err := a.repo.LoadRoomMessage(ctx, room, id)
+	if errors.Is(err, errs.ErrNotFound) {
+		return domain.Message{}, &Error{err: ErrNotFound, msg: fmt.Sprintf("message %d not found in room %s", id, room)}
+	}
+	if err != nil {
+		return domain.Message{}, newAppError(err)
 	}
+	return msg, nil
 }
 
-func (a *App) SaveMessage(msg string, user string) error {
-	err := a.repo.SaveMessage(
-		context.Background(),
-		domain.Message{Username: user, Text: msg},
+// MarkRead moves the user's read marker in the room forward to the message.
+func (a *App) MarkRead(ctx context.Context, username string, room string, messageID int64) error {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	err := a.repo.SaveReadMarker(
+		ctx,
+		domain.ReadMarker{Username: username, Room: room, MessageID: messageID},
 	)
 
 	if err != nil {
@@ -35,9 +272,86 @@ func (a *App) SaveMessage(msg string, user string) error {
 	return nil
 }
 
-func (a *App) LoadLastMessages() ([]domain.Message, error) {
-	messages, err := a.repo.LoadMessages(
-		context.Background(),
+// LoadReadMarkers returns the user's read markers with the number of unread messages in each room.
+func (a *App) LoadReadMarkers(ctx context.Context, username string) ([]domain.ReadMarker, error) {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	markers, err := a.repo.LoadReadMarkers(
+		ctx,
+		username,
+	)
+
+	if err != nil {
+		return nil, newAppError(err)
+	}
+	return markers, nil
+}
+
+// React adds the reaction to the message of the room or removes it, banned users cannot react.
+func (a *App) React(ctx context.Context, reaction domain.Reaction) error {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	if reaction.Room == "" {
+		reaction.Room = domain.DefaultRoom
+	}
+
+	err := a.checkBan(ctx, reaction.Username)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.loadRoomMessage(ctx, reaction.Room, reaction.MessageID)
+	if err != nil {
+		return err
+	}
+
+	err = a.repo.SaveReaction(
+		ctx,
+		reaction,
+	)
+
+	if err != nil {
+		return newAppError(err)
+	}
+	return nil
+}
+
+// LoadThread returns the message of the room with the given ID followed by the replies to it.
+func (a *App) LoadThread(ctx context.Context, room string, id int64) ([]domain.Message, error) {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	if room == "" {
+		room = domain.DefaultRoom
+	}
+
+	_, err := a.loadRoomMessage(ctx, room, id)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := a.repo.LoadThread(
+		ctx,
+		room,
+		id,
+	)
+
+	if err != nil {
+		return nil, newAppError(err)
+	}
+	return messages, nil
+}
+
+// LoadMentions returns the last messages mentioning the user in all rooms.
+func (a *App) LoadMentions(ctx context.Context, username string) ([]domain.Message, error) {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	messages, err := a.repo.LoadMentions(
+		ctx,
+		username,
 		a.messagesToLoad,
 	)
 
@@ -46,3 +360,40 @@ func (a *App) LoadLastMessages() ([]domain.Message, error) {
 	}
 	return messages, nil
 }
+
+// SearchMessages returns at most limit messages matching the full-text query,
+// the messages are searched in all rooms if the room is empty.
+func (a *App) SearchMessages(ctx context.Context, query string, room string, limit int) ([]domain.Message, error) {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	messages, err := a.repo.SearchMessages(
+		ctx,
+		query,
+		room,
+		limit,
+	)
+
+	if err != nil {
+		return nil, newAppError(err)
+	}
+	return messages, nil
+}
+
+// LoadContext returns the message with the given ID surrounded by
+// at most limit messages before and after it in the same room.
+func (a *App) LoadContext(ctx context.Context, id int64, limit int) ([]domain.Message, error) {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	messages, err := a.repo.LoadContext(
+		ctx,
+		id,
+		limit,
+	)
+
+	if err != nil {
+		return nil, newAppError(err)
+	}
+	return messages, nil
+}
@@ -3,10 +3,13 @@ package app
 import (
 	"chat/internal/app/mocks"
 	"chat/internal/domain"
+	"chat/internal/filter"
 	"chat/internal/repository/errs"
 	"context"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"testing"
+	"time"
 )
 
 func TestApp_New(t *testing.T) {
@@ -32,15 +35,16 @@ func TestApp_New(t *testing.T) {
 			repo: mocks.NewLoadSaver(t),
 		},
 		{
-			conf: &Config{MessagesToLoad: 100},
+			conf: &Config{MessagesToLoad: 100, MaxMessageLength: 4096},
 			repo: mocks.NewLoadSaver(t),
 		},
 	}
 
 	for _, test := range tests {
-		app := New(test.repo, test.conf)
+		app := New(test.repo, nil, test.conf)
 		assert.Equal(t, test.repo, app.repo)
 		assert.Equal(t, test.conf.MessagesToLoad, app.messagesToLoad)
+		assert.Equal(t, test.conf.MaxMessageLength, app.maxMessageLength)
 	}
 }
 
@@ -75,19 +79,25 @@ func TestApp_SaveMessage_NoRepoError(t *testing.T) {
 
 	for _, test := range tests {
 		repo := mocks.NewLoadSaver(t)
+		canWrite(repo)
 		for _, tc := range test {
 			repo.On(
 				"SaveMessage",
-				context.Background(),
-				domain.Message{Username: tc.username, Text: tc.message},
+				mock.Anything,
+				matchMessage(tc.username, tc.message, domain.DefaultRoom),
 			).
 				Return(tc.returnedError)
 		}
 
-		app := New(repo, &Config{MessagesToLoad: 10})
+		app := New(repo, nil, &Config{MessagesToLoad: 10})
+		prevID := int64(0)
 		for _, tc := range test {
-			err := app.SaveMessage(tc.message, tc.username)
+			msg, err := app.SaveMessage(context.Background(), domain.Message{Username: tc.username, Text: tc.message})
 			assert.Equal(t, tc.returnedError, err)
+			assert.Equal(t, tc.username, msg.Username)
+			assert.Equal(t, tc.message, msg.Text)
+			assert.Greater(t, msg.ID, prevID)
+			prevID = msg.ID
 		}
 	}
 }
@@ -123,18 +133,19 @@ func TestApp_SaveMessage_WithRepoError(t *testing.T) {
 
 	for _, test := range tests {
 		repo := mocks.NewLoadSaver(t)
+		canWrite(repo)
 		for _, tc := range test {
 			repo.On(
 				"SaveMessage",
-				context.Background(),
-				domain.Message{Username: tc.username, Text: tc.message},
+				mock.Anything,
+				matchMessage(tc.username, tc.message, domain.DefaultRoom),
 			).
 				Return(tc.returnedError)
 		}
 
-		app := New(repo, &Config{MessagesToLoad: 10})
+		app := New(repo, nil, &Config{MessagesToLoad: 10})
 		for _, tc := range test {
-			err := app.SaveMessage(tc.message, tc.username)
+			_, err := app.SaveMessage(context.Background(), domain.Message{Username: tc.username, Text: tc.message})
 			assert.Error(t, err)
 		}
 	}
@@ -142,6 +153,7 @@ func TestApp_SaveMessage_WithRepoError(t *testing.T) {
 
 func TestApp_LoadLastMessages(t *testing.T) {
 	type testcase struct {
+		room     string
 		count    int
 		messages []domain.Message
 		err      error
@@ -149,6 +161,7 @@ func TestApp_LoadLastMessages(t *testing.T) {
 
 	tests := []testcase{
 		{
+			room:  domain.DefaultRoom,
 			count: 8,
 			messages: []domain.Message{
 				{Username: "danil", Text: "Hello, World"},
@@ -163,13 +176,15 @@ func TestApp_LoadLastMessages(t *testing.T) {
 			err: nil,
 		},
 		{
+			room:  "random",
 			count: 1,
 			messages: []domain.Message{
-				{Username: "danil", Text: "Hello, World"},
+				{Username: "danil", Text: "Hello, World", Room: "random"},
 			},
 			err: nil,
 		},
 		{
+			room:     domain.DefaultRoom,
 			count:    10,
 			messages: nil,
 			err:      errs.ErrNotFound,
@@ -180,12 +195,13 @@ func TestApp_LoadLastMessages(t *testing.T) {
 		repo := mocks.NewLoadSaver(t)
 		repo.On(
 			"LoadMessages",
-			context.Background(),
+			mock.Anything,
+			test.room,
 			test.count,
 		).Return(test.messages, test.err)
 
-		app := New(repo, &Config{MessagesToLoad: test.count})
-		messages, err := app.LoadLastMessages()
+		app := New(repo, nil, &Config{MessagesToLoad: test.count})
+		messages, err := app.LoadLastMessages(context.Background(), test.room)
 		assert.Equal(t, test.messages, messages)
 		if test.err != nil {
 			assert.Error(t, err)
@@ -194,3 +210,786 @@ func TestApp_LoadLastMessages(t *testing.T) {
 		}
 	}
 }
+
+func TestApp_LoadMissedMessages(t *testing.T) {
+	type testcase struct {
+		room      string
+		after     int64
+		messages  []domain.Message
+		expected  []domain.Message
+		truncated bool
+		err       error
+	}
+
+	// missed are one more message than is sent
+	missed := make([]domain.Message, maxMissedMessages+1)
+	for i := range missed {
+		missed[i] = domain.Message{ID: int64(43 + i), Username: "danil", Text: "Hello", Room: "random"}
+	}
+
+	tests := []testcase{
+		{
+			room:  "random",
+			after: 42,
+			messages: []domain.Message{
+				{ID: 43, Username: "danil", Text: "Hello, World", Room: "random"},
+			},
+			expected: []domain.Message{
+				{ID: 43, Username: "danil", Text: "Hello, World", Room: "random"},
+			},
+			err: nil,
+		},
+		{
+			room:      "random",
+			after:     42,
+			messages:  missed,
+			expected:  missed[1:],
+			truncated: true,
+			err:       nil,
+		},
+		{
+			room:     domain.DefaultRoom,
+			after:    42,
+			messages: nil,
+			err:      errs.ErrInternal,
+		},
+	}
+
+	for _, test := range tests {
+		repo := mocks.NewLoadSaver(t)
+		repo.On(
+			"LoadMessagesAfter",
+			mock.Anything,
+			test.room,
+			test.after,
+			maxMissedMessages+1,
+		).Return(test.messages, test.err)
+
+		app := New(repo, nil, &Config{MessagesToLoad: 10})
+		messages, truncated, err := app.LoadMissedMessages(context.Background(), test.room, test.after)
+		assert.Equal(t, test.expected, messages)
+		assert.Equal(t, test.truncated, truncated)
+		if test.err != nil {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestApp_MarkRead(t *testing.T) {
+	type testcase struct {
+		marker domain.ReadMarker
+		err    error
+	}
+
+	tests := []testcase{
+		{
+			marker: domain.ReadMarker{Username: "danil", Room: domain.DefaultRoom, MessageID: 42},
+			err:    nil,
+		},
+		{
+			marker: domain.ReadMarker{Username: "gleb", Room: "random", MessageID: 1},
+			err:    errs.ErrInternal,
+		},
+	}
+
+	for _, test := range tests {
+		repo := mocks.NewLoadSaver(t)
+		repo.On(
+			"SaveReadMarker",
+			mock.Anything,
+			test.marker,
+		).Return(test.err)
+
+		app := New(repo, nil, &Config{MessagesToLoad: 10})
+		err := app.MarkRead(context.Background(), test.marker.Username, test.marker.Room, test.marker.MessageID)
+		if test.err != nil {
+			assert.ErrorIs(t, err, ErrInternal)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestApp_LoadReadMarkers(t *testing.T) {
+	type testcase struct {
+		username string
+		markers  []domain.ReadMarker
+		err      error
+	}
+
+	tests := []testcase{
+		{
+			username: "danil",
+			markers: []domain.ReadMarker{
+				{Username: "danil", Room: domain.DefaultRoom, MessageID: 42, Unread: 3},
+				{Username: "danil", Room: "random", MessageID: 7, Unread: 0},
+			},
+			err: nil,
+		},
+		{
+			username: "gleb",
+			markers:  nil,
+			err:      errs.ErrInternal,
+		},
+	}
+
+	for _, test := range tests {
+		repo := mocks.NewLoadSaver(t)
+		repo.On(
+			"LoadReadMarkers",
+			mock.Anything,
+			test.username,
+		).Return(test.markers, test.err)
+
+		app := New(repo, nil, &Config{MessagesToLoad: 10})
+		markers, err := app.LoadReadMarkers(context.Background(), test.username)
+		assert.Equal(t, test.markers, markers)
+		if test.err != nil {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestApp_React(t *testing.T) {
+	type testcase struct {
+		reaction domain.Reaction
+		expected domain.Reaction
+		banned   bool
+		// loadErr is returned by the repository for the message reacted to
+		loadErr error
+		err     error
+	}
+
+	tests := []testcase{
+		{
+			reaction: domain.Reaction{MessageID: 42, Username: "danil", Room: "random", Emoji: "👍", Action: domain.ReactionAdd},
+			expected: domain.Reaction{MessageID: 42, Username: "danil", Room: "random", Emoji: "👍", Action: domain.ReactionAdd},
+			err:      nil,
+		},
+		{
+			reaction: domain.Reaction{MessageID: 42, Username: "gleb", Emoji: "🔥", Action: domain.ReactionRemove},
+			expected: domain.Reaction{MessageID: 42, Username: "gleb", Room: domain.DefaultRoom, Emoji: "🔥", Action: domain.ReactionRemove},
+			err:      nil,
+		},
+		{
+			reaction: domain.Reaction{MessageID: 1, Username: "maks", Room: "random", Emoji: "👍", Action: domain.ReactionAdd},
+			expected: domain.Reaction{MessageID: 1, Username: "maks", Room: "random", Emoji: "👍", Action: domain.ReactionAdd},
+			err:      ErrInternal,
+		},
+		{
+			// the message doesn't exist or belongs to another room
+			reaction: domain.Reaction{MessageID: 7, Username: "maks", Room: "random", Emoji: "👍", Action: domain.ReactionAdd},
+			loadErr:  errs.ErrNotFound,
+			err:      ErrNotFound,
+		},
+		{
+			reaction: domain.Reaction{MessageID: 42, Username: "spammer", Room: "random", Emoji: "👍", Action: domain.ReactionAdd},
+			banned:   true,
+			err:      ErrBanned,
+		},
+	}
+
+	for _, test := range tests {
+		repo := mocks.NewLoadSaver(t)
+		if test.banned {
+			repo.On("LoadBan", mock.Anything, test.reaction.Username).Return(domain.Ban{Username: test.reaction.Username}, nil)
+
+			app := New(repo, nil, &Config{MessagesToLoad: 10})
+			err := app.React(context.Background(), test.reaction)
+			assert.ErrorIs(t, err, test.err)
+			continue
+		}
+
+		repo.On("LoadBan", mock.Anything, test.reaction.Username).Return(domain.Ban{}, errs.ErrNotFound)
+		room := test.reaction.Room
+		if room == "" {
+			room = domain.DefaultRoom
+		}
+		repo.On(
+			"LoadRoomMessage",
+			mock.Anything,
+			room,
+			test.reaction.MessageID,
+		).Return(domain.Message{ID: test.reaction.MessageID, Room: room}, test.loadErr)
+		if test.loadErr == nil {
+			var saveErr error
+			if test.err != nil {
+				saveErr = errs.ErrInternal
+			}
+			repo.On(
+				"SaveReaction",
+				mock.Anything,
+				test.expected,
+			).Return(saveErr)
+		}
+
+		app := New(repo, nil, &Config{MessagesToLoad: 10})
+		err := app.React(context.Background(), test.reaction)
+		if test.err != nil {
+			assert.ErrorIs(t, err, test.err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestApp_LoadThread(t *testing.T) {
+	type testcase struct {
+		room     string
+		id       int64
+		messages []domain.Message
+		expected []domain.Message
+		// loadErr is returned by the repository for the root of the thread
+		loadErr error
+		err     error
+	}
+
+	tests := []testcase{
+		{
+			room: domain.DefaultRoom,
+			id:   42,
+			messages: []domain.Message{
+				{ID: 42, Username: "danil", Text: "Hello, World", Room: domain.DefaultRoom, Replies: 2},
+				{ID: 43, Username: "gleb", Text: "Hello", Room: domain.DefaultRoom, ReplyTo: 42},
+				{ID: 45, Username: "maks", Text: "Hi", Room: domain.DefaultRoom, ReplyTo: 42},
+			},
+			expected: []domain.Message{
+				{ID: 42, Username: "danil", Text: "Hello, World", Room: domain.DefaultRoom, Replies: 2},
+				{ID: 43, Username: "gleb", Text: "Hello", Room: domain.DefaultRoom, ReplyTo: 42},
+				{ID: 45, Username: "maks", Text: "Hi", Room: domain.DefaultRoom, ReplyTo: 42},
+			},
+			err: nil,
+		},
+		{
+			room: "random",
+			id:   42,
+			messages: []domain.Message{
+				{ID: 42, Username: "danil", Text: "Hello, World", Room: "random", Replies: 1},
+				{ID: 45, Username: "maks", Text: "Hi", Room: "random", ReplyTo: 42},
+			},
+			expected: []domain.Message{
+				{ID: 42, Username: "danil", Text: "Hello, World", Room: "random", Replies: 1},
+				{ID: 45, Username: "maks", Text: "Hi", Room: "random", ReplyTo: 42},
+			},
+			err: nil,
+		},
+		{
+			// the message doesn't exist or belongs to another room
+			room:    domain.DefaultRoom,
+			id:      7,
+			loadErr: errs.ErrNotFound,
+			err:     ErrNotFound,
+		},
+		{
+			room:    domain.DefaultRoom,
+			id:      8,
+			loadErr: errs.ErrInternal,
+			err:     ErrInternal,
+		},
+	}
+
+	for _, test := range tests {
+		repo := mocks.NewLoadSaver(t)
+		repo.On(
+			"LoadRoomMessage",
+			mock.Anything,
+			test.room,
+			test.id,
+		).Return(domain.Message{ID: test.id, Room: test.room}, test.loadErr)
+		if test.loadErr == nil {
+			repo.On(
+				"LoadThread",
+				mock.Anything,
+				test.room,
+				test.id,
+			).Return(test.messages, nil)
+		}
+
+		app := New(repo, nil, &Config{MessagesToLoad: 10})
+		messages, err := app.LoadThread(context.Background(), test.room, test.id)
+		assert.Equal(t, test.expected, messages)
+		if test.err != nil {
+			assert.ErrorIs(t, err, test.err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestApp_SaveMessage_Reply(t *testing.T) {
+	type testcase struct {
+		replyTo int64
+		// loadErr is returned by the repository for the message replied to
+		loadErr error
+		err     error
+	}
+
+	tests := []testcase{
+		{replyTo: 42, loadErr: nil, err: nil},
+		// the message doesn't exist or belongs to another room
+		{replyTo: 7, loadErr: errs.ErrNotFound, err: ErrInvalidMessage},
+		{replyTo: 8, loadErr: errs.ErrInternal, err: ErrInternal},
+	}
+
+	for _, test := range tests {
+		repo := mocks.NewLoadSaver(t)
+		canWrite(repo)
+		repo.On(
+			"LoadRoomMessage",
+			mock.Anything,
+			"random",
+			test.replyTo,
+		).Return(domain.Message{ID: test.replyTo, Room: "random"}, test.loadErr)
+		if test.err == nil {
+			repo.On(
+				"SaveMessage",
+				mock.Anything,
+				matchMessage("danil", "hello", "random"),
+			).Return(nil)
+		}
+
+		app := New(repo, nil, &Config{MessagesToLoad: 10})
+		msg, err := app.SaveMessage(context.Background(), domain.Message{Username: "danil", Text: "hello", Room: "random", ReplyTo: test.replyTo})
+		if test.err != nil {
+			assert.ErrorIs(t, err, test.err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, test.replyTo, msg.ReplyTo)
+		}
+	}
+}
+
+func TestApp_SaveMessage_Sanitized(t *testing.T) {
+	type testcase struct {
+		username string
+		message  string
+		expected string
+		err      error
+	}
+
+	tests := []testcase{
+		{username: "danil", message: "\x1b[31mhello\x1b[0m", expected: "hello", err: nil},
+		{username: "danil", message: " салют ", expected: "салют", err: nil},
+		{username: "danil", message: "12345", expected: "12345", err: nil},
+		{username: "danil", message: "ёёёёё", expected: "ёёёёё", err: nil},
+		{username: "danil", message: "123456", err: ErrInvalidMessage},
+		{username: "danil", message: "\x1b[2J\r\n", err: ErrInvalidMessage},
+		// the names shown as the name of another user are rejected, not rewritten
+		{username: "\x1b[1mdanil", message: "hello", err: ErrInvalidMessage},
+		{username: "danil\u200e", message: "hello", err: ErrInvalidMessage},
+		{username: "danil ", message: "hello", err: ErrInvalidMessage},
+	}
+
+	for _, test := range tests {
+		repo := mocks.NewLoadSaver(t)
+		canWrite(repo)
+		if test.err == nil {
+			repo.On(
+				"SaveMessage",
+				mock.Anything,
+				matchMessage("danil", test.expected, domain.DefaultRoom),
+			).Return(nil)
+		}
+
+		app := New(repo, nil, &Config{MessagesToLoad: 10, MaxMessageLength: 5})
+		msg, err := app.SaveMessage(context.Background(), domain.Message{Username: test.username, Text: test.message})
+		if test.err != nil {
+			assert.ErrorIs(t, err, test.err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, msg.Text)
+	}
+}
+
+func TestApp_SaveMessage_Filtered(t *testing.T) {
+	type testcase struct {
+		message  string
+		filtered string
+		verdict  filter.Verdict
+		saved    bool
+		shadowed bool
+		err      error
+	}
+
+	tests := []testcase{
+		{
+			message:  "hello, @gleb",
+			filtered: "hello, @gleb",
+			verdict:  filter.Verdict{Action: filter.ActionAllow},
+			saved:    true,
+		},
+		{
+			message:  "hello, idiot",
+			filtered: "hello, *****",
+			verdict:  filter.Verdict{Action: filter.ActionMask, Filter: "words", Reason: "masked words: idiot"},
+			saved:    true,
+		},
+		{
+			message: "hello, hello",
+			verdict: filter.Verdict{Action: filter.ActionReject, Filter: "flood", Reason: "too many messages"},
+			err:     ErrInvalidMessage,
+		},
+		{
+			message:  "casino, @gleb",
+			filtered: "casino, @gleb",
+			verdict:  filter.Verdict{Action: filter.ActionShadowBan, Filter: "words", Reason: "forbidden words: casino"},
+			shadowed: true,
+		},
+	}
+
+	for _, test := range tests {
+		repo := mocks.NewLoadSaver(t)
+		canWrite(repo)
+		if test.saved {
+			repo.On(
+				"SaveMessage",
+				mock.Anything,
+				matchMessage("danil", test.filtered, domain.DefaultRoom),
+			).Return(nil)
+		}
+		f := mocks.NewMessageFilter(t)
+		f.On("Check", domain.Message{Username: "danil", Text: test.message, Room: domain.DefaultRoom}).
+			Return(domain.Message{Username: "danil", Text: test.filtered, Room: domain.DefaultRoom}, test.verdict)
+
+		app := New(repo, f, &Config{MessagesToLoad: 10})
+		msg, err := app.SaveMessage(context.Background(), domain.Message{Username: "danil", Text: test.message})
+		if test.err != nil {
+			assert.ErrorIs(t, err, test.err)
+			assert.ErrorContains(t, err, test.verdict.Reason)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.NotZero(t, msg.ID)
+		assert.Equal(t, test.filtered, msg.Text)
+		assert.Equal(t, test.shadowed, msg.Shadowed)
+	}
+}
+
+func TestApp_SaveMessage_Mentions(t *testing.T) {
+	type testcase struct {
+		message  string
+		mentions []string
+	}
+
+	tests := []testcase{
+		{message: "hello", mentions: nil},
+		{message: "hello, @gleb", mentions: []string{"gleb"}},
+		{message: "@maks @gleb @maks look", mentions: []string{"maks", "gleb"}},
+	}
+
+	for _, test := range tests {
+		repo := mocks.NewLoadSaver(t)
+		canWrite(repo)
+		repo.On(
+			"SaveMessage",
+			mock.Anything,
+			mock.MatchedBy(func(msg domain.Message) bool {
+				return assert.ObjectsAreEqual(test.mentions, msg.Mentions)
+			}),
+		).Return(nil)
+
+		app := New(repo, nil, &Config{MessagesToLoad: 10})
+		msg, err := app.SaveMessage(context.Background(), domain.Message{Username: "danil", Text: test.message})
+		assert.NoError(t, err)
+		assert.Equal(t, test.mentions, msg.Mentions)
+	}
+}
+
+func TestApp_LoadMentions(t *testing.T) {
+	type testcase struct {
+		username string
+		messages []domain.Message
+		err      error
+	}
+
+	tests := []testcase{
+		{
+			username: "gleb",
+			messages: []domain.Message{
+				{ID: 42, Username: "danil", Text: "hi @gleb", Room: domain.DefaultRoom},
+				{ID: 45, Username: "maks", Text: "@gleb look", Room: "random"},
+			},
+			err: nil,
+		},
+		{
+			username: "maks",
+			messages: nil,
+			err:      errs.ErrInternal,
+		},
+	}
+
+	for _, test := range tests {
+		repo := mocks.NewLoadSaver(t)
+		repo.On(
+			"LoadMentions",
+			mock.Anything,
+			test.username,
+			10,
+		).Return(test.messages, test.err)
+
+		app := New(repo, nil, &Config{MessagesToLoad: 10})
+		messages, err := app.LoadMentions(context.Background(), test.username)
+		assert.Equal(t, test.messages, messages)
+		if test.err != nil {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestApp_SearchMessages(t *testing.T) {
+	type testcase struct {
+		query    string
+		room     string
+		limit    int
+		messages []domain.Message
+		err      error
+	}
+
+	tests := []testcase{
+		{
+			query: "hello",
+			room:  "",
+			limit: 20,
+			messages: []domain.Message{
+				{ID: 45, Username: "gleb", Text: "hello", Room: "random"},
+				{ID: 42, Username: "danil", Text: "Hello, World", Room: domain.DefaultRoom},
+			},
+			err: nil,
+		},
+		{
+			query:    "bye",
+			room:     domain.DefaultRoom,
+			limit:    5,
+			messages: []domain.Message{},
+			err:      nil,
+		},
+		{
+			query:    "hello",
+			room:     "random",
+			limit:    20,
+			messages: nil,
+			err:      errs.ErrInternal,
+		},
+	}
+
+	for _, test := range tests {
+		repo := mocks.NewLoadSaver(t)
+		repo.On(
+			"SearchMessages",
+			mock.Anything,
+			test.query,
+			test.room,
+			test.limit,
+		).Return(test.messages, test.err)
+
+		app := New(repo, nil, &Config{MessagesToLoad: 10})
+		messages, err := app.SearchMessages(context.Background(), test.query, test.room, test.limit)
+		assert.Equal(t, test.messages, messages)
+		if test.err != nil {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestApp_LoadContext(t *testing.T) {
+	type testcase struct {
+		id       int64
+		limit    int
+		messages []domain.Message
+		err      error
+	}
+
+	tests := []testcase{
+		{
+			id:    43,
+			limit: 1,
+			messages: []domain.Message{
+				{ID: 42, Username: "danil", Text: "Hello, World", Room: domain.DefaultRoom},
+				{ID: 43, Username: "gleb", Text: "hello", Room: domain.DefaultRoom},
+				{ID: 45, Username: "maks", Text: "Hi", Room: domain.DefaultRoom},
+			},
+			err: nil,
+		},
+		{
+			id:       7,
+			limit:    10,
+			messages: nil,
+			err:      errs.ErrNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		repo := mocks.NewLoadSaver(t)
+		repo.On(
+			"LoadContext",
+			mock.Anything,
+			test.id,
+			test.limit,
+		).Return(test.messages, test.err)
+
+		app := New(repo, nil, &Config{MessagesToLoad: 10})
+		messages, err := app.LoadContext(context.Background(), test.id, test.limit)
+		assert.Equal(t, test.messages, messages)
+		if test.err != nil {
+			assert.ErrorIs(t, err, ErrNotFound)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestApp_LoadHistory(t *testing.T) {
+	type testcase struct {
+		room     string
+		expected string
+		before   int64
+		limit    int
+		messages []domain.Message
+		err      error
+	}
+
+	tests := []testcase{
+		{
+			room:     "random",
+			expected: "random",
+			before:   45,
+			limit:    2,
+			messages: []domain.Message{
+				{ID: 42, Username: "danil", Text: "Hello, World", Room: "random"},
+				{ID: 43, Username: "gleb", Text: "hello", Room: "random"},
+			},
+			err: nil,
+		},
+		{
+			room:     "",
+			expected: domain.DefaultRoom,
+			before:   0,
+			limit:    50,
+			messages: []domain.Message{},
+			err:      nil,
+		},
+		{
+			room:     "random",
+			expected: "random",
+			before:   0,
+			limit:    50,
+			messages: nil,
+			err:      errs.ErrInternal,
+		},
+	}
+
+	for _, test := range tests {
+		repo := mocks.NewLoadSaver(t)
+		repo.On(
+			"LoadHistory",
+			mock.Anything,
+			test.expected,
+			test.before,
+			test.limit,
+		).Return(test.messages, test.err)
+
+		app := New(repo, nil, &Config{MessagesToLoad: 10})
+		messages, err := app.LoadHistory(context.Background(), test.room, test.before, test.limit)
+		assert.Equal(t, test.messages, messages)
+		if test.err != nil {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestApp_LoadMessage(t *testing.T) {
+	type testcase struct {
+		id      int64
+		message domain.Message
+		err     error
+	}
+
+	tests := []testcase{
+		{
+			id:      42,
+			message: domain.Message{ID: 42, Username: "danil", Text: "Hello, World", Room: domain.DefaultRoom},
+			err:     nil,
+		},
+		{
+			id:      7,
+			message: domain.Message{},
+			err:     errs.ErrNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		repo := mocks.NewLoadSaver(t)
+		repo.On(
+			"LoadMessage",
+			mock.Anything,
+			test.id,
+		).Return(test.message, test.err)
+
+		app := New(repo, nil, &Config{MessagesToLoad: 10})
+		msg, err := app.LoadMessage(context.Background(), test.id)
+		assert.Equal(t, test.message, msg)
+		if test.err != nil {
+			assert.ErrorIs(t, err, ErrNotFound)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestApp_OperationTimeout(t *testing.T) {
+	type testcase struct {
+		name     string
+		timeout  time.Duration
+		deadline bool
+	}
+
+	tests := []testcase{
+		{name: "limited", timeout: time.Second, deadline: true},
+		{name: "unlimited", timeout: 0, deadline: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var ctx context.Context
+			repo := mocks.NewLoadSaver(t)
+			repo.On("LoadMessage", mock.Anything, int64(42)).
+				Run(func(args mock.Arguments) { ctx = args.Get(0).(context.Context) }).
+				Return(domain.Message{ID: 42}, nil)
+
+			app := New(repo, nil, &Config{MessagesToLoad: 10, OperationTimeout: test.timeout})
+			_, err := app.LoadMessage(context.Background(), 42)
+			assert.NoError(t, err)
+
+			_, ok := ctx.Deadline()
+			assert.Equal(t, test.deadline, ok)
+			// the context of the operation is cancelled after it returns
+			assert.ErrorIs(t, ctx.Err(), context.Canceled)
+		})
+	}
+}
+
+// matchMessage matches the saved message by its content, ignoring the generated ID.
+func matchMessage(username string, text string, room string) any {
+	return mock.MatchedBy(func(msg domain.Message) bool {
+		return msg.ID != 0 && msg.Username == username && msg.Text == text && msg.Room == room
+	})
+}
+
+// canWrite lets the repo load the bans and the mutes of the users and the read-only rooms,
+// nobody is banned or muted and no room is read-only.
+func canWrite(repo *mocks.LoadSaver) {
+	repo.On("LoadBan", mock.Anything, mock.Anything).Return(domain.Ban{}, errs.ErrNotFound).Maybe()
+	repo.On("LoadMute", mock.Anything, mock.Anything).Return(time.Time{}, nil).Maybe()
+	repo.On("LoadReadOnly", mock.Anything, mock.Anything).Return(false, nil).Maybe()
+}
@@ -1,5 +1,13 @@
 package app
 
+import "time"
+
 type Config struct {
 	MessagesToLoad int
+	// NodeID distinguishes message IDs generated by different replicas of the service.
+	NodeID int64
+	// MaxMessageLength is the maximal number of characters in the message text, 0 disables the limit.
+	MaxMessageLength int
+	// OperationTimeout limits the time of the repository calls made by one operation, 0 disables the limit.
+	OperationTimeout time.Duration
 }
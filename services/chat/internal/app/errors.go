@@ -9,6 +9,14 @@ import (
 var (
 	ErrInternal = errors.New("internal error")
 	ErrNotFound = errors.New("data not found")
+	// ErrInvalidMessage is returned for messages that cannot be saved due to their content.
+	ErrInvalidMessage = errors.New("invalid message")
+	// ErrUnauthorized is returned for users connecting with a reserved username without its token.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden is returned for actions the user's role doesn't allow.
+	ErrForbidden = errors.New("forbidden")
+	ErrBanned    = errors.New("banned")
+	ErrMuted     = errors.New("muted")
 )
 
 type Error struct {
@@ -37,3 +45,11 @@ func newAppError(e error) *Error {
 		return &Error{err: ErrInternal, msg: e.Error()}
 	}
 }
+
+func newInvalidMessageError(msg string) *Error {
+	return &Error{err: ErrInvalidMessage, msg: msg}
+}
+
+func newForbiddenError(msg string) *Error {
+	return &Error{err: ErrForbidden, msg: msg}
+}
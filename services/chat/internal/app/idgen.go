@@ -0,0 +1,59 @@
+package app
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	nodeBits     = 10
+	sequenceBits = 12
+
+	maxNodeID   = 1<<nodeBits - 1
+	maxSequence = 1<<sequenceBits - 1
+)
+
+// idEpoch is the start of the time counted in message IDs.
+var idEpoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// idGenerator generates unique message IDs ordered by creation time.
+// An ID consists of milliseconds since idEpoch, the node ID and a sequence number,
+// so the chat service replicas must be started with different node IDs.
+type idGenerator struct {
+	mx       sync.Mutex
+	node     int64
+	lastMs   int64
+	sequence int64
+	now      func() time.Time
+}
+
+func newIDGenerator(node int64) *idGenerator {
+	return &idGenerator{
+		node: node & maxNodeID,
+		now:  time.Now,
+	}
+}
+
+func (g *idGenerator) Next() int64 {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	ms := g.now().Sub(idEpoch).Milliseconds()
+	if ms < g.lastMs {
+		// the clock went backwards, keep IDs growing
+		ms = g.lastMs
+	}
+
+	if ms == g.lastMs {
+		g.sequence = (g.sequence + 1) & maxSequence
+		if g.sequence == 0 {
+			// the sequence is exhausted, borrow the next millisecond
+			ms++
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.lastMs = ms
+
+	return ms<<(nodeBits+sequenceBits) | g.node<<sequenceBits | g.sequence
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestIDGenerator_Next(t *testing.T) {
+	type testcase struct {
+		node  int64
+		count int
+	}
+
+	tests := []testcase{
+		{node: 0, count: 1},
+		{node: 1, count: 100},
+		{node: 7, count: 10000},
+		{node: maxNodeID, count: 3 * maxSequence},
+	}
+
+	for _, test := range tests {
+		g := newIDGenerator(test.node)
+		prev := int64(0)
+		for i := 0; i < test.count; i++ {
+			id := g.Next()
+			assert.Greater(t, id, prev)
+			assert.Equal(t, test.node, id>>sequenceBits&maxNodeID)
+			prev = id
+		}
+	}
+}
+
+func TestIDGenerator_ClockGoesBackwards(t *testing.T) {
+	now := time.Now()
+	g := newIDGenerator(1)
+	g.now = func() time.Time { return now }
+	first := g.Next()
+
+	g.now = func() time.Time { return now.Add(-time.Second) }
+	second := g.Next()
+
+	assert.Greater(t, second, first)
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"regexp"
+)
+
+// maxMentions limits the users mentioned in one message, the mentions after it are not notified,
+// so a single message cannot notify the whole chat.
+const maxMentions = 10
+
+// mentionRegexp matches @username, the mention ends at the first character
+// that cannot be a part of a username, e.g. "@alice, hi" mentions "alice".
+var mentionRegexp = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@-])@([\p{L}\p{N}_-]{3,128})`)
+
+// parseMentions returns at most maxMentions unique usernames mentioned in the text in order of appearance.
+func parseMentions(text string) []string {
+	matches := mentionRegexp.FindAllStringSubmatch(text, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]struct{}, maxMentions)
+	mentions := make([]string, 0, min(len(matches), maxMentions))
+	for _, m := range matches {
+		if len(mentions) == maxMentions {
+			break
+		}
+		if _, ok := seen[m[1]]; ok {
+			continue
+		}
+		seen[m[1]] = struct{}{}
+		mentions = append(mentions, m[1])
+	}
+	return mentions
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	type testcase struct {
+		text     string
+		expected []string
+	}
+
+	tests := []testcase{
+		{text: "hello", expected: nil},
+		{text: "@danil", expected: []string{"danil"}},
+		{text: "@danil, @gleb and @maks!", expected: []string{"danil", "gleb", "maks"}},
+		{text: "@danil @danil", expected: []string{"danil"}},
+		{text: "hi @да_нил.", expected: []string{"да_нил"}},
+		{text: "mail me: danil@example.com", expected: nil},
+		{text: "@@danil", expected: nil},
+		{text: "@ab is too short", expected: nil},
+		{text: "(@gleb-2)", expected: []string{"gleb-2"}},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, parseMentions(test.text), test.text)
+	}
+}
+
+func TestParseMentions_Limit(t *testing.T) {
+	var text strings.Builder
+	expected := make([]string, 0, maxMentions)
+	for i := 0; i < maxMentions+5; i++ {
+		// the repeated mentions don't count towards the limit
+		fmt.Fprintf(&text, "@user%d @user%d ", i, i)
+		if i < maxMentions {
+			expected = append(expected, fmt.Sprintf("user%d", i))
+		}
+	}
+
+	assert.Equal(t, expected, parseMentions(text.String()))
+}
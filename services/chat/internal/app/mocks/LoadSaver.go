@@ -5,6 +5,7 @@ package mocks
 import (
 	domain "chat/internal/domain"
 	context "context"
+	time "time"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -14,9 +15,219 @@ type LoadSaver struct {
 	mock.Mock
 }
 
-// LoadMessages provides a mock function with given fields: ctx, count
-func (_m *LoadSaver) LoadMessages(ctx context.Context, count int) ([]domain.Message, error) {
-	ret := _m.Called(ctx, count)
+// DeleteBan provides a mock function with given fields: ctx, username
+func (_m *LoadSaver) DeleteBan(ctx context.Context, username string) error {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteBan")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteMute provides a mock function with given fields: ctx, username
+func (_m *LoadSaver) DeleteMute(ctx context.Context, username string) error {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteMute")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteUserMessages provides a mock function with given fields: ctx, username
+func (_m *LoadSaver) DeleteUserMessages(ctx context.Context, username string) (int, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteUserMessages")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoadBan provides a mock function with given fields: ctx, username
+func (_m *LoadSaver) LoadBan(ctx context.Context, username string) (domain.Ban, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoadBan")
+	}
+
+	var r0 domain.Ban
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (domain.Ban, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) domain.Ban); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Get(0).(domain.Ban)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoadContext provides a mock function with given fields: ctx, id, count
+func (_m *LoadSaver) LoadContext(ctx context.Context, id int64, count int) ([]domain.Message, error) {
+	ret := _m.Called(ctx, id, count)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoadContext")
+	}
+
+	var r0 []domain.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int) ([]domain.Message, error)); ok {
+		return rf(ctx, id, count)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int) []domain.Message); ok {
+		r0 = rf(ctx, id, count)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int) error); ok {
+		r1 = rf(ctx, id, count)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoadHistory provides a mock function with given fields: ctx, room, before, count
+func (_m *LoadSaver) LoadHistory(ctx context.Context, room string, before int64, count int) ([]domain.Message, error) {
+	ret := _m.Called(ctx, room, before, count)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoadHistory")
+	}
+
+	var r0 []domain.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int) ([]domain.Message, error)); ok {
+		return rf(ctx, room, before, count)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int) []domain.Message); ok {
+		r0 = rf(ctx, room, before, count)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64, int) error); ok {
+		r1 = rf(ctx, room, before, count)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoadMentions provides a mock function with given fields: ctx, username, count
+func (_m *LoadSaver) LoadMentions(ctx context.Context, username string, count int) ([]domain.Message, error) {
+	ret := _m.Called(ctx, username, count)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoadMentions")
+	}
+
+	var r0 []domain.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]domain.Message, error)); ok {
+		return rf(ctx, username, count)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []domain.Message); ok {
+		r0 = rf(ctx, username, count)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, username, count)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoadMessage provides a mock function with given fields: ctx, id
+func (_m *LoadSaver) LoadMessage(ctx context.Context, id int64) (domain.Message, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoadMessage")
+	}
+
+	var r0 domain.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.Message, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.Message); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(domain.Message)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoadMessages provides a mock function with given fields: ctx, room, count
+func (_m *LoadSaver) LoadMessages(ctx context.Context, room string, count int) ([]domain.Message, error) {
+	ret := _m.Called(ctx, room, count)
 
 	if len(ret) == 0 {
 		panic("no return value specified for LoadMessages")
@@ -24,19 +235,221 @@ func (_m *LoadSaver) LoadMessages(ctx context.Context, count int) ([]domain.Mess
 
 	var r0 []domain.Message
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) ([]domain.Message, error)); ok {
-		return rf(ctx, count)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]domain.Message, error)); ok {
+		return rf(ctx, room, count)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []domain.Message); ok {
+		r0 = rf(ctx, room, count)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, room, count)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoadMessagesAfter provides a mock function with given fields: ctx, room, after, count
+func (_m *LoadSaver) LoadMessagesAfter(ctx context.Context, room string, after int64, count int) ([]domain.Message, error) {
+	ret := _m.Called(ctx, room, after, count)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoadMessagesAfter")
+	}
+
+	var r0 []domain.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int) ([]domain.Message, error)); ok {
+		return rf(ctx, room, after, count)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int) []domain.Message); ok {
+		r0 = rf(ctx, room, after, count)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64, int) error); ok {
+		r1 = rf(ctx, room, after, count)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoadMute provides a mock function with given fields: ctx, username
+func (_m *LoadSaver) LoadMute(ctx context.Context, username string) (time.Time, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoadMute")
+	}
+
+	var r0 time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (time.Time, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) time.Time); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoadReadMarkers provides a mock function with given fields: ctx, username
+func (_m *LoadSaver) LoadReadMarkers(ctx context.Context, username string) ([]domain.ReadMarker, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoadReadMarkers")
+	}
+
+	var r0 []domain.ReadMarker
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]domain.ReadMarker, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []domain.ReadMarker); ok {
+		r0 = rf(ctx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.ReadMarker)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoadReadOnly provides a mock function with given fields: ctx, room
+func (_m *LoadSaver) LoadReadOnly(ctx context.Context, room string) (bool, error) {
+	ret := _m.Called(ctx, room)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoadReadOnly")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, room)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, room)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, room)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoadRoomMessage provides a mock function with given fields: ctx, room, id
+func (_m *LoadSaver) LoadRoomMessage(ctx context.Context, room string, id int64) (domain.Message, error) {
+	ret := _m.Called(ctx, room, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoadRoomMessage")
+	}
+
+	var r0 domain.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) (domain.Message, error)); ok {
+		return rf(ctx, room, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) domain.Message); ok {
+		r0 = rf(ctx, room, id)
+	} else {
+		r0 = ret.Get(0).(domain.Message)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = rf(ctx, room, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoadThread provides a mock function with given fields: ctx, room, id
+func (_m *LoadSaver) LoadThread(ctx context.Context, room string, id int64) ([]domain.Message, error) {
+	ret := _m.Called(ctx, room, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoadThread")
+	}
+
+	var r0 []domain.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) ([]domain.Message, error)); ok {
+		return rf(ctx, room, id)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int) []domain.Message); ok {
-		r0 = rf(ctx, count)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) []domain.Message); ok {
+		r0 = rf(ctx, room, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]domain.Message)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
-		r1 = rf(ctx, count)
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = rf(ctx, room, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoadUserRole provides a mock function with given fields: ctx, username
+func (_m *LoadSaver) LoadUserRole(ctx context.Context, username string) (domain.UserRole, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoadUserRole")
+	}
+
+	var r0 domain.UserRole
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (domain.UserRole, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) domain.UserRole); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Get(0).(domain.UserRole)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -44,6 +457,60 @@ func (_m *LoadSaver) LoadMessages(ctx context.Context, count int) ([]domain.Mess
 	return r0, r1
 }
 
+// PublishAnnouncement provides a mock function with given fields: ctx, a
+func (_m *LoadSaver) PublishAnnouncement(ctx context.Context, a domain.Announcement) error {
+	ret := _m.Called(ctx, a)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PublishAnnouncement")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Announcement) error); ok {
+		r0 = rf(ctx, a)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PublishModeration provides a mock function with given fields: ctx, m
+func (_m *LoadSaver) PublishModeration(ctx context.Context, m domain.Moderation) error {
+	ret := _m.Called(ctx, m)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PublishModeration")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Moderation) error); ok {
+		r0 = rf(ctx, m)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveBan provides a mock function with given fields: ctx, ban
+func (_m *LoadSaver) SaveBan(ctx context.Context, ban domain.Ban) error {
+	ret := _m.Called(ctx, ban)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveBan")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Ban) error); ok {
+		r0 = rf(ctx, ban)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SaveMessage provides a mock function with given fields: ctx, message
 func (_m *LoadSaver) SaveMessage(ctx context.Context, message domain.Message) error {
 	ret := _m.Called(ctx, message)
@@ -62,6 +529,186 @@ func (_m *LoadSaver) SaveMessage(ctx context.Context, message domain.Message) er
 	return r0
 }
 
+// SaveModeration provides a mock function with given fields: ctx, m
+func (_m *LoadSaver) SaveModeration(ctx context.Context, m domain.Moderation) error {
+	ret := _m.Called(ctx, m)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveModeration")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Moderation) error); ok {
+		r0 = rf(ctx, m)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveMute provides a mock function with given fields: ctx, username, d
+func (_m *LoadSaver) SaveMute(ctx context.Context, username string, d time.Duration) error {
+	ret := _m.Called(ctx, username, d)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveMute")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) error); ok {
+		r0 = rf(ctx, username, d)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveReaction provides a mock function with given fields: ctx, reaction
+func (_m *LoadSaver) SaveReaction(ctx context.Context, reaction domain.Reaction) error {
+	ret := _m.Called(ctx, reaction)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveReaction")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Reaction) error); ok {
+		r0 = rf(ctx, reaction)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveReadMarker provides a mock function with given fields: ctx, marker
+func (_m *LoadSaver) SaveReadMarker(ctx context.Context, marker domain.ReadMarker) error {
+	ret := _m.Called(ctx, marker)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveReadMarker")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.ReadMarker) error); ok {
+		r0 = rf(ctx, marker)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveReadOnly provides a mock function with given fields: ctx, room, readOnly
+func (_m *LoadSaver) SaveReadOnly(ctx context.Context, room string, readOnly bool) error {
+	ret := _m.Called(ctx, room, readOnly)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveReadOnly")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) error); ok {
+		r0 = rf(ctx, room, readOnly)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SearchMessages provides a mock function with given fields: ctx, query, room, count
+func (_m *LoadSaver) SearchMessages(ctx context.Context, query string, room string, count int) ([]domain.Message, error) {
+	ret := _m.Called(ctx, query, room, count)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchMessages")
+	}
+
+	var r0 []domain.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) ([]domain.Message, error)); ok {
+		return rf(ctx, query, room, count)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) []domain.Message); ok {
+		r0 = rf(ctx, query, room, count)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int) error); ok {
+		r1 = rf(ctx, query, room, count)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SubscribeAnnouncements provides a mock function with given fields: ctx
+func (_m *LoadSaver) SubscribeAnnouncements(ctx context.Context) (<-chan domain.Announcement, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SubscribeAnnouncements")
+	}
+
+	var r0 <-chan domain.Announcement
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (<-chan domain.Announcement, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) <-chan domain.Announcement); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan domain.Announcement)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SubscribeModeration provides a mock function with given fields: ctx
+func (_m *LoadSaver) SubscribeModeration(ctx context.Context) (<-chan domain.Moderation, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SubscribeModeration")
+	}
+
+	var r0 <-chan domain.Moderation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (<-chan domain.Moderation, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) <-chan domain.Moderation); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan domain.Moderation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewLoadSaver creates a new instance of LoadSaver. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewLoadSaver(t interface {
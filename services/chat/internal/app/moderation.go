@@ -0,0 +1,136 @@
+package app
+
+import (
+	"chat/internal/domain"
+	errs "chat/internal/repository/errs"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Authenticate checks that the user may connect to the chat and returns the user's role.
+// Users with a role must present their token, the users without username are members.
+func (a *App) Authenticate(ctx context.Context, username string, token string) (domain.Role, error) {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	if username == "" {
+		return domain.RoleMember, nil
+	}
+
+	err := a.checkBan(ctx, username)
+	if err != nil {
+		return "", err
+	}
+
+	role, err := a.loadRole(ctx, username)
+	if err != nil {
+		return "", err
+	}
+	if role.Role != domain.RoleMember && !checkToken(token, role.TokenHash) {
+		return "", &Error{err: ErrUnauthorized, msg: "the username is reserved, a valid token is required"}
+	}
+	return role.Role, nil
+}
+
+// checkBan returns ErrBanned if the user is banned.
+func (a *App) checkBan(ctx context.Context, username string) error {
+	ban, err := a.repo.LoadBan(ctx, username)
+	if err == nil {
+		return &Error{err: ErrBanned, msg: fmt.Sprintf("banned by %s: %s", ban.BannedBy, ban.Reason)}
+	}
+	if !errors.Is(err, errs.ErrNotFound) {
+		return newAppError(err)
+	}
+	return nil
+}
+
+// Moderate applies the action of the moderator with the role and notifies all replicas about it.
+// The action is written to the audit log before it is applied.
+func (a *App) Moderate(ctx context.Context, m domain.Moderation, role domain.Role) error {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	if !role.CanModerate(domain.RoleMember) {
+		return newForbiddenError("only moderators can moderate users")
+	}
+	if m.Target == m.Moderator {
+		return newForbiddenError("you cannot moderate yourself")
+	}
+	if m.Action == domain.ModerationMute && m.Duration <= 0 {
+		return newInvalidMessageError("mute duration must be positive")
+	}
+
+	target, err := a.loadRole(ctx, m.Target)
+	if err != nil {
+		return err
+	}
+	if !role.CanModerate(target.Role) {
+		return newForbiddenError(fmt.Sprintf("%s cannot moderate %s", role, target.Role))
+	}
+
+	err = a.repo.SaveModeration(ctx, m)
+	if err != nil {
+		return newAppError(err)
+	}
+
+	switch m.Action {
+	case domain.ModerationMute:
+		err = a.repo.SaveMute(ctx, m.Target, m.Duration)
+	case domain.ModerationUnmute:
+		err = a.repo.DeleteMute(ctx, m.Target)
+	case domain.ModerationBan:
+		err = a.repo.SaveBan(ctx, domain.Ban{Username: m.Target, Reason: m.Reason, BannedBy: m.Moderator})
+	case domain.ModerationUnban:
+		err = a.repo.DeleteBan(ctx, m.Target)
+	case domain.ModerationKick:
+		// kicked users are only disconnected
+	default:
+		return newInvalidMessageError(fmt.Sprintf("unknown moderation action %q", m.Action))
+	}
+	if err != nil {
+		return newAppError(err)
+	}
+
+	err = a.repo.PublishModeration(ctx, m)
+	if err != nil {
+		return newAppError(err)
+	}
+	return nil
+}
+
+// SubscribeModeration returns the moderator actions applied by all replicas.
+func (a *App) SubscribeModeration(ctx context.Context) (<-chan domain.Moderation, error) {
+	ch, err := a.repo.SubscribeModeration(ctx)
+	if err != nil {
+		return nil, newAppError(err)
+	}
+	return ch, nil
+}
+
+// loadRole returns the role of the user, the users without a role are members.
+func (a *App) loadRole(ctx context.Context, username string) (domain.UserRole, error) {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	role, err := a.repo.LoadUserRole(ctx, username)
+	if errors.Is(err, errs.ErrNotFound) {
+		return domain.UserRole{Username: username, Role: domain.RoleMember}, nil
+	}
+	if err != nil {
+		return domain.UserRole{}, newAppError(err)
+	}
+	return role, nil
+}
+
+// checkToken compares the SHA-256 hash of the token with the stored one in constant time.
+func checkToken(token string, hash string) bool {
+	if token == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(token))
+	return subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(hash)) == 1
+}
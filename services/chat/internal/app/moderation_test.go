@@ -0,0 +1,249 @@
+package app
+
+import (
+	"chat/internal/app/mocks"
+	"chat/internal/domain"
+	"chat/internal/repository/errs"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+// tokenHash is the SHA-256 hash of "secret".
+const tokenHash = "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b"
+
+func TestApp_Authenticate(t *testing.T) {
+	type testcase struct {
+		username string
+		token    string
+		setup    func(repo *mocks.LoadSaver)
+		role     domain.Role
+		err      error
+	}
+
+	tests := []testcase{
+		{
+			username: "",
+			setup:    func(*mocks.LoadSaver) {},
+			role:     domain.RoleMember,
+		},
+		{
+			username: "danil",
+			setup: func(repo *mocks.LoadSaver) {
+				repo.On("LoadBan", mock.Anything, "danil").Return(domain.Ban{}, errs.ErrNotFound)
+				repo.On("LoadUserRole", mock.Anything, "danil").Return(domain.UserRole{}, errs.ErrNotFound)
+			},
+			role: domain.RoleMember,
+		},
+		{
+			username: "gleb",
+			token:    "secret",
+			setup: func(repo *mocks.LoadSaver) {
+				repo.On("LoadBan", mock.Anything, "gleb").Return(domain.Ban{}, errs.ErrNotFound)
+				repo.On("LoadUserRole", mock.Anything, "gleb").
+					Return(domain.UserRole{Username: "gleb", Role: domain.RoleModerator, TokenHash: tokenHash}, nil)
+			},
+			role: domain.RoleModerator,
+		},
+		{
+			username: "gleb",
+			token:    "wrong",
+			setup: func(repo *mocks.LoadSaver) {
+				repo.On("LoadBan", mock.Anything, "gleb").Return(domain.Ban{}, errs.ErrNotFound)
+				repo.On("LoadUserRole", mock.Anything, "gleb").
+					Return(domain.UserRole{Username: "gleb", Role: domain.RoleModerator, TokenHash: tokenHash}, nil)
+			},
+			err: ErrUnauthorized,
+		},
+		{
+			username: "gleb",
+			setup: func(repo *mocks.LoadSaver) {
+				repo.On("LoadBan", mock.Anything, "gleb").Return(domain.Ban{}, errs.ErrNotFound)
+				repo.On("LoadUserRole", mock.Anything, "gleb").
+					Return(domain.UserRole{Username: "gleb", Role: domain.RoleAdmin, TokenHash: tokenHash}, nil)
+			},
+			err: ErrUnauthorized,
+		},
+		{
+			username: "maks",
+			setup: func(repo *mocks.LoadSaver) {
+				repo.On("LoadBan", mock.Anything, "maks").
+					Return(domain.Ban{Username: "maks", Reason: "spam", BannedBy: "gleb"}, nil)
+			},
+			err: ErrBanned,
+		},
+		{
+			username: "maks",
+			setup: func(repo *mocks.LoadSaver) {
+				repo.On("LoadBan", mock.Anything, "maks").Return(domain.Ban{}, errs.ErrInternal)
+			},
+			err: ErrInternal,
+		},
+	}
+
+	for _, test := range tests {
+		repo := mocks.NewLoadSaver(t)
+		test.setup(repo)
+
+		app := New(repo, nil, &Config{MessagesToLoad: 10})
+		role, err := app.Authenticate(context.Background(), test.username, test.token)
+		if test.err != nil {
+			assert.ErrorIs(t, err, test.err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.role, role)
+	}
+}
+
+func TestApp_Moderate(t *testing.T) {
+	type testcase struct {
+		name       string
+		moderation domain.Moderation
+		role       domain.Role
+		target     domain.Role
+		setup      func(repo *mocks.LoadSaver, m domain.Moderation)
+		err        error
+	}
+
+	tests := []testcase{
+		{
+			name:       "mute",
+			moderation: domain.Moderation{Moderator: "gleb", Action: domain.ModerationMute, Target: "danil", Room: "general", Duration: 10 * time.Minute},
+			role:       domain.RoleModerator,
+			target:     domain.RoleMember,
+			setup: func(repo *mocks.LoadSaver, m domain.Moderation) {
+				repo.On("SaveMute", mock.Anything, "danil", 10*time.Minute).Return(nil)
+			},
+		},
+		{
+			name:       "unmute",
+			moderation: domain.Moderation{Moderator: "gleb", Action: domain.ModerationUnmute, Target: "danil", Room: "general"},
+			role:       domain.RoleModerator,
+			target:     domain.RoleMember,
+			setup: func(repo *mocks.LoadSaver, m domain.Moderation) {
+				repo.On("DeleteMute", mock.Anything, "danil").Return(nil)
+			},
+		},
+		{
+			name:       "kick",
+			moderation: domain.Moderation{Moderator: "gleb", Action: domain.ModerationKick, Target: "danil", Room: "general"},
+			role:       domain.RoleModerator,
+			target:     domain.RoleMember,
+			setup:      func(*mocks.LoadSaver, domain.Moderation) {},
+		},
+		{
+			name:       "ban moderator",
+			moderation: domain.Moderation{Moderator: "maks", Action: domain.ModerationBan, Target: "gleb", Room: "general", Reason: "abuse"},
+			role:       domain.RoleAdmin,
+			target:     domain.RoleModerator,
+			setup: func(repo *mocks.LoadSaver, m domain.Moderation) {
+				repo.On("SaveBan", mock.Anything, domain.Ban{Username: "gleb", Reason: "abuse", BannedBy: "maks"}).Return(nil)
+			},
+		},
+		{
+			name:       "unban",
+			moderation: domain.Moderation{Moderator: "gleb", Action: domain.ModerationUnban, Target: "danil", Room: "general"},
+			role:       domain.RoleModerator,
+			target:     domain.RoleMember,
+			setup: func(repo *mocks.LoadSaver, m domain.Moderation) {
+				repo.On("DeleteBan", mock.Anything, "danil").Return(nil)
+			},
+		},
+		{
+			name:       "member",
+			moderation: domain.Moderation{Moderator: "danil", Action: domain.ModerationKick, Target: "gleb", Room: "general"},
+			role:       domain.RoleMember,
+			err:        ErrForbidden,
+		},
+		{
+			name:       "moderator bans moderator",
+			moderation: domain.Moderation{Moderator: "gleb", Action: domain.ModerationBan, Target: "maks", Room: "general"},
+			role:       domain.RoleModerator,
+			target:     domain.RoleModerator,
+			err:        ErrForbidden,
+		},
+		{
+			name:       "admin kicks admin",
+			moderation: domain.Moderation{Moderator: "gleb", Action: domain.ModerationKick, Target: "maks", Room: "general"},
+			role:       domain.RoleAdmin,
+			target:     domain.RoleAdmin,
+			err:        ErrForbidden,
+		},
+		{
+			name:       "yourself",
+			moderation: domain.Moderation{Moderator: "gleb", Action: domain.ModerationKick, Target: "gleb", Room: "general"},
+			role:       domain.RoleAdmin,
+			err:        ErrForbidden,
+		},
+		{
+			name:       "mute without duration",
+			moderation: domain.Moderation{Moderator: "gleb", Action: domain.ModerationMute, Target: "danil", Room: "general"},
+			role:       domain.RoleModerator,
+			err:        ErrInvalidMessage,
+		},
+		{
+			name:       "repo error",
+			moderation: domain.Moderation{Moderator: "gleb", Action: domain.ModerationBan, Target: "danil", Room: "general"},
+			role:       domain.RoleModerator,
+			target:     domain.RoleMember,
+			setup: func(repo *mocks.LoadSaver, m domain.Moderation) {
+				repo.On("SaveBan", mock.Anything, mock.Anything).Return(errs.ErrInternal)
+			},
+			err: ErrInternal,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := mocks.NewLoadSaver(t)
+			if test.target != "" {
+				role := domain.UserRole{Username: test.moderation.Target, Role: test.target}
+				var err error
+				if test.target == domain.RoleMember {
+					role, err = domain.UserRole{}, errs.ErrNotFound
+				}
+				repo.On("LoadUserRole", mock.Anything, test.moderation.Target).Return(role, err)
+			}
+			if test.setup != nil {
+				repo.On("SaveModeration", mock.Anything, test.moderation).Return(nil)
+				test.setup(repo, test.moderation)
+				if test.err == nil {
+					repo.On("PublishModeration", mock.Anything, test.moderation).Return(nil)
+				}
+			}
+
+			app := New(repo, nil, &Config{MessagesToLoad: 10})
+			err := app.Moderate(context.Background(), test.moderation, test.role)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestApp_SaveMessage_Muted(t *testing.T) {
+	repo := mocks.NewLoadSaver(t)
+	until := time.Now().Add(10 * time.Minute)
+	repo.On("LoadBan", mock.Anything, "danil").Return(domain.Ban{}, errs.ErrNotFound)
+	repo.On("LoadMute", mock.Anything, "danil").Return(until, nil)
+
+	app := New(repo, nil, &Config{MessagesToLoad: 10})
+	_, err := app.SaveMessage(context.Background(), domain.Message{Username: "danil", Text: "hello"})
+	assert.ErrorIs(t, err, ErrMuted)
+	assert.ErrorContains(t, err, until.UTC().Format(time.RFC3339))
+}
+
+func TestApp_SaveMessage_Banned(t *testing.T) {
+	repo := mocks.NewLoadSaver(t)
+	repo.On("LoadBan", mock.Anything, "danil").Return(domain.Ban{Username: "danil", Reason: "spam", BannedBy: "gleb"}, nil)
+
+	app := New(repo, nil, &Config{MessagesToLoad: 10})
+	_, err := app.SaveMessage(context.Background(), domain.Message{Username: "danil", Text: "hello"})
+	assert.ErrorIs(t, err, ErrBanned)
+	assert.ErrorContains(t, err, "banned by gleb: spam")
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// escapeRegexp matches terminal escape sequences: CSI (e.g. colors and cursor movement),
+// OSC (e.g. window title and hyperlinks) and the other two-character sequences.
+var escapeRegexp = regexp.MustCompile(`\x1b(?:\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)?|[@-Z\\-_])`)
+
+// sanitizeText removes terminal escape sequences and control characters except
+// new lines and tabs from the text and normalizes it to the NFC form.
+func sanitizeText(s string) string {
+	return sanitize(s, func(r rune) bool { return r == '\n' || r == '\t' })
+}
+
+// sanitizeName sanitizes the text the same way as sanitizeText, but keeps no control characters.
+func sanitizeName(s string) string {
+	return sanitize(s, func(rune) bool { return false })
+}
+
+// CheckUsername returns ErrInvalidMessage if the username is not sanitized, such names could be shown
+// as the name of another user, so they are rejected instead of being rewritten.
+func CheckUsername(username string) error {
+	if sanitizeName(username) != username {
+		return newInvalidMessageError("username must not contain control characters, escape sequences, " +
+			"text direction characters or surrounding spaces and must be in Unicode NFC")
+	}
+	return nil
+}
+
+func sanitize(s string, keep func(rune) bool) string {
+	s = strings.ToValidUTF8(s, string(unicode.ReplacementChar))
+	s = escapeRegexp.ReplaceAllString(s, "")
+	s = strings.Map(func(r rune) rune {
+		if keep(r) {
+			return r
+		}
+		if unicode.IsControl(r) || isBidiControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.TrimSpace(norm.NFC.String(s))
+}
+
+// isBidiControl reports whether the rune changes the direction of the text,
+// such runes make the text shown differently from what it is.
+func isBidiControl(r rune) bool {
+	return (r >= '\u202a' && r <= '\u202e') || (r >= '\u2066' && r <= '\u2069') || r == '\u200e' || r == '\u200f'
+}
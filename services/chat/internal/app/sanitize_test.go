@@ -0,0 +1,79 @@
+package app
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSanitizeText(t *testing.T) {
+	type testcase struct {
+		text     string
+		expected string
+	}
+
+	tests := []testcase{
+		{text: "hello", expected: "hello"},
+		{text: "  hello\n\tworld  ", expected: "hello\n\tworld"},
+		{text: "\x1b[31mred\x1b[0m", expected: "red"},
+		{text: "\x1b[2J\x1b[Hclear", expected: "clear"},
+		{text: "\x1b]0;title\x07text", expected: "text"},
+		{text: "\x1b]8;;http://evil\x1b\\link\x1b]8;;\x1b\\", expected: "link"},
+		{text: "bell\x07 back\bspace\r", expected: "bell backspace"},
+		{text: "c1\u009b31m", expected: "c131m"},
+		{text: "abc\u202edcba", expected: "abcdcba"},
+		{text: "e\u0301", expected: "\u00e9"},
+		{text: "\U0001f469\u200d\U0001f4bb", expected: "\U0001f469\u200d\U0001f4bb"},
+		{text: "bad\xffutf8", expected: "bad\ufffdutf8"},
+		{text: "\x1b[31m\x1b[0m", expected: ""},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, sanitizeText(test.text), test.text)
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	type testcase struct {
+		name     string
+		expected string
+	}
+
+	tests := []testcase{
+		{name: "danil", expected: "danil"},
+		{name: "da\nnil", expected: "danil"},
+		{name: "\x1b[1mdanil\x1b[0m", expected: "danil"},
+		{name: "gl\teb", expected: "gleb"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, sanitizeName(test.name), test.name)
+	}
+}
+
+func TestCheckUsername(t *testing.T) {
+	type testcase struct {
+		username string
+		valid    bool
+	}
+
+	tests := []testcase{
+		{username: "danil", valid: true},
+		{username: "даниил", valid: true},
+		{username: "danil\u200e", valid: false},
+		{username: "\u202edanil", valid: false},
+		{username: " danil", valid: false},
+		{username: "da\nnil", valid: false},
+		{username: "\x1b[1mdanil", valid: false},
+		// "e" followed by the combining acute accent is "é" in NFC
+		{username: "danie\u0301l", valid: false},
+	}
+
+	for _, test := range tests {
+		err := CheckUsername(test.username)
+		if test.valid {
+			assert.NoError(t, err, test.username)
+			continue
+		}
+		assert.ErrorIs(t, err, ErrInvalidMessage, test.username)
+	}
+}
@@ -6,10 +6,14 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 )
 
 type App struct {
-	MessagesToLoad int
+	MessagesToLoad   int
+	NodeID           int64
+	MaxMessageLength int
+	OperationTimeout time.Duration
 }
 
 func getAppConfig() (*app.Config, error) {
@@ -18,7 +22,10 @@ func getAppConfig() (*app.Config, error) {
 		return nil, err
 	}
 	return &app.Config{
-		MessagesToLoad: cfg.MessagesToLoad,
+		MessagesToLoad:   cfg.MessagesToLoad,
+		NodeID:           cfg.NodeID,
+		MaxMessageLength: cfg.MaxMessageLength,
+		OperationTimeout: cfg.OperationTimeout,
 	}, nil
 }
 
@@ -31,5 +38,47 @@ func loadEnvAppConfig() (*App, error) {
 	if err != nil {
 		return nil, fmt.Errorf("%s: variable 'MESSAGES_TO_LOAD' must be integer", err.Error())
 	}
-	return &App{MessagesToLoad: messagesToLoad}, nil
+
+	node, ok := os.LookupEnv("NODE_ID")
+	if !ok {
+		return nil, errors.New("cannot find 'NODE_ID' variable in environment")
+	}
+	nodeID, err := strconv.ParseInt(node, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: variable 'NODE_ID' must be integer", err.Error())
+	}
+	if nodeID < 0 || nodeID > 1023 {
+		return nil, errors.New("variable 'NODE_ID' must be in range [0, 1023]")
+	}
+
+	length, ok := os.LookupEnv("MAX_MESSAGE_LENGTH")
+	if !ok {
+		return nil, errors.New("cannot find 'MAX_MESSAGE_LENGTH' variable in environment")
+	}
+	maxMessageLength, err := strconv.Atoi(length)
+	if err != nil {
+		return nil, fmt.Errorf("%s: variable 'MAX_MESSAGE_LENGTH' must be integer", err.Error())
+	}
+	if maxMessageLength < 0 {
+		return nil, errors.New("variable 'MAX_MESSAGE_LENGTH' must be non-negative")
+	}
+
+	timeout, ok := os.LookupEnv("OPERATION_TIMEOUT")
+	if !ok {
+		return nil, errors.New("cannot find 'OPERATION_TIMEOUT' variable in environment")
+	}
+	operationTimeout, err := time.ParseDuration(timeout)
+	if err != nil {
+		return nil, fmt.Errorf("%s: variable 'OPERATION_TIMEOUT' must be duration", err.Error())
+	}
+	if operationTimeout < 0 {
+		return nil, errors.New("variable 'OPERATION_TIMEOUT' must be non-negative")
+	}
+
+	return &App{
+		MessagesToLoad:   messagesToLoad,
+		NodeID:           nodeID,
+		MaxMessageLength: maxMessageLength,
+		OperationTimeout: operationTimeout,
+	}, nil
 }
@@ -6,6 +6,9 @@ import (
 	rds "chat/internal/adapters/redis"
 	"chat/internal/adapters/websocket"
 	"chat/internal/app"
+	"chat/internal/filter"
+	"chat/internal/logging"
+	"chat/internal/tracing"
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
 )
@@ -16,6 +19,9 @@ type Config struct {
 	Redis    *rds.Config
 	Server   *websocket.Config
 	App      *app.Config
+	Filter   *filter.Config
+	Tracing  *tracing.Config
+	Logging  *logging.Config
 }
 
 func Get(logger *logrus.Logger, envFile string) (*Config, error) {
@@ -50,12 +56,30 @@ func Get(logger *logrus.Logger, envFile string) (*Config, error) {
 		return nil, err
 	}
 
+	filterConfig, err := getFilterConfig(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	tracingConfig, err := getTracingConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	loggingConfig, err := getLoggingConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	config := &Config{
 		Postgres: postgresConfig,
 		Kafka:    kafkaConfig,
 		Redis:    redisConfig,
 		Server:   serverConfig,
 		App:      appConfig,
+		Filter:   filterConfig,
+		Tracing:  tracingConfig,
+		Logging:  loggingConfig,
 	}
 	return config, nil
 }
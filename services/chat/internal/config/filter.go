@@ -0,0 +1,31 @@
+package config
+
+import (
+	"chat/internal/filter"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"os"
+)
+
+type Filter struct {
+	RulesFile string
+}
+
+func getFilterConfig(logger logrus.FieldLogger) (*filter.Config, error) {
+	cfg, err := loadEnvFilterConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &filter.Config{
+		RulesFile: cfg.RulesFile,
+		Logger:    logger.WithField("FROM", "[FILTER]"),
+	}, nil
+}
+
+func loadEnvFilterConfig() (*Filter, error) {
+	file, ok := os.LookupEnv("FILTER_RULES_FILE")
+	if !ok {
+		return nil, errors.New("cannot find 'FILTER_RULES_FILE' variable in environment")
+	}
+	return &Filter{RulesFile: file}, nil
+}
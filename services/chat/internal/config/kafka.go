@@ -5,12 +5,17 @@ import (
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Kafka struct {
-	Brokers string
-	Topic   string
+	Brokers        string
+	Topic          string
+	ConnectRetries int
+	ConnectBackoff time.Duration
+	FlushTimeout   time.Duration
 }
 
 func getKafkaConfig(logger logrus.FieldLogger) (*kafka.Config, error) {
@@ -21,7 +26,11 @@ func getKafkaConfig(logger logrus.FieldLogger) (*kafka.Config, error) {
 	kafkaConfig := &kafka.Config{
 		Brokers: strings.Split(k.Brokers, ","),
 		Topic:   k.Topic,
-		Logger:  logger.WithField("FROM", "[KAFKA-PRODUCER]"),
+
+		ConnectRetries: k.ConnectRetries,
+		ConnectBackoff: k.ConnectBackoff,
+		FlushTimeout:   k.FlushTimeout,
+		Logger:         logger.WithField("FROM", "[KAFKA-PRODUCER]"),
 	}
 	return kafkaConfig, nil
 }
@@ -36,8 +45,47 @@ func loadEnvKafkaConfig() (Kafka, error) {
 		return Kafka{}, fmt.Errorf("KAFKA_TOPICS environment variable not set")
 	}
 
+	value, ok := os.LookupEnv("KAFKA_CONNECT_RETRIES")
+	if !ok {
+		return Kafka{}, fmt.Errorf("KAFKA_CONNECT_RETRIES environment variable not set")
+	}
+	retries, err := strconv.Atoi(value)
+	if err != nil {
+		return Kafka{}, fmt.Errorf("%s: variable 'KAFKA_CONNECT_RETRIES' must be integer", err.Error())
+	}
+	if retries < 0 {
+		return Kafka{}, fmt.Errorf("variable 'KAFKA_CONNECT_RETRIES' must be non-negative")
+	}
+
+	value, ok = os.LookupEnv("KAFKA_CONNECT_BACKOFF")
+	if !ok {
+		return Kafka{}, fmt.Errorf("KAFKA_CONNECT_BACKOFF environment variable not set")
+	}
+	backoff, err := time.ParseDuration(value)
+	if err != nil {
+		return Kafka{}, fmt.Errorf("%s: variable 'KAFKA_CONNECT_BACKOFF' must be duration", err.Error())
+	}
+	if backoff <= 0 {
+		return Kafka{}, fmt.Errorf("variable 'KAFKA_CONNECT_BACKOFF' must be positive")
+	}
+
+	value, ok = os.LookupEnv("KAFKA_FLUSH_TIMEOUT")
+	if !ok {
+		return Kafka{}, fmt.Errorf("KAFKA_FLUSH_TIMEOUT environment variable not set")
+	}
+	flushTimeout, err := time.ParseDuration(value)
+	if err != nil {
+		return Kafka{}, fmt.Errorf("%s: variable 'KAFKA_FLUSH_TIMEOUT' must be duration", err.Error())
+	}
+	if flushTimeout <= 0 {
+		return Kafka{}, fmt.Errorf("variable 'KAFKA_FLUSH_TIMEOUT' must be positive")
+	}
+
 	return Kafka{
-		Brokers: brokers,
-		Topic:   topics,
+		Brokers:        brokers,
+		Topic:          topics,
+		ConnectRetries: retries,
+		ConnectBackoff: backoff,
+		FlushTimeout:   flushTimeout,
 	}, nil
 }
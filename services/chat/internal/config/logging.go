@@ -0,0 +1,92 @@
+package config
+
+import (
+	"chat/internal/logging"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"os"
+	"strconv"
+)
+
+type Logging struct {
+	Format             string
+	Level              logrus.Level
+	SamplingInitial    int
+	SamplingThereafter int
+	LogContent         bool
+}
+
+func getLoggingConfig() (*logging.Config, error) {
+	cfg, err := loadEnvLoggingConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &logging.Config{
+		Format:             cfg.Format,
+		Level:              cfg.Level,
+		SamplingInitial:    cfg.SamplingInitial,
+		SamplingThereafter: cfg.SamplingThereafter,
+		LogContent:         cfg.LogContent,
+	}, nil
+}
+
+func loadEnvLoggingConfig() (*Logging, error) {
+	format, ok := os.LookupEnv("LOG_FORMAT")
+	if !ok {
+		return nil, errors.New("cannot find 'LOG_FORMAT' variable in environment")
+	}
+	if format != logging.FormatText && format != logging.FormatJSON {
+		return nil, fmt.Errorf("variable 'LOG_FORMAT' must be %s or %s", logging.FormatText, logging.FormatJSON)
+	}
+
+	value, ok := os.LookupEnv("LOG_LEVEL")
+	if !ok {
+		return nil, errors.New("cannot find 'LOG_LEVEL' variable in environment")
+	}
+	level, err := logrus.ParseLevel(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: variable 'LOG_LEVEL' must be log level", err.Error())
+	}
+
+	value, ok = os.LookupEnv("LOG_SAMPLING_INITIAL")
+	if !ok {
+		return nil, errors.New("cannot find 'LOG_SAMPLING_INITIAL' variable in environment")
+	}
+	initial, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: variable 'LOG_SAMPLING_INITIAL' must be integer", err.Error())
+	}
+	if initial < 0 {
+		return nil, errors.New("variable 'LOG_SAMPLING_INITIAL' must be non-negative")
+	}
+
+	value, ok = os.LookupEnv("LOG_SAMPLING_THEREAFTER")
+	if !ok {
+		return nil, errors.New("cannot find 'LOG_SAMPLING_THEREAFTER' variable in environment")
+	}
+	thereafter, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: variable 'LOG_SAMPLING_THEREAFTER' must be integer", err.Error())
+	}
+	if thereafter < 0 {
+		return nil, errors.New("variable 'LOG_SAMPLING_THEREAFTER' must be non-negative")
+	}
+
+	value, ok = os.LookupEnv("LOG_MESSAGE_CONTENT")
+	if !ok {
+		return nil, errors.New("cannot find 'LOG_MESSAGE_CONTENT' variable in environment")
+	}
+	logContent, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: variable 'LOG_MESSAGE_CONTENT' must be boolean", err.Error())
+	}
+
+	return &Logging{
+		Format:             format,
+		Level:              level,
+		SamplingInitial:    initial,
+		SamplingThereafter: thereafter,
+		LogContent:         logContent,
+	}, nil
+}
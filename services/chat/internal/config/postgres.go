@@ -2,6 +2,7 @@ package config
 
 import (
 	"chat/internal/adapters/postgres"
+	"chat/internal/tracing"
 	"context"
 	"fmt"
 	pgxLogrus "github.com/jackc/pgx-logrus"
@@ -40,9 +41,11 @@ func getPostgresConfig(logger logrus.FieldLogger) (*postgres.Config, error) {
 			Error("cannot parse postgres config")
 		return nil, err
 	}
-	pgxConfig.ConnConfig.Tracer = &tracelog.TraceLog{
-		Logger:   pgxLogrus.NewLogger(logger.WithField("FROM", "[PGX-POOL]")),
-		LogLevel: tracelog.LogLevelDebug,
+	pgxConfig.ConnConfig.Tracer = &tracing.QueryTracer{
+		Next: &tracelog.TraceLog{
+			Logger:   pgxLogrus.NewLogger(logger.WithField("FROM", "[PGX-POOL]")),
+			LogLevel: tracelog.LogLevelDebug,
+		},
 	}
 
 	pool, err := pgxpool.NewWithConfig(context.Background(), pgxConfig)
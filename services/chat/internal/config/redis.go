@@ -14,6 +14,12 @@ type Redis struct {
 	Port string
 	Key  string
 	DB   int
+	// RateLimitKey is the prefix of the rate limiter buckets.
+	RateLimitKey string
+	// ModerationKey is the prefix of the mute keys and the channel of the moderator actions.
+	ModerationKey string
+	// AdminKey is the prefix of the read-only rooms set and the channel of the announcements.
+	AdminKey string
 }
 
 func getRedisConfig(logger logrus.FieldLogger) (*rds.Config, error) {
@@ -26,8 +32,11 @@ func getRedisConfig(logger logrus.FieldLogger) (*rds.Config, error) {
 			Addr: fmt.Sprintf("%s:%s", r.Host, r.Port),
 			DB:   r.DB,
 		},
-		Key:    r.Key,
-		Logger: logger.WithField("FROM", "[REDIS]"),
+		Key:           r.Key,
+		RateLimitKey:  r.RateLimitKey,
+		ModerationKey: r.ModerationKey,
+		AdminKey:      r.AdminKey,
+		Logger:        logger.WithField("FROM", "[REDIS]"),
 	}
 	return redisConfig, nil
 }
@@ -53,10 +62,25 @@ func loadEnvRedisConfig() (Redis, error) {
 	if !ok {
 		return Redis{}, fmt.Errorf("REDIS_KEY environment variable not set")
 	}
+	rateLimitKey, ok := os.LookupEnv("REDIS_RATE_LIMIT_KEY")
+	if !ok {
+		return Redis{}, fmt.Errorf("REDIS_RATE_LIMIT_KEY environment variable not set")
+	}
+	moderationKey, ok := os.LookupEnv("REDIS_MODERATION_KEY")
+	if !ok {
+		return Redis{}, fmt.Errorf("REDIS_MODERATION_KEY environment variable not set")
+	}
+	adminKey, ok := os.LookupEnv("REDIS_ADMIN_KEY")
+	if !ok {
+		return Redis{}, fmt.Errorf("REDIS_ADMIN_KEY environment variable not set")
+	}
 	return Redis{
-		Host: host,
-		Port: port,
-		Key:  key,
-		DB:   db,
+		Host:          host,
+		Port:          port,
+		Key:           key,
+		DB:            db,
+		RateLimitKey:  rateLimitKey,
+		ModerationKey: moderationKey,
+		AdminKey:      adminKey,
 	}, nil
 }
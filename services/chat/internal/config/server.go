@@ -6,12 +6,25 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 )
 
 type Server struct {
 	Port            string
 	WriteBufferSize int
 	ReadBufferSize  int
+	ReadLimit       int64
+
+	ConnectionLimit     websocket.RateLimit
+	UserLimit           websocket.RateLimit
+	IPLimit             websocket.RateLimit
+	MaxConnectionsPerIP int
+
+	AdminPort  string
+	AdminToken string
+
+	ShutdownDelay   time.Duration
+	ShutdownTimeout time.Duration
 }
 
 func getServerConfig() (*websocket.Config, error) {
@@ -23,6 +36,18 @@ func getServerConfig() (*websocket.Config, error) {
 		Port:            cfg.Port,
 		WriteBufferSize: cfg.WriteBufferSize,
 		ReadBufferSize:  cfg.ReadBufferSize,
+		ReadLimit:       cfg.ReadLimit,
+
+		ConnectionLimit:     cfg.ConnectionLimit,
+		UserLimit:           cfg.UserLimit,
+		IPLimit:             cfg.IPLimit,
+		MaxConnectionsPerIP: cfg.MaxConnectionsPerIP,
+
+		AdminPort:  cfg.AdminPort,
+		AdminToken: cfg.AdminToken,
+
+		ShutdownDelay:   cfg.ShutdownDelay,
+		ShutdownTimeout: cfg.ShutdownTimeout,
 	}, nil
 }
 
@@ -51,9 +76,125 @@ func loadEnvServerConfig() (*Server, error) {
 		return nil, fmt.Errorf("%s: variable 'READ_BUFFER_SIZE' must be integer", err.Error())
 	}
 
+	limit, ok := os.LookupEnv("READ_LIMIT")
+	if !ok {
+		return nil, errors.New("cannot find 'READ_LIMIT' variable in environment")
+	}
+	readLimit, err := strconv.ParseInt(limit, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: variable 'READ_LIMIT' must be integer", err.Error())
+	}
+	if readLimit <= 0 {
+		return nil, errors.New("variable 'READ_LIMIT' must be positive")
+	}
+
+	connectionLimit, err := loadEnvRateLimit("CONNECTION")
+	if err != nil {
+		return nil, err
+	}
+	userLimit, err := loadEnvRateLimit("USER")
+	if err != nil {
+		return nil, err
+	}
+	ipLimit, err := loadEnvRateLimit("IP")
+	if err != nil {
+		return nil, err
+	}
+
+	connections, ok := os.LookupEnv("MAX_CONNECTIONS_PER_IP")
+	if !ok {
+		return nil, errors.New("cannot find 'MAX_CONNECTIONS_PER_IP' variable in environment")
+	}
+	maxConnectionsPerIP, err := strconv.Atoi(connections)
+	if err != nil {
+		return nil, fmt.Errorf("%s: variable 'MAX_CONNECTIONS_PER_IP' must be integer", err.Error())
+	}
+	if maxConnectionsPerIP < 0 {
+		return nil, errors.New("variable 'MAX_CONNECTIONS_PER_IP' must be non-negative")
+	}
+
+	adminPort, ok := os.LookupEnv("ADMIN_PORT")
+	if !ok {
+		return nil, errors.New("cannot find 'ADMIN_PORT' variable in environment")
+	}
+	adminToken, ok := os.LookupEnv("ADMIN_TOKEN")
+	if !ok {
+		return nil, errors.New("cannot find 'ADMIN_TOKEN' variable in environment")
+	}
+	if adminToken == "" {
+		return nil, errors.New("variable 'ADMIN_TOKEN' must be non-empty")
+	}
+
+	delay, ok := os.LookupEnv("SHUTDOWN_DELAY")
+	if !ok {
+		return nil, errors.New("cannot find 'SHUTDOWN_DELAY' variable in environment")
+	}
+	shutdownDelay, err := time.ParseDuration(delay)
+	if err != nil {
+		return nil, fmt.Errorf("%s: variable 'SHUTDOWN_DELAY' must be duration", err.Error())
+	}
+	if shutdownDelay < 0 {
+		return nil, errors.New("variable 'SHUTDOWN_DELAY' must be non-negative")
+	}
+
+	timeout, ok := os.LookupEnv("SHUTDOWN_TIMEOUT")
+	if !ok {
+		return nil, errors.New("cannot find 'SHUTDOWN_TIMEOUT' variable in environment")
+	}
+	shutdownTimeout, err := time.ParseDuration(timeout)
+	if err != nil {
+		return nil, fmt.Errorf("%s: variable 'SHUTDOWN_TIMEOUT' must be duration", err.Error())
+	}
+	if shutdownTimeout <= 0 {
+		return nil, errors.New("variable 'SHUTDOWN_TIMEOUT' must be positive")
+	}
+
 	return &Server{
 		Port:            port,
 		WriteBufferSize: writeBufferSize,
 		ReadBufferSize:  readBufferSize,
+		ReadLimit:       readLimit,
+
+		ConnectionLimit:     connectionLimit,
+		UserLimit:           userLimit,
+		IPLimit:             ipLimit,
+		MaxConnectionsPerIP: maxConnectionsPerIP,
+
+		AdminPort:  adminPort,
+		AdminToken: adminToken,
+
+		ShutdownDelay:   shutdownDelay,
+		ShutdownTimeout: shutdownTimeout,
 	}, nil
 }
+
+// loadEnvRateLimit loads the rate limit from the '<PREFIX>_RATE' and '<PREFIX>_BURST' variables.
+func loadEnvRateLimit(prefix string) (websocket.RateLimit, error) {
+	rateName, burstName := prefix+"_RATE", prefix+"_BURST"
+
+	value, ok := os.LookupEnv(rateName)
+	if !ok {
+		return websocket.RateLimit{}, fmt.Errorf("cannot find '%s' variable in environment", rateName)
+	}
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return websocket.RateLimit{}, fmt.Errorf("%s: variable '%s' must be number", err.Error(), rateName)
+	}
+	if rate <= 0 {
+		return websocket.RateLimit{}, fmt.Errorf("variable '%s' must be positive", rateName)
+	}
+
+	value, ok = os.LookupEnv(burstName)
+	if !ok {
+		return websocket.RateLimit{}, fmt.Errorf("cannot find '%s' variable in environment", burstName)
+	}
+	burst, err := strconv.Atoi(value)
+	if err != nil {
+		return websocket.RateLimit{}, fmt.Errorf("%s: variable '%s' must be integer", err.Error(), burstName)
+	}
+	if burst <= 0 {
+		return websocket.RateLimit{}, fmt.Errorf("variable '%s' must be positive", burstName)
+	}
+
+	return websocket.RateLimit{Rate: rate, Burst: burst}, nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"chat/internal/tracing"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// serviceName is the name of the service in the exported spans.
+const serviceName = "chat"
+
+type Tracing struct {
+	Exporter    string
+	Endpoint    string
+	SampleRatio float64
+}
+
+func getTracingConfig() (*tracing.Config, error) {
+	cfg, err := loadEnvTracingConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &tracing.Config{
+		Exporter:    cfg.Exporter,
+		Endpoint:    cfg.Endpoint,
+		ServiceName: serviceName,
+		SampleRatio: cfg.SampleRatio,
+	}, nil
+}
+
+func loadEnvTracingConfig() (*Tracing, error) {
+	exporter, ok := os.LookupEnv("TRACING_EXPORTER")
+	if !ok {
+		return nil, errors.New("cannot find 'TRACING_EXPORTER' variable in environment")
+	}
+	switch exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	default:
+		return nil, fmt.Errorf("variable 'TRACING_EXPORTER' must be one of %s, %s or %s",
+			tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP)
+	}
+
+	endpoint, ok := os.LookupEnv("TRACING_ENDPOINT")
+	if !ok && exporter == tracing.ExporterOTLP {
+		return nil, errors.New("cannot find 'TRACING_ENDPOINT' variable in environment")
+	}
+
+	value, ok := os.LookupEnv("TRACING_SAMPLE_RATIO")
+	if !ok {
+		return nil, errors.New("cannot find 'TRACING_SAMPLE_RATIO' variable in environment")
+	}
+	ratio, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: variable 'TRACING_SAMPLE_RATIO' must be number", err.Error())
+	}
+	if ratio < 0 || ratio > 1 {
+		return nil, errors.New("variable 'TRACING_SAMPLE_RATIO' must be between 0 and 1")
+	}
+
+	return &Tracing{
+		Exporter:    exporter,
+		Endpoint:    endpoint,
+		SampleRatio: ratio,
+	}, nil
+}
@@ -0,0 +1,8 @@
+package domain
+
+// Announcement is a system message of the operators, it is sent to all rooms if Room is empty.
+// Announcements are not saved.
+type Announcement struct {
+	Text string `json:"message"`
+	Room string `json:"room,omitempty"`
+}
@@ -1,6 +1,29 @@
 package domain
 
+import "fmt"
+
+// DefaultRoom is the room clients join when they don't ask for a specific one.
+const DefaultRoom = "general"
+
 type Message struct {
+	ID       int64  `json:"id,omitempty"`
 	Username string `json:"username" required:"true"`
 	Text     string `json:"message" required:"true"`
+	Room     string `json:"room,omitempty"`
+	// ReplyTo is the ID of the message this message replies to.
+	ReplyTo int64 `json:"reply_to,omitempty"`
+	// Replies is the number of messages replying to this message.
+	Replies int `json:"replies,omitempty"`
+	// Mentions are usernames mentioned in the text as @username.
+	Mentions []string `json:"mentions,omitempty"`
+	// Reactions is the number of users reacted to the message with each emoji.
+	Reactions map[string]int `json:"reactions,omitempty"`
+	// Shadowed is set for shadow-banned messages, they are shown only to the sender and never saved.
+	Shadowed bool `json:"-"`
+}
+
+// Redacted returns the message without the text, it is logged unless the content logging is enabled.
+func (m Message) Redacted() any {
+	m.Text = fmt.Sprintf("[redacted %d bytes]", len(m.Text))
+	return m
 }
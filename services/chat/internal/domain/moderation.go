@@ -0,0 +1,64 @@
+package domain
+
+import "time"
+
+// Role defines what the user is allowed to do in the chat.
+type Role string
+
+const (
+	RoleMember    Role = "member"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+var roleRanks = map[Role]int{
+	RoleMember:    0,
+	RoleModerator: 1,
+	RoleAdmin:     2,
+}
+
+// CanModerate reports whether the user with the role may moderate the users with the target role,
+// moderators moderate members and admins moderate everyone except other admins.
+func (r Role) CanModerate(target Role) bool {
+	return r != RoleMember && roleRanks[r] > roleRanks[target]
+}
+
+// AtLeast reports whether the role grants at least the permissions of the other role.
+func (r Role) AtLeast(other Role) bool {
+	return roleRanks[r] >= roleRanks[other]
+}
+
+// UserRole is the role of the user, the user proves it with the token.
+type UserRole struct {
+	Username string
+	Role     Role
+	// TokenHash is the hex-encoded SHA-256 hash of the user's token.
+	TokenHash string
+}
+
+const (
+	ModerationMute   = "mute"
+	ModerationUnmute = "unmute"
+	ModerationKick   = "kick"
+	ModerationBan    = "ban"
+	ModerationUnban  = "unban"
+)
+
+// Moderation is an action of the moderator against the user.
+type Moderation struct {
+	Moderator string `json:"moderator"`
+	Action    string `json:"action"`
+	Target    string `json:"target"`
+	// Room is the room the moderator issued the action in.
+	Room string `json:"room"`
+	// Duration is the duration of the mute.
+	Duration time.Duration `json:"duration,omitempty"`
+	Reason   string        `json:"reason,omitempty"`
+}
+
+// Ban forbids the user to connect to the chat.
+type Ban struct {
+	Username string
+	Reason   string
+	BannedBy string
+}
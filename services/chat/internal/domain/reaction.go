@@ -0,0 +1,15 @@
+package domain
+
+const (
+	ReactionAdd    = "add"
+	ReactionRemove = "remove"
+)
+
+// Reaction is an emoji added to the message by the user or removed from it.
+type Reaction struct {
+	MessageID int64  `json:"id"`
+	Username  string `json:"username"`
+	Room      string `json:"room"`
+	Emoji     string `json:"emoji"`
+	Action    string `json:"action"`
+}
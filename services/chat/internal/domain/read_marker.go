@@ -0,0 +1,10 @@
+package domain
+
+// ReadMarker is the last message of the room read by the user.
+type ReadMarker struct {
+	Username  string
+	Room      string
+	MessageID int64
+	// Unread is the number of messages in the room after the marker.
+	Unread int
+}
@@ -0,0 +1,90 @@
+package filter
+
+import (
+	"chat/internal/domain"
+	"github.com/sirupsen/logrus"
+	"sync/atomic"
+	"time"
+)
+
+// Chain runs the filters one by one. The most severe verdict wins,
+// the filters after a rejecting or shadow-banning one are skipped.
+type Chain struct {
+	path    string
+	filters []Filter
+	rules   atomic.Pointer[Rules]
+	log     logrus.FieldLogger
+	now     func() time.Time
+}
+
+// NewChain loads the rules and creates the chain of the filters.
+func NewChain(cfg *Config, filters ...Filter) (*Chain, error) {
+	rules, err := LoadRules(cfg.RulesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Chain{
+		path:    cfg.RulesFile,
+		filters: filters,
+		log:     cfg.Logger,
+		now:     time.Now,
+	}
+	c.rules.Store(rules)
+	return c, nil
+}
+
+// Reload reloads the rules from the file, the current rules are kept if the file is invalid.
+func (c *Chain) Reload() error {
+	rules, err := LoadRules(c.path)
+	if err != nil {
+		c.log.WithError(err).
+			WithField("file", c.path).
+			Error("cannot reload the rules, keeping the current ones")
+		return err
+	}
+
+	c.rules.Store(rules)
+	c.log.WithField("file", c.path).
+		Info("the rules are reloaded")
+	return nil
+}
+
+// Check runs the filters for the message and returns the message with the masked text.
+// Every verdict is logged, so the moderators can review them.
+func (c *Chain) Check(msg domain.Message) (domain.Message, Verdict) {
+	rules := c.rules.Load()
+	now := c.now()
+	text := msg.Text
+
+	verdict := Verdict{Action: ActionAllow}
+	for _, f := range c.filters {
+		v := f.Check(&msg, rules, now)
+		if v.Action > verdict.Action {
+			verdict = v
+			verdict.Filter = f.Name()
+		}
+		if verdict.Action >= ActionReject {
+			break
+		}
+	}
+
+	c.logVerdict(msg, text, verdict)
+	return msg, verdict
+}
+
+// logVerdict logs the verdict with the original text of the message.
+func (c *Chain) logVerdict(msg domain.Message, text string, verdict Verdict) {
+	log := c.log.WithField("username", msg.Username).
+		WithField("room", msg.Room).
+		WithField("action", verdict.Action.String())
+	if verdict.Action == ActionAllow {
+		log.Debug("message is allowed")
+		return
+	}
+
+	log.WithField("filter", verdict.Filter).
+		WithField("reason", verdict.Reason).
+		WithField("message", text).
+		Warn("message is filtered")
+}
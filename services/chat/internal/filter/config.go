@@ -0,0 +1,10 @@
+package filter
+
+import "github.com/sirupsen/logrus"
+
+type Config struct {
+	// RulesFile is the path of the JSON file with the rules.
+	RulesFile string
+	// Logger logs the verdicts for the moderators.
+	Logger logrus.FieldLogger
+}
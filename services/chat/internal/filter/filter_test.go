@@ -0,0 +1,196 @@
+package filter
+
+import (
+	"chat/internal/domain"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const rulesJSON = `{
+	"words": [
+		{"action": "mask", "words": ["Idiot", "дурак"]},
+		{"action": "reject", "words": ["scam"]},
+		{"action": "shadow_ban", "words": ["casino"]}
+	],
+	"flood": {"window": "10s", "max_messages": 3, "max_duplicates": 1},
+	"links": {"action": "shadow_ban", "window": "1m", "max_links": 2},
+	"shadow_banned": ["spammer"]
+}`
+
+func TestParseRules(t *testing.T) {
+	type testcase struct {
+		data  string
+		valid bool
+	}
+
+	tests := []testcase{
+		{data: rulesJSON, valid: true},
+		{data: `{}`, valid: true},
+		{data: `{"words": [{"action": "allow", "words": ["hello"]}]}`},
+		{data: `{"words": [{"action": "mask", "words": [" "]}]}`},
+		{data: `{"words": [{"action": "ban", "words": ["hello"]}]}`},
+		{data: `{"flood": {"max_messages": 3}}`},
+		{data: `{"flood": {"window": "1s", "max_messages": -1}}`},
+		{data: `{"flood": {"window": "ten seconds", "max_messages": 3}}`},
+		{data: `{"links": {"action": "mask", "window": "1s", "max_links": 1}}`},
+		{data: `{"links": {"max_links": 1}}`},
+		{data: `{"unknown": true}`},
+		{data: `[]`},
+	}
+
+	for _, test := range tests {
+		_, err := ParseRules([]byte(test.data))
+		if test.valid {
+			assert.NoError(t, err, test.data)
+		} else {
+			assert.Error(t, err, test.data)
+		}
+	}
+}
+
+func TestWordFilter(t *testing.T) {
+	type testcase struct {
+		text     string
+		expected string
+		action   Action
+	}
+
+	tests := []testcase{
+		{text: "hello", expected: "hello", action: ActionAllow},
+		{text: "you IDIOT!", expected: "you *****!", action: ActionMask},
+		{text: "idiotic idiot", expected: "idiotic *****", action: ActionMask},
+		{text: "сам дурак", expected: "сам *****", action: ActionMask},
+		{text: "idiot, it's a scam", expected: "idiot, it's a scam", action: ActionReject},
+		{text: "scam in the casino", expected: "scam in the casino", action: ActionShadowBan},
+	}
+
+	rules, err := ParseRules([]byte(rulesJSON))
+	require.NoError(t, err)
+
+	for _, test := range tests {
+		msg := domain.Message{Username: "danil", Text: test.text}
+		verdict := WordFilter{}.Check(&msg, rules, time.Now())
+		assert.Equal(t, test.action, verdict.Action, test.text)
+		assert.Equal(t, test.expected, msg.Text)
+	}
+}
+
+func TestFloodFilter(t *testing.T) {
+	type testcase struct {
+		username string
+		text     string
+		after    time.Duration
+		action   Action
+	}
+
+	tests := []testcase{
+		{username: "danil", text: "hello", action: ActionAllow},
+		{username: "danil", text: "Hello ", after: time.Second, action: ActionReject},
+		{username: "gleb", text: "hello", after: time.Second, action: ActionAllow},
+		{username: "danil", text: "bye", after: time.Second, action: ActionAllow},
+		{username: "danil", text: "see you", after: time.Second, action: ActionReject},
+		{username: "danil", text: "hello", after: 20 * time.Second, action: ActionAllow},
+		{username: "danil", text: "bye", after: time.Second, action: ActionAllow},
+	}
+
+	rules, err := ParseRules([]byte(rulesJSON))
+	require.NoError(t, err)
+
+	f := NewFloodFilter()
+	now := time.Now()
+	for i, test := range tests {
+		now = now.Add(test.after)
+		msg := domain.Message{Username: test.username, Text: test.text}
+		verdict := f.Check(&msg, rules, now)
+		assert.Equal(t, test.action, verdict.Action, i)
+	}
+}
+
+func TestLinkFilter(t *testing.T) {
+	type testcase struct {
+		text   string
+		after  time.Duration
+		action Action
+	}
+
+	tests := []testcase{
+		{text: "see https://example.com", action: ActionAllow},
+		{text: "no links here", action: ActionAllow},
+		{text: "and www.example.org", after: time.Second, action: ActionAllow},
+		{text: "HTTP://example.net", after: time.Second, action: ActionShadowBan},
+		{text: "https://example.com", after: time.Minute, action: ActionAllow},
+	}
+
+	rules, err := ParseRules([]byte(rulesJSON))
+	require.NoError(t, err)
+
+	f := NewLinkFilter()
+	now := time.Now()
+	for i, test := range tests {
+		now = now.Add(test.after)
+		msg := domain.Message{Username: "danil", Text: test.text}
+		verdict := f.Check(&msg, rules, now)
+		assert.Equal(t, test.action, verdict.Action, i)
+	}
+}
+
+func TestChain_Check(t *testing.T) {
+	type testcase struct {
+		username string
+		text     string
+		expected string
+		action   Action
+		filter   string
+	}
+
+	tests := []testcase{
+		{username: "danil", text: "hello", expected: "hello", action: ActionAllow},
+		{username: "gleb", text: "idiot", expected: "*****", action: ActionMask, filter: "words"},
+		{username: "spammer", text: "hello", expected: "hello", action: ActionShadowBan, filter: "shadow_ban"},
+		{username: "maks", text: "idiot scam", expected: "idiot scam", action: ActionReject, filter: "words"},
+		{username: "maks", text: "idiot", expected: "*****", action: ActionMask, filter: "words"},
+		{username: "maks", text: "idiot", expected: "*****", action: ActionReject, filter: "flood"},
+	}
+
+	c := newTestChain(t, rulesJSON)
+	for _, test := range tests {
+		msg, verdict := c.Check(domain.Message{Username: test.username, Text: test.text, Room: domain.DefaultRoom})
+		assert.Equal(t, test.expected, msg.Text)
+		assert.Equal(t, test.action, verdict.Action)
+		assert.Equal(t, test.filter, verdict.Filter)
+	}
+}
+
+func TestChain_Reload(t *testing.T) {
+	c := newTestChain(t, `{"words": [{"action": "reject", "words": ["hello"]}]}`)
+	_, verdict := c.Check(domain.Message{Username: "danil", Text: "hello"})
+	assert.Equal(t, ActionReject, verdict.Action)
+
+	require.NoError(t, os.WriteFile(c.path, []byte(`{}`), 0o600))
+	require.NoError(t, c.Reload())
+	_, verdict = c.Check(domain.Message{Username: "danil", Text: "hello"})
+	assert.Equal(t, ActionAllow, verdict.Action)
+
+	// invalid rules keep the current ones
+	require.NoError(t, os.WriteFile(c.path, []byte(`{"words": [{"action": "allow"}]}`), 0o600))
+	assert.Error(t, c.Reload())
+	_, verdict = c.Check(domain.Message{Username: "danil", Text: "hello"})
+	assert.Equal(t, ActionAllow, verdict.Action)
+}
+
+func newTestChain(t *testing.T, rules string) *Chain {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	require.NoError(t, os.WriteFile(path, []byte(rules), 0o600))
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	c, err := NewChain(&Config{RulesFile: path, Logger: log}, DefaultFilters()...)
+	require.NoError(t, err)
+	return c
+}
@@ -0,0 +1,217 @@
+package filter
+
+import (
+	"chat/internal/domain"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Filter checks a message against the rules, it may mask the message text.
+type Filter interface {
+	Name() string
+	Check(msg *domain.Message, rules *Rules, now time.Time) Verdict
+}
+
+// DefaultFilters returns the filters applying all the rules.
+func DefaultFilters() []Filter {
+	return []Filter{
+		ShadowBanFilter{},
+		WordFilter{},
+		NewFloodFilter(),
+		NewLinkFilter(),
+	}
+}
+
+// ShadowBanFilter shadow-bans the messages of the shadow-banned users.
+type ShadowBanFilter struct{}
+
+func (ShadowBanFilter) Name() string {
+	return "shadow_ban"
+}
+
+func (ShadowBanFilter) Check(msg *domain.Message, rules *Rules, _ time.Time) Verdict {
+	if _, ok := rules.shadowBanned[msg.Username]; ok {
+		return Verdict{Action: ActionShadowBan, Reason: "user is shadow-banned"}
+	}
+	return Verdict{Action: ActionAllow}
+}
+
+// WordFilter applies the word rules, the masked words are replaced with asterisks.
+type WordFilter struct{}
+
+func (WordFilter) Name() string {
+	return "words"
+}
+
+func (WordFilter) Check(msg *domain.Message, rules *Rules, _ time.Time) Verdict {
+	if len(rules.words) == 0 {
+		return Verdict{Action: ActionAllow}
+	}
+
+	verdict := Verdict{Action: ActionAllow}
+	var found []string
+	text := []rune(msg.Text)
+	for start := 0; start < len(text); {
+		if !isWordRune(text[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(text) && isWordRune(text[end]) {
+			end++
+		}
+
+		word := strings.ToLower(string(text[start:end]))
+		if action, ok := rules.words[word]; ok {
+			found = append(found, word)
+			verdict.Action = max(verdict.Action, action)
+			if action == ActionMask {
+				for i := start; i < end; i++ {
+					text[i] = '*'
+				}
+			}
+		}
+		start = end
+	}
+
+	switch verdict.Action {
+	case ActionAllow:
+		return verdict
+	case ActionMask:
+		msg.Text = string(text)
+		verdict.Reason = fmt.Sprintf("masked words: %s", strings.Join(found, ", "))
+	default:
+		verdict.Reason = fmt.Sprintf("forbidden words: %s", strings.Join(found, ", "))
+	}
+	return verdict
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// FloodFilter limits the number of messages and of the repeated messages of a user.
+// The messages are counted by the replica, so the limits apply to every replica separately.
+type FloodFilter struct {
+	sent *userWindows[string]
+}
+
+func NewFloodFilter() *FloodFilter {
+	return &FloodFilter{sent: newUserWindows[string]()}
+}
+
+func (f *FloodFilter) Name() string {
+	return "flood"
+}
+
+func (f *FloodFilter) Check(msg *domain.Message, rules *Rules, now time.Time) Verdict {
+	rule := rules.Flood
+	if rule.MaxMessages == 0 && rule.MaxDuplicates == 0 {
+		return Verdict{Action: ActionAllow}
+	}
+
+	text := strings.ToLower(strings.TrimSpace(msg.Text))
+	prev := f.sent.add(msg.Username, text, now, time.Duration(rule.Window))
+
+	if rule.MaxMessages > 0 && len(prev) >= rule.MaxMessages {
+		return Verdict{Action: rule.Action, Reason: fmt.Sprintf("too many messages, at most %d messages per %s", rule.MaxMessages, time.Duration(rule.Window))}
+	}
+
+	duplicates := 0
+	for _, t := range prev {
+		if t == text {
+			duplicates++
+		}
+	}
+	if rule.MaxDuplicates > 0 && duplicates >= rule.MaxDuplicates {
+		return Verdict{Action: rule.Action, Reason: "the message is repeated too many times"}
+	}
+	return Verdict{Action: ActionAllow}
+}
+
+var linkRegexp = regexp.MustCompile(`(?i)(?:https?://|www\.)[^\s]+`)
+
+// LinkFilter limits the number of links a user sends.
+// The links are counted by the replica, so the limit applies to every replica separately.
+type LinkFilter struct {
+	sent *userWindows[int]
+}
+
+func NewLinkFilter() *LinkFilter {
+	return &LinkFilter{sent: newUserWindows[int]()}
+}
+
+func (f *LinkFilter) Name() string {
+	return "links"
+}
+
+func (f *LinkFilter) Check(msg *domain.Message, rules *Rules, now time.Time) Verdict {
+	rule := rules.Links
+	if rule.MaxLinks == 0 {
+		return Verdict{Action: ActionAllow}
+	}
+	links := len(linkRegexp.FindAllStringIndex(msg.Text, -1))
+	if links == 0 {
+		return Verdict{Action: ActionAllow}
+	}
+
+	prev := f.sent.add(msg.Username, links, now, time.Duration(rule.Window))
+	for _, n := range prev {
+		links += n
+	}
+	if links > rule.MaxLinks {
+		return Verdict{Action: rule.Action, Reason: fmt.Sprintf("too many links, at most %d links per %s", rule.MaxLinks, time.Duration(rule.Window))}
+	}
+	return Verdict{Action: ActionAllow}
+}
+
+// userWindows keeps the recent events of every user.
+type userWindows[T any] struct {
+	mx     sync.Mutex
+	events map[string][]event[T]
+	// swept is the time the events of all users were last removed.
+	swept time.Time
+}
+
+type event[T any] struct {
+	at    time.Time
+	value T
+}
+
+func newUserWindows[T any]() *userWindows[T] {
+	return &userWindows[T]{events: make(map[string][]event[T])}
+}
+
+// add stores the event of the user and returns the values of the user's events within the window before it.
+func (w *userWindows[T]) add(username string, value T, now time.Time, window time.Duration) []T {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	// the events of the users who stopped writing are removed once per window
+	if now.Sub(w.swept) > window {
+		for user, events := range w.events {
+			if len(events) == 0 || now.Sub(events[len(events)-1].at) > window {
+				delete(w.events, user)
+			}
+		}
+		w.swept = now
+	}
+
+	events := w.events[username]
+	i := 0
+	for i < len(events) && now.Sub(events[i].at) > window {
+		i++
+	}
+	events = events[i:]
+
+	prev := make([]T, 0, len(events))
+	for _, e := range events {
+		prev = append(prev, e.value)
+	}
+	w.events[username] = append(events, event[T]{at: now, value: value})
+	return prev
+}
@@ -0,0 +1,144 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Rules configure the filters, they are loaded from a JSON file.
+type Rules struct {
+	Words []WordRule `json:"words"`
+	Flood FloodRule  `json:"flood"`
+	Links LinkRule   `json:"links"`
+	// ShadowBanned are the users whose messages are shown only to themselves.
+	ShadowBanned []string `json:"shadow_banned"`
+
+	// words maps the lower-cased forbidden words to the actions.
+	words map[string]Action
+	// shadowBanned is the set of ShadowBanned.
+	shadowBanned map[string]struct{}
+}
+
+// WordRule applies the action to the messages containing any of the words, the words are case-insensitive.
+type WordRule struct {
+	Action Action   `json:"action"`
+	Words  []string `json:"words"`
+}
+
+// FloodRule limits the messages of a user sent within the window, a zero limit disables the check.
+// The action is reject by default, the messages cannot be masked.
+type FloodRule struct {
+	Action Action   `json:"action"`
+	Window Duration `json:"window"`
+	// MaxMessages is the maximal number of messages.
+	MaxMessages int `json:"max_messages"`
+	// MaxDuplicates is the maximal number of messages with the same text.
+	MaxDuplicates int `json:"max_duplicates"`
+}
+
+// LinkRule limits the number of links a user sends within the window, a zero limit disables the check.
+// The action is reject by default, the messages cannot be masked.
+type LinkRule struct {
+	Action   Action   `json:"action"`
+	Window   Duration `json:"window"`
+	MaxLinks int      `json:"max_links"`
+}
+
+// Duration is a time.Duration written as a string like "1m30s".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// LoadRules reads the rules from the file and checks them.
+func LoadRules(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read rules: %w", err)
+	}
+	return ParseRules(data)
+}
+
+// ParseRules decodes the rules and checks them.
+func ParseRules(data []byte) (*Rules, error) {
+	rules := &Rules{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	err := d.Decode(rules)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode rules: %w", err)
+	}
+
+	err = rules.init()
+	if err != nil {
+		return nil, fmt.Errorf("invalid rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (r *Rules) init() (err error) {
+	r.words = make(map[string]Action)
+	for _, rule := range r.Words {
+		if rule.Action == ActionAllow {
+			return errors.New("words: action must not be allow")
+		}
+		for _, word := range rule.Words {
+			word = strings.ToLower(strings.TrimSpace(word))
+			if word == "" {
+				return errors.New("words: word must be non-empty")
+			}
+			// the most severe action wins if the word is listed twice
+			r.words[word] = max(r.words[word], rule.Action)
+		}
+	}
+
+	if r.Flood.MaxMessages < 0 || r.Flood.MaxDuplicates < 0 {
+		return errors.New("flood: limits must be non-negative")
+	}
+	if (r.Flood.MaxMessages > 0 || r.Flood.MaxDuplicates > 0) && r.Flood.Window <= 0 {
+		return errors.New("flood: window must be positive")
+	}
+	r.Flood.Action, err = limitAction(r.Flood.Action)
+	if err != nil {
+		return fmt.Errorf("flood: %w", err)
+	}
+
+	if r.Links.MaxLinks < 0 {
+		return errors.New("links: limit must be non-negative")
+	}
+	if r.Links.MaxLinks > 0 && r.Links.Window <= 0 {
+		return errors.New("links: window must be positive")
+	}
+	r.Links.Action, err = limitAction(r.Links.Action)
+	if err != nil {
+		return fmt.Errorf("links: %w", err)
+	}
+
+	r.shadowBanned = make(map[string]struct{}, len(r.ShadowBanned))
+	for _, username := range r.ShadowBanned {
+		r.shadowBanned[username] = struct{}{}
+	}
+	return nil
+}
+
+// limitAction returns the action applied to the messages over a limit.
+func limitAction(a Action) (Action, error) {
+	switch a {
+	case ActionAllow:
+		return ActionReject, nil
+	case ActionMask:
+		return 0, errors.New("action must not be mask")
+	}
+	return a, nil
+}
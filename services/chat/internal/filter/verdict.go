@@ -0,0 +1,51 @@
+package filter
+
+import "fmt"
+
+// Action is the decision about a message, the actions are ordered by severity.
+type Action int
+
+const (
+	// ActionAllow saves the message as is.
+	ActionAllow Action = iota
+	// ActionMask saves the message with the forbidden words masked.
+	ActionMask
+	// ActionReject returns the error to the sender.
+	ActionReject
+	// ActionShadowBan shows the message only to the sender, it is not saved.
+	ActionShadowBan
+)
+
+var actionNames = map[Action]string{
+	ActionAllow:     "allow",
+	ActionMask:      "mask",
+	ActionReject:    "reject",
+	ActionShadowBan: "shadow_ban",
+}
+
+func (a Action) String() string {
+	name, ok := actionNames[a]
+	if !ok {
+		return fmt.Sprintf("Action(%d)", int(a))
+	}
+	return name
+}
+
+func (a *Action) UnmarshalText(text []byte) error {
+	for action, name := range actionNames {
+		if name == string(text) {
+			*a = action
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown action %q", string(text))
+}
+
+// Verdict is the decision of the filters about a message.
+type Verdict struct {
+	Action Action
+	// Filter is the name of the filter made the decision, it is empty for allowed messages.
+	Filter string
+	// Reason describes the decision, it is sent to the sender of a rejected message.
+	Reason string
+}
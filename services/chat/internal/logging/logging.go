@@ -0,0 +1,103 @@
+// Package logging configures the logger of the chat service: the format, the level and the sampling
+// of the entries, the fields passed in the context and the redaction of the message content.
+package logging
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Formats of the entries.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+type Config struct {
+	// Format is FormatText or FormatJSON.
+	Format string
+	Level  logrus.Level
+	// SamplingInitial entries with the same message are logged every second, then only
+	// every SamplingThereafter-th of them. Warnings and errors are not sampled,
+	// SamplingInitial of 0 disables the sampling.
+	SamplingInitial    int
+	SamplingThereafter int
+	// LogContent disables the redaction of the message content.
+	LogContent bool
+}
+
+// Configure applies the config to the logger, the loggers derived from it are configured too.
+func Configure(logger *logrus.Logger, cfg *Config) {
+	var formatter logrus.Formatter = &logrus.TextFormatter{
+		FullTimestamp: true,
+		PadLevelText:  true,
+	}
+	if cfg.Format == FormatJSON {
+		formatter = &logrus.JSONFormatter{}
+	}
+	if cfg.SamplingInitial > 0 {
+		formatter = newSamplingFormatter(formatter, cfg.SamplingInitial, cfg.SamplingThereafter)
+	}
+
+	logger.SetFormatter(formatter)
+	logger.SetLevel(cfg.Level)
+	logger.AddHook(contextHook{})
+	if !cfg.LogContent {
+		logger.AddHook(redactHook{})
+	}
+}
+
+type fieldsKey struct{}
+
+// WithFields returns the context with the fields added to the entries logged with it,
+// e.g. log.WithContext(ctx).Info("...").
+func WithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	merged := make(logrus.Fields, len(fields))
+	if parent, ok := ctx.Value(fieldsKey{}).(logrus.Fields); ok {
+		for k, v := range parent {
+			merged[k] = v
+		}
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// contextHook adds the fields of the entry context and the IDs of its span to the entry.
+type contextHook struct{}
+
+func (contextHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (contextHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	if fields, ok := entry.Context.Value(fieldsKey{}).(logrus.Fields); ok {
+		for k, v := range fields {
+			if _, ok := entry.Data[k]; !ok {
+				entry.Data[k] = v
+			}
+		}
+	}
+	if sc := trace.SpanContextFromContext(entry.Context); sc.IsValid() {
+		entry.Data["trace_id"] = sc.TraceID().String()
+		entry.Data["span_id"] = sc.SpanID().String()
+	}
+	return nil
+}
+
+// FromContext returns the logger adding the fields of the context to its entries.
+func FromContext(ctx context.Context, log logrus.FieldLogger) logrus.FieldLogger {
+	switch l := log.(type) {
+	case *logrus.Entry:
+		return l.WithContext(ctx)
+	case *logrus.Logger:
+		return l.WithContext(ctx)
+	}
+	fields, _ := ctx.Value(fieldsKey{}).(logrus.Fields)
+	return log.WithFields(fields)
+}
@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"bytes"
+	"chat/internal/domain"
+	"context"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLogger(cfg *Config) (*logrus.Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	Configure(logger, cfg)
+	return logger, buf
+}
+
+func TestConfigure_Redaction(t *testing.T) {
+	type testcase struct {
+		name       string
+		logContent bool
+		field      string
+		value      any
+		expected   any
+	}
+
+	tests := []testcase{
+		{name: "frame", field: "data", value: `{"message": "secret"}`, expected: "[redacted 21 bytes]"},
+		{name: "bytes", field: "data", value: []byte("secret"), expected: "[redacted 6 bytes]"},
+		{name: "query args", field: "args", value: []any{"danil", "secret"}, expected: "[redacted 2 args]"},
+		{name: "search query", field: "query", value: "secret plans", expected: "[redacted 12 bytes]"},
+		{name: "pubsub payload", field: "payload", value: `{"reason": "secret"}`, expected: "[redacted 20 bytes]"},
+		{
+			name:     "message",
+			field:    "message",
+			value:    domain.Message{Username: "danil", Text: "secret"},
+			expected: map[string]any{"username": "danil", "message": "[redacted 6 bytes]"},
+		},
+		{name: "other field", field: "username", value: "danil", expected: "danil"},
+		{name: "content logging", logContent: true, field: "data", value: "secret", expected: "secret"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logger, buf := newTestLogger(&Config{Format: FormatJSON, Level: logrus.InfoLevel, LogContent: test.logContent})
+			logger.WithField(test.field, test.value).Info("test")
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Equal(t, test.expected, entry[test.field])
+			if !test.logContent {
+				assert.NotContains(t, buf.String(), "secret")
+			}
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	logger, buf := newTestLogger(&Config{Format: FormatJSON, Level: logrus.InfoLevel})
+
+	ctx := WithFields(context.Background(), logrus.Fields{"connection_id": "42", "room": "general"})
+	ctx = WithFields(ctx, logrus.Fields{"room": "news"})
+	FromContext(ctx, logger.WithField("FROM", "[TEST]")).WithField("username", "danil").Info("test")
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "42", entry["connection_id"])
+	assert.Equal(t, "news", entry["room"])
+	assert.Equal(t, "danil", entry["username"])
+	assert.Equal(t, "[TEST]", entry["FROM"])
+}
+
+func TestSamplingFormatter(t *testing.T) {
+	type testcase struct {
+		name       string
+		initial    int
+		thereafter int
+		level      logrus.Level
+		entries    int
+		expected   int
+	}
+
+	tests := []testcase{
+		{name: "first entries", initial: 3, thereafter: 0, level: logrus.InfoLevel, entries: 10, expected: 3},
+		{name: "every n-th entry", initial: 2, thereafter: 3, level: logrus.InfoLevel, entries: 11, expected: 5},
+		{name: "warnings are not sampled", initial: 1, thereafter: 0, level: logrus.WarnLevel, entries: 10, expected: 10},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logger, buf := newTestLogger(&Config{
+				Format:             FormatText,
+				Level:              logrus.InfoLevel,
+				SamplingInitial:    test.initial,
+				SamplingThereafter: test.thereafter,
+			})
+			// the counters are not reset during the test
+			now := time.Now()
+			logger.Formatter.(*samplingFormatter).now = func() time.Time { return now }
+
+			for range test.entries {
+				logger.Log(test.level, "test")
+			}
+			assert.Equal(t, test.expected, strings.Count(buf.String(), "\n"))
+		})
+	}
+}
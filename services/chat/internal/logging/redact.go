@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"reflect"
+)
+
+// Redacter is a logged value with the user content, Redacted returns the value without it.
+type Redacter interface {
+	Redacted() any
+}
+
+// contentFields are the fields with the raw frames, message texts, query arguments,
+// search queries and pub/sub payloads.
+var contentFields = map[string]bool{
+	"data":    true,
+	"message": true,
+	"text":    true,
+	"args":    true,
+	"query":   true,
+	"payload": true,
+}
+
+// redactHook replaces the user content in the entry fields.
+type redactHook struct{}
+
+func (redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (redactHook) Fire(entry *logrus.Entry) error {
+	for k, v := range entry.Data {
+		if r, ok := v.(Redacter); ok {
+			if rv := reflect.ValueOf(v); rv.Kind() != reflect.Pointer || !rv.IsNil() {
+				entry.Data[k] = r.Redacted()
+			}
+			continue
+		}
+		if !contentFields[k] {
+			continue
+		}
+		switch v := v.(type) {
+		case string:
+			entry.Data[k] = redacted(len(v))
+		case []byte:
+			entry.Data[k] = redacted(len(v))
+		case []any:
+			entry.Data[k] = fmt.Sprintf("[redacted %d args]", len(v))
+		}
+	}
+	return nil
+}
+
+func redacted(size int) string {
+	return fmt.Sprintf("[redacted %d bytes]", size)
+}
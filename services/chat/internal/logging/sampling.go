@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// samplingFormatter drops the repeated entries below the warning level: in every second the first
+// initial entries with the same message are formatted, then only every thereafter-th of them.
+type samplingFormatter struct {
+	next       logrus.Formatter
+	initial    int
+	thereafter int
+
+	mx     sync.Mutex
+	counts map[string]int
+	reset  time.Time
+	now    func() time.Time
+}
+
+func newSamplingFormatter(next logrus.Formatter, initial int, thereafter int) *samplingFormatter {
+	return &samplingFormatter{
+		next:       next,
+		initial:    initial,
+		thereafter: thereafter,
+		counts:     make(map[string]int),
+		now:        time.Now,
+	}
+}
+
+// Format formats the sampled entries, the dropped entries are formatted as nothing.
+func (f *samplingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if entry.Level <= logrus.WarnLevel || f.sample(entry.Message) {
+		return f.next.Format(entry)
+	}
+	return nil, nil
+}
+
+func (f *samplingFormatter) sample(message string) bool {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	now := f.now()
+	if now.Sub(f.reset) >= time.Second {
+		clear(f.counts)
+		f.reset = now
+	}
+	f.counts[message]++
+
+	n := f.counts[message]
+	if n <= f.initial {
+		return true
+	}
+	return f.thereafter > 0 && (n-f.initial)%f.thereafter == 0
+}
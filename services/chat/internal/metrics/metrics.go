@@ -0,0 +1,90 @@
+// Package metrics contains the Prometheus metrics of the chat service,
+// they are exposed on GET /metrics of the websocket server.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+const namespace = "chat"
+
+// Transports of the received messages.
+const (
+	TransportWebsocket = "websocket"
+	TransportREST      = "rest"
+)
+
+// Reasons of the rejected messages.
+const (
+	ReasonInvalid      = "invalid"
+	ReasonRateLimited  = "rate_limited"
+	ReasonUnauthorized = "unauthorized"
+	ReasonMuted        = "muted"
+	ReasonForbidden    = "forbidden"
+	ReasonError        = "error"
+)
+
+// Sources of the last messages sent on connect.
+const (
+	SourceRedis    = "redis"
+	SourcePostgres = "postgres"
+)
+
+// Results of the produced Kafka records.
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+)
+
+var (
+	ActiveConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_connections",
+		Help:      "Number of the open websocket connections to the replica.",
+	})
+
+	MessagesReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_received_total",
+		Help:      "Number of the messages received from the clients.",
+	}, []string{"transport"})
+
+	MessagesBroadcast = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_broadcast_total",
+		Help:      "Number of the saved messages sent to the connections of their rooms.",
+	})
+
+	MessagesRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_rejected_total",
+		Help:      "Number of the messages and frames rejected by the server.",
+	}, []string{"reason"})
+
+	BroadcastDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "broadcast_duration_seconds",
+		Help:      "Time of sending a message to all connections of its room.",
+		Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 10),
+	})
+
+	KafkaProduced = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_produced_total",
+		Help:      "Number of the records acknowledged or failed by Kafka.",
+	}, []string{"result"})
+
+	HistoryLoads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "history_loads_total",
+		Help:      "Number of the last messages loads by the source they were loaded from.",
+	}, []string{"source"})
+)
+
+// Handler serves the metrics in the Prometheus format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
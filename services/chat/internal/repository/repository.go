@@ -5,7 +5,13 @@ import (
 	"chat/internal/adapters/postgres"
 	"chat/internal/adapters/redis"
 	"chat/internal/domain"
+	"chat/internal/metrics"
+	errs "chat/internal/repository/errs"
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
 	"time"
 )
 
@@ -16,22 +22,7 @@ type Repository struct {
 }
 
 func NewRepository(pgConf *postgres.Config, redisConf *redis.Config, kafkaConf *kafka.Config) (*Repository, error) {
-	var (
-		k   *kafka.Producer
-		err error
-	)
-
-	ticker := time.NewTicker(200 * time.Millisecond)
-	defer ticker.Stop()
-	retries := 15
-	for retries > 0 {
-		<-ticker.C
-		k, err = kafka.NewProducer(kafkaConf)
-		if err == nil {
-			break
-		}
-		retries--
-	}
+	k, err := kafka.NewProducer(kafkaConf)
 	if err != nil {
 		return nil, err
 	}
@@ -43,19 +34,288 @@ func NewRepository(pgConf *postgres.Config, redisConf *redis.Config, kafkaConf *
 	}, nil
 }
 
+// CheckHealth pings postgres, redis and the kafka brokers concurrently.
+func (r *Repository) CheckHealth(ctx context.Context) map[string]error {
+	checks := map[string]func(ctx context.Context) error{
+		"postgres": r.postgres.Ping,
+		"redis":    r.redis.Ping,
+		"kafka":    r.kafka.Ping,
+	}
+
+	var (
+		mx  sync.Mutex
+		wg  sync.WaitGroup
+		res = make(map[string]error, len(checks))
+	)
+	for name, ping := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := ping(ctx)
+			mx.Lock()
+			res[name] = err
+			mx.Unlock()
+		}()
+	}
+	wg.Wait()
+	return res
+}
+
+// Close flushes the messages queued to kafka until ctx is done and closes the connections.
+func (r *Repository) Close(ctx context.Context) error {
+	err := r.kafka.Close(ctx)
+	r.postgres.Close()
+	return errors.Join(err, r.redis.Close())
+}
+
+// SaveMessage produces the message to kafka and adds it to the recent messages of the room,
+// so it can be found before the storage service saves it to postgres.
 func (r *Repository) SaveMessage(ctx context.Context, message domain.Message) error {
-	return r.kafka.SaveMessage(ctx, message)
+	err := r.kafka.SaveMessage(ctx, message)
+	if err != nil {
+		return err
+	}
+	// the message is saved even if it is not added to the recent messages, the error is logged by the adapter
+	_ = r.redis.SaveRecentMessage(ctx, message)
+	return nil
 }
 
-func (r *Repository) LoadMessages(ctx context.Context, count int) ([]domain.Message, error) {
-	messages, err := r.redis.LoadMessages(ctx, count)
+func (r *Repository) SaveReaction(ctx context.Context, reaction domain.Reaction) error {
+	return r.kafka.SaveReaction(ctx, reaction)
+}
+
+func (r *Repository) LoadMessages(ctx context.Context, room string, count int) ([]domain.Message, error) {
+	messages, err := r.redis.LoadMessages(ctx, room, count)
 	if err == nil {
-		return messages, nil
+		metrics.HistoryLoads.WithLabelValues(metrics.SourceRedis).Inc()
+		return r.withCounts(ctx, messages), nil
 	}
 
-	messages, err = r.postgres.LoadMessages(ctx, count)
+	messages, err = r.postgres.LoadMessages(ctx, room, count)
 	if err == nil {
-		return messages, nil
+		metrics.HistoryLoads.WithLabelValues(metrics.SourcePostgres).Inc()
+		return r.withCounts(ctx, messages), nil
 	}
 	return nil, err
 }
+
+// LoadHistory loads the history from postgres, the cache contains only the last messages.
+func (r *Repository) LoadHistory(ctx context.Context, room string, before int64, count int) ([]domain.Message, error) {
+	messages, err := r.postgres.LoadHistory(ctx, room, before, count)
+	if err != nil {
+		return nil, err
+	}
+	return r.withCounts(ctx, messages), nil
+}
+
+// LoadMessagesAfter loads the last count messages missed by the client from postgres and the recent messages,
+// since the messages produced to kafka are saved to postgres by the storage service with a lag.
+func (r *Repository) LoadMessagesAfter(ctx context.Context, room string, after int64, count int) ([]domain.Message, error) {
+	messages, err := r.postgres.LoadMessagesAfter(ctx, room, after, count)
+	if err != nil {
+		return nil, err
+	}
+
+	// the messages not saved yet are missed while redis is down, the error is logged by the adapter
+	recent, err := r.redis.LoadRecentMessagesAfter(ctx, room, after)
+	if err == nil {
+		messages = mergeMessages(messages, recent, count)
+	}
+	return r.withCounts(ctx, messages), nil
+}
+
+// mergeMessages returns the last count messages of both lists ordered by ID, the messages found in both lists
+// are taken from the first one.
+func mergeMessages(messages []domain.Message, other []domain.Message, count int) []domain.Message {
+	ids := make(map[int64]struct{}, len(messages))
+	for _, m := range messages {
+		ids[m.ID] = struct{}{}
+	}
+	for _, m := range other {
+		if _, ok := ids[m.ID]; !ok {
+			messages = append(messages, m)
+		}
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].ID < messages[j].ID
+	})
+	if len(messages) > count {
+		messages = messages[len(messages)-count:]
+	}
+	return messages
+}
+
+func (r *Repository) LoadMessage(ctx context.Context, id int64) (domain.Message, error) {
+	msg, err := r.postgres.LoadMessage(ctx, id)
+	if err != nil {
+		return domain.Message{}, err
+	}
+	return r.withCounts(ctx, []domain.Message{msg})[0], nil
+}
+
+// LoadRoomMessage loads the message of the room from postgres or, if it is not saved yet, from the recent messages.
+// The messages of the other rooms are not found.
+func (r *Repository) LoadRoomMessage(ctx context.Context, room string, id int64) (domain.Message, error) {
+	msg, err := r.postgres.LoadMessage(ctx, id)
+	if errors.Is(err, errs.ErrNotFound) {
+		msg, err = r.redis.LoadRecentMessage(ctx, room, id)
+	}
+	if err != nil {
+		return domain.Message{}, err
+	}
+	if msg.Room != room {
+		return domain.Message{}, fmt.Errorf("message %d of room %s: %w", id, room, errs.ErrNotFound)
+	}
+	return msg, nil
+}
+
+// withCounts fills reaction and reply counts of the messages, the messages
+// are returned without the counts if they cannot be loaded.
+func (r *Repository) withCounts(ctx context.Context, messages []domain.Message) []domain.Message {
+	if len(messages) == 0 {
+		return messages
+	}
+
+	ids := make([]int64, 0, len(messages))
+	for _, m := range messages {
+		ids = append(ids, m.ID)
+	}
+
+	reactions, err := r.postgres.LoadReactions(ctx, ids)
+	if err == nil {
+		for i := range messages {
+			messages[i].Reactions = reactions[messages[i].ID]
+		}
+	}
+
+	replies, err := r.postgres.LoadReplyCounts(ctx, ids)
+	if err == nil {
+		for i := range messages {
+			messages[i].Replies = replies[messages[i].ID]
+		}
+	}
+	return messages
+}
+
+// LoadThread loads the thread from postgres, since threads are not cached.
+func (r *Repository) LoadThread(ctx context.Context, room string, id int64) ([]domain.Message, error) {
+	messages, err := r.postgres.LoadThread(ctx, room, id)
+	if err != nil {
+		return nil, err
+	}
+	return r.withCounts(ctx, messages), nil
+}
+
+// LoadMentions loads the messages mentioning the user from postgres, since mentions are not cached.
+func (r *Repository) LoadMentions(ctx context.Context, username string, count int) ([]domain.Message, error) {
+	messages, err := r.postgres.LoadMentions(ctx, username, count)
+	if err != nil {
+		return nil, err
+	}
+	return r.withCounts(ctx, messages), nil
+}
+
+// SearchMessages searches the messages in postgres, the cache doesn't support full-text search.
+func (r *Repository) SearchMessages(ctx context.Context, query string, room string, count int) ([]domain.Message, error) {
+	messages, err := r.postgres.SearchMessages(ctx, query, room, count)
+	if err != nil {
+		return nil, err
+	}
+	return r.withCounts(ctx, messages), nil
+}
+
+func (r *Repository) LoadContext(ctx context.Context, id int64, count int) ([]domain.Message, error) {
+	messages, err := r.postgres.LoadContext(ctx, id, count)
+	if err != nil {
+		return nil, err
+	}
+	return r.withCounts(ctx, messages), nil
+}
+
+func (r *Repository) SaveReadMarker(ctx context.Context, marker domain.ReadMarker) error {
+	return r.postgres.SaveReadMarker(ctx, marker)
+}
+
+func (r *Repository) LoadReadMarkers(ctx context.Context, username string) ([]domain.ReadMarker, error) {
+	return r.postgres.LoadReadMarkers(ctx, username)
+}
+
+func (r *Repository) LoadUserRole(ctx context.Context, username string) (domain.UserRole, error) {
+	return r.postgres.LoadUserRole(ctx, username)
+}
+
+func (r *Repository) LoadBan(ctx context.Context, username string) (domain.Ban, error) {
+	return r.postgres.LoadBan(ctx, username)
+}
+
+func (r *Repository) SaveBan(ctx context.Context, ban domain.Ban) error {
+	return r.postgres.SaveBan(ctx, ban)
+}
+
+func (r *Repository) DeleteBan(ctx context.Context, username string) error {
+	return r.postgres.DeleteBan(ctx, username)
+}
+
+func (r *Repository) SaveModeration(ctx context.Context, m domain.Moderation) error {
+	return r.postgres.SaveModeration(ctx, m)
+}
+
+// SaveMute keeps the mute in redis, since mutes are temporary.
+func (r *Repository) SaveMute(ctx context.Context, username string, d time.Duration) error {
+	return r.redis.SaveMute(ctx, username, d)
+}
+
+func (r *Repository) DeleteMute(ctx context.Context, username string) error {
+	return r.redis.DeleteMute(ctx, username)
+}
+
+func (r *Repository) LoadMute(ctx context.Context, username string) (time.Time, error) {
+	return r.redis.LoadMute(ctx, username)
+}
+
+func (r *Repository) PublishModeration(ctx context.Context, m domain.Moderation) error {
+	return r.redis.PublishModeration(ctx, m)
+}
+
+func (r *Repository) SubscribeModeration(ctx context.Context) (<-chan domain.Moderation, error) {
+	return r.redis.SubscribeModeration(ctx)
+}
+
+// DeleteUserMessages deletes the messages of the user from postgres and from the cache of the rooms the user wrote to,
+// it returns the number of the messages deleted from postgres.
+func (r *Repository) DeleteUserMessages(ctx context.Context, username string) (int, error) {
+	deleted, err := r.postgres.DeleteUserMessages(ctx, username)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	rooms := make([]string, 0, len(deleted))
+	for room, count := range deleted {
+		rooms = append(rooms, room)
+		total += count
+	}
+	_, err = r.redis.DeleteUserMessages(ctx, username, rooms)
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// SaveReadOnly keeps the read-only rooms in redis, so all replicas see them.
+func (r *Repository) SaveReadOnly(ctx context.Context, room string, readOnly bool) error {
+	return r.redis.SaveReadOnly(ctx, room, readOnly)
+}
+
+func (r *Repository) LoadReadOnly(ctx context.Context, room string) (bool, error) {
+	return r.redis.LoadReadOnly(ctx, room)
+}
+
+func (r *Repository) PublishAnnouncement(ctx context.Context, a domain.Announcement) error {
+	return r.redis.PublishAnnouncement(ctx, a)
+}
+
+func (r *Repository) SubscribeAnnouncements(ctx context.Context) (<-chan domain.Announcement, error) {
+	return r.redis.SubscribeAnnouncements(ctx)
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"chat/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMergeMessages(t *testing.T) {
+	type testcase struct {
+		name     string
+		messages []int64
+		other    []int64
+		count    int
+		expected []int64
+	}
+
+	tests := []testcase{
+		{name: "no recent messages", messages: []int64{43, 44}, other: nil, count: 3, expected: []int64{43, 44}},
+		{name: "messages not saved yet", messages: []int64{43, 44}, other: []int64{44, 45, 46}, count: 5, expected: []int64{43, 44, 45, 46}},
+		{name: "only recent messages", messages: nil, other: []int64{45, 46}, count: 5, expected: []int64{45, 46}},
+		{name: "last messages", messages: []int64{43, 44, 45}, other: []int64{45, 46}, count: 3, expected: []int64{44, 45, 46}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged := mergeMessages(messagesWithIDs(test.messages, "postgres"), messagesWithIDs(test.other, "redis"), test.count)
+
+			saved := make(map[int64]bool, len(test.messages))
+			for _, id := range test.messages {
+				saved[id] = true
+			}
+			ids := make([]int64, 0, len(merged))
+			for _, m := range merged {
+				ids = append(ids, m.ID)
+				// the saved messages are taken from postgres
+				if saved[m.ID] {
+					assert.Equal(t, "postgres", m.Text, m.ID)
+				}
+			}
+			assert.Equal(t, test.expected, ids)
+		})
+	}
+}
+
+func messagesWithIDs(ids []int64, text string) []domain.Message {
+	messages := make([]domain.Message, 0, len(ids))
+	for _, id := range ids {
+		messages = append(messages, domain.Message{ID: id, Text: text, Room: domain.DefaultRoom})
+	}
+	return messages
+}
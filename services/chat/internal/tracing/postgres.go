@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+)
+
+// QueryTracer creates a span for every query to postgres and passes the query to the next tracer.
+type QueryTracer struct {
+	Next pgx.QueryTracer
+}
+
+func (t *QueryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = Tracer().Start(ctx, "postgres "+operationName(data.SQL),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(operationName(data.SQL)),
+			semconv.DBQueryText(data.SQL),
+		),
+	)
+	if t.Next != nil {
+		ctx = t.Next.TraceQueryStart(ctx, conn, data)
+	}
+	return ctx
+}
+
+func (t *QueryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	if t.Next != nil {
+		t.Next.TraceQueryEnd(ctx, conn, data)
+	}
+	span := trace.SpanFromContext(ctx)
+	RecordError(span, data.Err)
+	span.End()
+}
+
+// operationName returns the first keyword of the query, e.g. SELECT.
+func operationName(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToUpper(fields[0])
+}
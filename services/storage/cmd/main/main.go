@@ -2,38 +2,52 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
+	"net/http"
 	"os"
 	"os/signal"
 	"storage/internal/adapters/kafka"
 	"storage/internal/app"
 	"storage/internal/config"
+	"storage/internal/logging"
+	"storage/internal/metrics"
 	"storage/internal/repository"
+	"storage/internal/tracing"
 	"syscall"
 )
 
 const EnvFile = "example.env"
 
+// errSignal stops the service without an error, the exit status is 0.
+var errSignal = errors.New("captured signal")
+
 func main() {
-	logger := &logrus.Logger{
-		Out: os.Stderr,
-		Formatter: &logrus.TextFormatter{
-			ForceColors:   true,
-			PadLevelText:  true,
-			FullTimestamp: true,
-		},
-		Hooks: logrus.LevelHooks{},
-		Level: logrus.DebugLevel,
-	}
+	// the logger is configured when the config is loaded
+	logger := logrus.New()
 
 	cfg, err := config.Get(logger, EnvFile)
 	if err != nil {
 		logger.Fatal(err)
 	}
+	logging.Configure(logger, cfg.Logging)
+
+	tracerProvider, err := tracing.NewProvider(cfg.Tracing)
+	if err != nil {
+		logger.
+			WithError(err).
+			Fatal("cannot create tracer provider")
+	}
 
 	repo := repository.New(cfg.Postgres, cfg.Redis, logger.WithField("FROM", "[REPOSITORY]"))
+	// the service is ready after the schema is migrated, the chat service starts after it
+	if err = repo.Migrate(context.Background()); err != nil {
+		logger.
+			WithError(err).
+			Fatal("cannot migrate the database")
+	}
 	a := app.NewApp(repo)
 	consumer, err := kafka.NewConsumer(a, cfg.Kafka)
 	if err != nil {
@@ -41,6 +55,11 @@ func main() {
 			WithError(err).
 			Fatal("cannot create kafka consumer")
 	}
+	metricsServer := metrics.NewServer(cfg.Metrics, map[string]metrics.Check{
+		"postgres": repo.PingPostgres,
+		"redis":    repo.PingRedis,
+		"kafka":    consumer.Ping,
+	}, logger.WithField("FROM", "[METRICS]"))
 
 	// graceful shutdown
 	eg, ctx := errgroup.WithContext(context.Background())
@@ -52,26 +71,78 @@ func main() {
 			logger.
 				WithField("signal", s).
 				Info("captured signal")
-			return fmt.Errorf("captured signal: %v", s)
+			return fmt.Errorf("%w: %v", errSignal, s)
 		case <-ctx.Done():
 			return nil
 		}
 	})
 
+	eg.Go(func() error {
+		logger.WithField("port", cfg.Metrics.Port).Info("metrics server: start listening")
+		defer logger.WithField("port", cfg.Metrics.Port).Info("metrics server: close listening")
+
+		errCh := make(chan error)
+
+		go func() {
+			err := metricsServer.ListenAndServe()
+			if !errors.Is(err, http.ErrServerClosed) && err != nil {
+				errCh <- err
+			}
+		}()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errCh:
+			return fmt.Errorf("metrics server can't listen and serve requests: %s", err.Error())
+		}
+	})
+
 	eg.Go(func() error {
 		logger.Info("starting consumer")
-		return consumer.Run()
+		return consumer.Run(ctx)
 	})
 
-	if err = eg.Wait(); err != nil {
+	// the consumer has returned here: the in-flight record is saved and the marked offsets
+	// are committed when the session is released
+	exitCode := 0
+	if err = eg.Wait(); errors.Is(err, errSignal) {
 		logger.
 			WithError(err).
 			Info("gracefully shutting down the consumer")
+	} else if err != nil {
+		logger.
+			WithError(err).
+			Error("the consumer is stopped, shutting down")
+		exitCode = 1
 	}
+	metricsServer.SetShuttingDown()
 
+	// the consumer leaves the group, so its partitions are reassigned without waiting for the session timeout
 	if err = consumer.Close(); err != nil {
 		logger.
 			WithError(err).
 			Error("failed to close consumer")
+		exitCode = 1
+	}
+
+	if err = repo.Close(); err != nil {
+		logger.
+			WithError(err).
+			Error("failed to close repository")
+		exitCode = 1
+	}
+
+	if err = metricsServer.Shutdown(context.Background()); err != nil {
+		logger.
+			WithError(err).
+			Error("failed to shut down metrics server")
+	}
+
+	if err = tracerProvider.Shutdown(context.Background()); err != nil {
+		logger.
+			WithError(err).
+			Error("failed to flush the spans")
 	}
+	os.Exit(exitCode)
 }
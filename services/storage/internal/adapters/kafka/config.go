@@ -3,13 +3,18 @@ package kafka
 import (
 	"github.com/IBM/sarama"
 	"github.com/sirupsen/logrus"
+	"time"
 )
 
 type Config struct {
 	Brokers []string
 	GroupID string
 	Topics  []string
-	Logger  logrus.FieldLogger
+	// ConnectRetries is the number of the retried attempts to connect to the brokers on start,
+	// ConnectBackoff is the delay before the first retry, it doubles after every attempt.
+	ConnectRetries int
+	ConnectBackoff time.Duration
+	Logger         logrus.FieldLogger
 }
 
 func InitConsumerConfig() *sarama.Config {
@@ -2,53 +2,50 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"github.com/IBM/sarama"
 	"github.com/sirupsen/logrus"
 	"storage/internal/app"
 	"time"
 )
 
+// maxConnectBackoff limits the delay between the attempts to connect to the brokers.
+const maxConnectBackoff = 10 * time.Second
+
 type Consumer struct {
+	client        sarama.Client
 	topics        []string
 	handler       *Handler
-	ctx           context.Context
 	consumerGroup sarama.ConsumerGroup
 	log           logrus.FieldLogger
 }
 
 func NewConsumer(app *app.App, cfg *Config) (*Consumer, error) {
-	consumer := &Consumer{
-		ctx: context.Background(),
-	}
+	consumer := &Consumer{}
 	handler := &Handler{
-		app: app,
-		log: cfg.Logger.WithField("FROM", "[KAFKA-HANDLER]"),
+		app:     app,
+		groupID: cfg.GroupID,
+		log:     cfg.Logger.WithField("FROM", "[KAFKA-HANDLER]"),
 	}
 
-	var (
-		consumerGroup sarama.ConsumerGroup
-		err           error
-	)
-
-	ticker := time.NewTicker(200 * time.Millisecond)
-	defer ticker.Stop()
-	retries := 15
-	for retries > 0 {
-		<-ticker.C
-		consumerGroup, err = sarama.NewConsumerGroup(cfg.Brokers, cfg.GroupID, InitConsumerConfig())
-		if err == nil {
-			break
-		}
-		retries--
+	client, err := connect(cfg)
+	if err != nil {
+		cfg.Logger.
+			WithError(err).
+			Error("cannot connect to kafka brokers")
+		return nil, err
 	}
 
+	consumerGroup, err := sarama.NewConsumerGroupFromClient(cfg.GroupID, client)
 	if err != nil {
 		cfg.Logger.
 			WithError(err).
 			Errorf("cannot create consumer group")
+		_ = client.Close()
 		return nil, err
 	}
 
+	consumer.client = client
 	consumer.consumerGroup = consumerGroup
 	consumer.topics = cfg.Topics
 	consumer.handler = handler
@@ -56,23 +53,63 @@ func NewConsumer(app *app.App, cfg *Config) (*Consumer, error) {
 	return consumer, nil
 }
 
-func (c *Consumer) Run() error {
+// connect creates the client of the brokers, the failed attempts are retried
+// cfg.ConnectRetries times with the exponential backoff starting at cfg.ConnectBackoff.
+func connect(cfg *Config) (sarama.Client, error) {
+	backoff := cfg.ConnectBackoff
+	for attempt := 0; ; attempt++ {
+		client, err := sarama.NewClient(cfg.Brokers, InitConsumerConfig())
+		if err == nil {
+			return client, nil
+		}
+		if attempt >= cfg.ConnectRetries {
+			return nil, err
+		}
+
+		cfg.Logger.
+			WithError(err).
+			WithField("attempt", attempt+1).
+			WithField("backoff", backoff).
+			Warn("cannot connect to kafka brokers, retrying")
+		time.Sleep(backoff)
+		backoff = min(2*backoff, maxConnectBackoff)
+	}
+}
+
+// Ping checks that the metadata of the topics can be loaded from the brokers.
+func (c *Consumer) Ping(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.client.RefreshMetadata(c.topics...)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run consumes the topics until ctx is done, the sessions are restarted after the rebalances.
+// The records are handled with the contexts of the sessions, so they are cancelled with ctx.
+func (c *Consumer) Run(ctx context.Context) error {
 	for {
-		if err := c.consumerGroup.Consume(c.ctx, c.topics, c.handler); err != nil {
+		if err := c.consumerGroup.Consume(ctx, c.topics, c.handler); err != nil {
 			c.log.
 				WithError(err).
 				Error("cannot consume message")
 			return err
 		}
-		if c.ctx.Err() != nil {
+		if ctx.Err() != nil {
 			c.log.
-				WithError(c.ctx.Err()).
-				Error("got context error")
-			return c.ctx.Err()
+				WithError(ctx.Err()).
+				Info("consumer is stopped")
+			return nil
 		}
 	}
 }
 
 func (c *Consumer) Close() error {
-	return c.consumerGroup.Close()
+	return errors.Join(c.consumerGroup.Close(), c.client.Close())
 }
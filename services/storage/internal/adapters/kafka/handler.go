@@ -1,26 +1,65 @@
 package kafka
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/IBM/sarama"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 	"storage/internal/app"
 	"storage/internal/domain"
+	"storage/internal/logging"
+	"storage/internal/metrics"
+	"storage/internal/tracing"
+	"strconv"
 )
 
+// EventHeader is the header with the type of the event stored in the record,
+// records without it are treated as messages.
+const EventHeader = "event"
+
+const (
+	eventMessage  = "message"
+	eventReaction = "reaction"
+)
+
+var errMalformedRecord = errors.New("malformed record")
+
 type Handler struct {
-	app *app.App
-	log logrus.FieldLogger
+	app     *app.App
+	groupID string
+	log     logrus.FieldLogger
 }
 
 // Setup is run at the beginning of a new session, before ConsumeClaim
-func (h *Handler) Setup(sarama.ConsumerGroupSession) error {
-	// Mark the consumer as ready
+func (h *Handler) Setup(session sarama.ConsumerGroupSession) error {
+	partitions := 0
+	for _, claimed := range session.Claims() {
+		partitions += len(claimed)
+	}
+	metrics.Rebalances.WithLabelValues(metrics.StageSetup).Inc()
+	metrics.AssignedPartitions.Set(float64(partitions))
+	h.log.
+		WithField("claims", session.Claims()).
+		Info("consumer group session is set up")
 	return nil
 }
 
 // Cleanup is run at the end of a session, once all ConsumeClaim goroutines have exited
-func (h *Handler) Cleanup(sarama.ConsumerGroupSession) error {
+func (h *Handler) Cleanup(session sarama.ConsumerGroupSession) error {
+	// the partitions may be claimed by another consumer after the rebalance, so their lag is not reported here
+	for topic, partitions := range session.Claims() {
+		for _, partition := range partitions {
+			metrics.ConsumerLag.DeleteLabelValues(topic, strconv.Itoa(int(partition)))
+		}
+	}
+	metrics.Rebalances.WithLabelValues(metrics.StageCleanup).Inc()
+	metrics.AssignedPartitions.Set(0)
 	return nil
 }
 
@@ -33,32 +72,30 @@ func (h *Handler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama
 				return nil
 			}
 
-			h.log.
-				WithField("message", string(message.Value)).
-				Info("message claimed")
-			msg := &domain.Message{}
-			err := json.Unmarshal(message.Value, msg)
-			if err != nil {
-				h.log.
-					WithError(err).
-					WithField("message.value", message.Value).
-					Errorf("cannot unmarshal message")
+			// the claimed record is saved and marked even if the session is done meanwhile,
+			// the saving is limited by the timeouts of the repositories
+			ctx := logging.WithFields(context.WithoutCancel(session.Context()), logrus.Fields{
+				"topic":     message.Topic,
+				"partition": message.Partition,
+				"offset":    message.Offset,
+			})
+			log := logging.FromContext(ctx, h.log)
+			log.WithField("data", message.Value).
+				Debug("message claimed")
+			// the high water mark is the offset of the next record produced to the partition
+			metrics.ConsumerLag.
+				WithLabelValues(message.Topic, strconv.Itoa(int(message.Partition))).
+				Set(float64(claim.HighWaterMarkOffset() - message.Offset - 1))
+
+			err := h.handle(ctx, message)
+			if errors.Is(err, errMalformedRecord) {
 				return err
 			}
-
-			h.log.Infoln("saving message")
-			err = h.app.SaveMessage(session.Context(), msg)
 			if err != nil {
-				h.log.
-					WithError(err).
-					WithField("message", msg).
-					Error("cannot save message")
 				return nil
 			}
 
-			h.log.
-				WithField("message", message).
-				Info("message successfully saved, marking message")
+			log.Info("message successfully saved, marking message")
 			session.MarkMessage(message, "")
 		case <-session.Context().Done():
 			h.log.Infoln("session is done")
@@ -67,3 +104,89 @@ func (h *Handler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama
 		}
 	}
 }
+
+// handle saves the event of the record in the span continuing the trace passed in the record headers.
+func (h *Handler) handle(ctx context.Context, message *sarama.ConsumerMessage) error {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, headersCarrier(message.Headers))
+	ctx, span := tracing.Tracer().Start(ctx, message.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypeDeliver,
+			semconv.MessagingDestinationName(message.Topic),
+			semconv.MessagingDestinationPartitionID(strconv.Itoa(int(message.Partition))),
+			semconv.MessagingKafkaMessageOffset(int(message.Offset)),
+			semconv.MessagingKafkaConsumerGroup(h.groupID),
+			attribute.String("storage.event", event(message)),
+		),
+	)
+	defer span.End()
+
+	var err error
+	switch event(message) {
+	case eventReaction:
+		err = h.saveReaction(ctx, message)
+	default:
+		err = h.saveMessage(ctx, message)
+	}
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (h *Handler) saveMessage(ctx context.Context, message *sarama.ConsumerMessage) error {
+	log := logging.FromContext(ctx, h.log)
+	msg := &domain.Message{}
+	err := json.Unmarshal(message.Value, msg)
+	if err != nil {
+		log.
+			WithError(err).
+			WithField("data", message.Value).
+			Errorf("cannot unmarshal message")
+		return fmt.Errorf("%w: %s", errMalformedRecord, err.Error())
+	}
+
+	log.Infoln("saving message")
+	err = h.app.SaveMessage(ctx, msg)
+	if err != nil {
+		log.
+			WithError(err).
+			WithField("message", msg).
+			Error("cannot save message")
+		return err
+	}
+	return nil
+}
+
+func (h *Handler) saveReaction(ctx context.Context, message *sarama.ConsumerMessage) error {
+	log := logging.FromContext(ctx, h.log)
+	reaction := &domain.Reaction{}
+	err := json.Unmarshal(message.Value, reaction)
+	if err != nil {
+		log.
+			WithError(err).
+			WithField("data", message.Value).
+			Errorf("cannot unmarshal reaction")
+		return fmt.Errorf("%w: %s", errMalformedRecord, err.Error())
+	}
+
+	log.Infoln("saving reaction")
+	err = h.app.SaveReaction(ctx, reaction)
+	if err != nil {
+		log.
+			WithError(err).
+			WithField("reaction", reaction).
+			Error("cannot save reaction")
+		return err
+	}
+	return nil
+}
+
+// event returns the type of the event stored in the record.
+func event(message *sarama.ConsumerMessage) string {
+	for _, header := range message.Headers {
+		if header != nil && string(header.Key) == EventHeader {
+			return string(header.Value)
+		}
+	}
+	return eventMessage
+}
@@ -0,0 +1,30 @@
+package kafka
+
+import (
+	"github.com/IBM/sarama"
+)
+
+// headersCarrier reads the trace context from the headers of the consumed record.
+type headersCarrier []*sarama.RecordHeader
+
+func (c headersCarrier) Get(key string) string {
+	for _, h := range c {
+		if h != nil && string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Set is not used, the headers of the consumed records are not modified.
+func (c headersCarrier) Set(string, string) {}
+
+func (c headersCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for _, h := range c {
+		if h != nil {
+			keys = append(keys, string(h.Key))
+		}
+	}
+	return keys
+}
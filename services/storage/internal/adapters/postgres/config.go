@@ -3,9 +3,12 @@ package postgres
 import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
+	"time"
 )
 
 type Config struct {
-	Pool   *pgxpool.Pool
-	Logger logrus.FieldLogger
+	Pool *pgxpool.Pool
+	// Timeout limits the time of saving one event, 0 disables the limit.
+	Timeout time.Duration
+	Logger  logrus.FieldLogger
 }
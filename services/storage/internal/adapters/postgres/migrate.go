@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// migrationFiles are the numbered schema changes applied on top of the bootstrap script
+// of the database (migrations/setup_table.sql), so the existing databases get them too.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationNameRegexp = regexp.MustCompile(`^(\d{4})_[a-z0-9_]+\.sql$`)
+
+// migrationLockID is the key of the advisory lock, so the replicas started at the same time
+// apply every migration once.
+const migrationLockID = 6_548_221_337
+
+const (
+	createMigrationsTableQuery = `CREATE TABLE IF NOT EXISTS schema_migrations (
+  version INTEGER PRIMARY KEY,
+  name TEXT NOT NULL,
+  applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);`
+	lockMigrationsQuery   = `SELECT pg_advisory_xact_lock($1);`
+	migrationAppliedQuery = `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1);`
+	saveMigrationQuery    = `INSERT INTO schema_migrations (version, name) VALUES ($1, $2);`
+)
+
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// loadMigrations reads the migrations from the directory, the versions must go one after another from 1.
+func loadMigrations(fsys fs.FS, dir string) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]migration, 0, len(entries))
+	for _, e := range entries {
+		match := migrationNameRegexp.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			return nil, fmt.Errorf("migration '%s' must be named like 0001_name.sql", e.Name())
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		version, _ := strconv.Atoi(match[1])
+		migrations = append(migrations, migration{version: version, name: e.Name(), sql: string(data)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	for i, m := range migrations {
+		if m.version != i+1 {
+			return nil, fmt.Errorf("migration '%s' must have version %04d", m.name, i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// Migrate applies the migrations that are not applied yet, every migration is applied in its own transaction.
+// The timeout of the repository is not applied, the migrations may rewrite the whole tables.
+func (r *Repository) Migrate(ctx context.Context) error {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		applied := true
+		err = pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, lockMigrationsQuery, migrationLockID)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(ctx, createMigrationsTableQuery)
+			if err != nil {
+				return err
+			}
+			err = tx.QueryRow(ctx, migrationAppliedQuery, m.version).Scan(&applied)
+			if err != nil || applied {
+				return err
+			}
+
+			_, err = tx.Exec(ctx, m.sql)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(ctx, saveMigrationQuery, m.version, m.name)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("cannot apply migration '%s': %w", m.name, err)
+		}
+		if !applied {
+			r.log.
+				WithField("migration", m.name).
+				Info("migration is applied")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	type testcase struct {
+		name     string
+		files    fstest.MapFS
+		versions []int
+		err      bool
+	}
+
+	tests := []testcase{
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"migrations/0002_replies.sql": {Data: []byte("ALTER TABLE messages ADD COLUMN reply_to BIGINT;")},
+				"migrations/0001_rooms.sql":   {Data: []byte("ALTER TABLE messages ADD COLUMN room TEXT;")},
+			},
+			versions: []int{1, 2},
+		},
+		{
+			name: "gap",
+			files: fstest.MapFS{
+				"migrations/0001_rooms.sql":   {Data: []byte("SELECT 1;")},
+				"migrations/0003_replies.sql": {Data: []byte("SELECT 1;")},
+			},
+			err: true,
+		},
+		{
+			name: "duplicate version",
+			files: fstest.MapFS{
+				"migrations/0001_rooms.sql":   {Data: []byte("SELECT 1;")},
+				"migrations/0001_replies.sql": {Data: []byte("SELECT 1;")},
+			},
+			err: true,
+		},
+		{
+			name: "invalid name",
+			files: fstest.MapFS{
+				"migrations/rooms.sql": {Data: []byte("SELECT 1;")},
+			},
+			err: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			migrations, err := loadMigrations(test.files, "migrations")
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			versions := make([]int, 0, len(migrations))
+			for _, m := range migrations {
+				versions = append(versions, m.version)
+				assert.NotEmpty(t, m.sql, m.name)
+			}
+			assert.Equal(t, test.versions, versions)
+		})
+	}
+}
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	require.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+}
@@ -0,0 +1,4 @@
+-- the messages of the databases created before the rooms are in the general room
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS room CHARACTER VARYING(64) NOT NULL DEFAULT 'general';
+
+CREATE INDEX IF NOT EXISTS messages_room_id_idx ON messages (room, id);
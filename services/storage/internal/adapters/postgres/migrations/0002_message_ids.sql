@@ -0,0 +1,20 @@
+-- the chat service generates the Snowflake IDs of the messages, the serial IDs of the existing messages
+-- are smaller than any of them, so the order of the messages is kept
+ALTER TABLE messages ALTER COLUMN id DROP DEFAULT;
+DROP SEQUENCE IF EXISTS messages_id_seq;
+
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = 'messages'::regclass AND contype = 'p') THEN
+    ALTER TABLE messages ADD PRIMARY KEY (id);
+  END IF;
+END
+$$;
+
+CREATE TABLE IF NOT EXISTS read_markers (
+  username CHARACTER VARYING(128) NOT NULL,
+  room CHARACTER VARYING(64) NOT NULL,
+  message_id BIGINT NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (username, room)
+);
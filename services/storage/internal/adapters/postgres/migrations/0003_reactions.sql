@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS message_reactions (
+  message_id BIGINT NOT NULL,
+  emoji CHARACTER VARYING(32) NOT NULL,
+  count INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY (message_id, emoji)
+);
+
+CREATE TABLE IF NOT EXISTS message_reaction_users (
+  message_id BIGINT NOT NULL,
+  emoji CHARACTER VARYING(32) NOT NULL,
+  username CHARACTER VARYING(128) NOT NULL,
+  PRIMARY KEY (message_id, emoji, username)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS reply_to BIGINT;
+
+CREATE INDEX IF NOT EXISTS messages_reply_to_idx ON messages (reply_to) WHERE reply_to IS NOT NULL;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS mentions (
+  message_id BIGINT NOT NULL,
+  username CHARACTER VARYING(128) NOT NULL,
+  PRIMARY KEY (message_id, username)
+);
+
+CREATE INDEX IF NOT EXISTS mentions_username_idx ON mentions (username, message_id);
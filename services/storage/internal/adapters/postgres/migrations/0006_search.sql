@@ -0,0 +1,6 @@
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS search TSVECTOR;
+
+-- the messages saved before the search are indexed too
+UPDATE messages SET search = to_tsvector('simple', data) WHERE search IS NULL;
+
+CREATE INDEX IF NOT EXISTS messages_search_idx ON messages USING GIN (search);
@@ -0,0 +1,26 @@
+-- users without a role are members, token_hash is the hex-encoded SHA-256 hash of the user's token
+CREATE TABLE IF NOT EXISTS user_roles (
+  username CHARACTER VARYING(128) PRIMARY KEY,
+  role CHARACTER VARYING(16) NOT NULL CHECK (role IN ('moderator', 'admin')),
+  token_hash CHARACTER(64) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS bans (
+  username CHARACTER VARYING(128) PRIMARY KEY,
+  reason TEXT NOT NULL DEFAULT '',
+  banned_by CHARACTER VARYING(128) NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS moderation_log (
+  id BIGSERIAL PRIMARY KEY,
+  moderator CHARACTER VARYING(128) NOT NULL,
+  action CHARACTER VARYING(16) NOT NULL,
+  target CHARACTER VARYING(128) NOT NULL,
+  room CHARACTER VARYING(64) NOT NULL,
+  duration INTERVAL NOT NULL DEFAULT '0',
+  reason TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS moderation_log_target_idx ON moderation_log (target, created_at);
@@ -2,38 +2,155 @@ package postgres
 
 import (
 	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
 	"storage/internal/domain"
+	"storage/internal/metrics"
+	"time"
 )
 
 type Repository struct {
-	pool *pgxpool.Pool
-	log  logrus.FieldLogger
+	pool    *pgxpool.Pool
+	timeout time.Duration
+	log     logrus.FieldLogger
 }
 
 func NewRepository(conf *Config) *Repository {
 	return &Repository{
-		pool: conf.Pool,
-		log:  conf.Logger,
+		pool:    conf.Pool,
+		timeout: conf.Timeout,
+		log:     conf.Logger,
 	}
 }
 
-const saveMessageQuery = `INSERT INTO messages (username, data) VALUES ($1, $2);`
+// withTimeout limits the transaction of the event by the timeout of the repository.
+func (r *Repository) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.timeout)
+}
 
+// Ping checks that a connection to the database can be acquired.
+func (r *Repository) Ping(ctx context.Context) error {
+	return r.pool.Ping(ctx)
+}
+
+// Close closes the connections of the pool, waiting for the acquired ones to be released.
+func (r *Repository) Close() {
+	r.pool.Close()
+}
+
+// saveMessageQuery also fills the full-text search vector of the message.
+const saveMessageQuery = `INSERT INTO messages (id, username, data, room, reply_to, search)
+VALUES ($1, $2, $3, $4, NULLIF($5, 0), to_tsvector('simple', $3))
+ON CONFLICT (id) DO NOTHING;`
+
+const saveMentionQuery = `INSERT INTO mentions (message_id, username) VALUES ($1, $2)
+ON CONFLICT DO NOTHING;`
+
+// SaveMessage saves the message along with its mentions.
 func (r *Repository) SaveMessage(ctx context.Context, message *domain.Message) error {
 	r.log.
 		WithField("message", message).
 		Info("got message")
-	_, err := r.pool.Exec(ctx, saveMessageQuery, message.Username, message.Text)
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	start := time.Now()
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, saveMessageQuery, message.ID, message.Username, message.Text, message.Room, message.ReplyTo)
+		if err != nil {
+			return err
+		}
+		for _, username := range message.Mentions {
+			_, err = tx.Exec(ctx, saveMentionQuery, message.ID, username)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	metrics.PostgresInsertDuration.WithLabelValues(metrics.EventMessage).Observe(time.Since(start).Seconds())
 	if err != nil {
 		r.log.
 			WithError(err).
 			Error("cannot save message")
 		return err
 	}
+	metrics.EventsPersisted.WithLabelValues(metrics.EventMessage).Inc()
 	r.log.
 		WithField("message", message).
 		Info("successfully save message")
 	return nil
 }
+
+const (
+	addReactionUserQuery = `INSERT INTO message_reaction_users (message_id, emoji, username) VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;`
+	incrementReactionQuery = `INSERT INTO message_reactions (message_id, emoji, count) VALUES ($1, $2, 1)
+ON CONFLICT (message_id, emoji) DO UPDATE SET count = message_reactions.count + 1;`
+
+	removeReactionUserQuery = `DELETE FROM message_reaction_users
+WHERE message_id = $1 AND emoji = $2 AND username = $3;`
+	decrementReactionQuery = `UPDATE message_reactions SET count = count - 1
+WHERE message_id = $1 AND emoji = $2;`
+	deleteEmptyReactionQuery = `DELETE FROM message_reactions
+WHERE message_id = $1 AND emoji = $2 AND count <= 0;`
+)
+
+// SaveReaction applies the reaction to the aggregated counts. Every user is counted
+// once per emoji, so repeated reactions don't change the counts.
+func (r *Repository) SaveReaction(ctx context.Context, reaction *domain.Reaction) error {
+	r.log.
+		WithField("reaction", reaction).
+		Info("got reaction")
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	start := time.Now()
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		switch reaction.Action {
+		case domain.ReactionAdd:
+			return r.applyReaction(ctx, tx, reaction, addReactionUserQuery, incrementReactionQuery)
+		case domain.ReactionRemove:
+			return r.applyReaction(ctx, tx, reaction, removeReactionUserQuery, decrementReactionQuery, deleteEmptyReactionQuery)
+		default:
+			return fmt.Errorf("unknown reaction action '%s'", reaction.Action)
+		}
+	})
+	metrics.PostgresInsertDuration.WithLabelValues(metrics.EventReaction).Observe(time.Since(start).Seconds())
+	if err != nil {
+		r.log.
+			WithError(err).
+			Error("cannot save reaction")
+		return err
+	}
+	metrics.EventsPersisted.WithLabelValues(metrics.EventReaction).Inc()
+
+	r.log.
+		WithField("reaction", reaction).
+		Info("successfully save reaction")
+	return nil
+}
+
+// applyReaction runs the user query and, if it changed the user's reactions, the count queries.
+func (r *Repository) applyReaction(ctx context.Context, tx pgx.Tx, reaction *domain.Reaction, userQuery string, countQueries ...string) error {
+	tag, err := tx.Exec(ctx, userQuery, reaction.MessageID, reaction.Emoji, reaction.Username)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+
+	for _, q := range countQueries {
+		_, err = tx.Exec(ctx, q, reaction.MessageID, reaction.Emoji)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
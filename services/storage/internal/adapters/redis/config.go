@@ -3,10 +3,13 @@ package redis
 import (
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
+	"time"
 )
 
 type Config struct {
-	Opt    *redis.Options
-	Key    string
-	Logger logrus.FieldLogger
+	Opt *redis.Options
+	Key string
+	// Timeout limits the time of caching one message, 0 disables the limit.
+	Timeout time.Duration
+	Logger  logrus.FieldLogger
 }
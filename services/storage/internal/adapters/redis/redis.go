@@ -3,25 +3,42 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 	"storage/internal/domain"
+	"storage/internal/metrics"
+	"storage/internal/tracing"
+	"time"
 )
 
 type Repository struct {
-	c   *redis.Client
-	key string
-	log logrus.FieldLogger
+	c       *redis.Client
+	key     string
+	timeout time.Duration
+	log     logrus.FieldLogger
 }
 
 func NewRepository(cfg *Config) *Repository {
+	c := redis.NewClient(cfg.Opt)
+	c.AddHook(tracing.RedisHook{})
 	return &Repository{
-		c:   redis.NewClient(cfg.Opt),
-		key: cfg.Key,
-		log: cfg.Logger,
+		c:       c,
+		key:     cfg.Key,
+		timeout: cfg.Timeout,
+		log:     cfg.Logger,
 	}
 }
 
+// Ping checks that the server responds.
+func (r *Repository) Ping(ctx context.Context) error {
+	return r.c.Ping(ctx).Err()
+}
+
+func (r *Repository) Close() error {
+	return r.c.Close()
+}
+
 func (r *Repository) SaveMessage(ctx context.Context, message *domain.Message) error {
 	r.log.
 		WithField("message", message).
@@ -37,6 +54,25 @@ func (r *Repository) SaveMessage(ctx context.Context, message *domain.Message) e
 	r.log.
 		WithField("message", message).
 		Info("saving message")
-	r.c.LPush(ctx, r.key, string(data))
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	err = r.c.LPush(ctx, r.roomKey(message.Room), string(data)).Err()
+	if err != nil {
+		metrics.RedisPushFailures.Inc()
+		return err
+	}
 	return nil
 }
+
+// withTimeout limits the command by the timeout of the repository.
+func (r *Repository) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+// roomKey returns the key of the list with cached messages of the room.
+func (r *Repository) roomKey(room string) string {
+	return fmt.Sprintf("%s:%s", r.key, room)
+}
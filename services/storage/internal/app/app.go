@@ -7,6 +7,7 @@ import (
 
 type MessageSaver interface {
 	SaveMessage(ctx context.Context, msg *domain.Message) error
+	SaveReaction(ctx context.Context, reaction *domain.Reaction) error
 }
 
 type App struct {
@@ -18,5 +19,12 @@ func NewApp(repository MessageSaver) *App {
 }
 
 func (a *App) SaveMessage(ctx context.Context, msg *domain.Message) error {
+	if msg.Room == "" {
+		msg.Room = domain.DefaultRoom
+	}
 	return a.repository.SaveMessage(ctx, msg)
 }
+
+func (a *App) SaveReaction(ctx context.Context, reaction *domain.Reaction) error {
+	return a.repository.SaveReaction(ctx, reaction)
+}
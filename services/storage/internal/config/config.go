@@ -6,12 +6,18 @@ import (
 	"storage/internal/adapters/kafka"
 	"storage/internal/adapters/postgres"
 	rds "storage/internal/adapters/redis"
+	"storage/internal/logging"
+	"storage/internal/metrics"
+	"storage/internal/tracing"
 )
 
 type Config struct {
 	Postgres *postgres.Config
 	Kafka    *kafka.Config
 	Redis    *rds.Config
+	Metrics  *metrics.Config
+	Tracing  *tracing.Config
+	Logging  *logging.Config
 }
 
 func Get(logger *logrus.Logger, envFile string) (*Config, error) {
@@ -36,10 +42,28 @@ func Get(logger *logrus.Logger, envFile string) (*Config, error) {
 		return nil, err
 	}
 
+	metricsConfig, err := getMetricsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	tracingConfig, err := getTracingConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	loggingConfig, err := getLoggingConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	config := &Config{
 		Postgres: postgresConfig,
 		Kafka:    kafkaConfig,
 		Redis:    redisConfig,
+		Metrics:  metricsConfig,
+		Tracing:  tracingConfig,
+		Logging:  loggingConfig,
 	}
 	return config, nil
 }
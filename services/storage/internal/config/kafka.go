@@ -5,13 +5,17 @@ import (
 	"github.com/sirupsen/logrus"
 	"os"
 	"storage/internal/adapters/kafka"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Kafka struct {
-	Brokers string
-	Topics  string
-	GroupID string
+	Brokers        string
+	Topics         string
+	GroupID        string
+	ConnectRetries int
+	ConnectBackoff time.Duration
 }
 
 func getKafkaConfig(logger logrus.FieldLogger) (*kafka.Config, error) {
@@ -23,7 +27,10 @@ func getKafkaConfig(logger logrus.FieldLogger) (*kafka.Config, error) {
 		Brokers: strings.Split(k.Brokers, ","),
 		Topics:  strings.Split(k.Topics, ","),
 		GroupID: k.GroupID,
-		Logger:  logger.WithField("FROM", "[KAFKA-CONSUMER]"),
+
+		ConnectRetries: k.ConnectRetries,
+		ConnectBackoff: k.ConnectBackoff,
+		Logger:         logger.WithField("FROM", "[KAFKA-CONSUMER]"),
 	}
 	return kafkaConfig, nil
 }
@@ -42,9 +49,29 @@ func loadEnvKafkaConfig() (Kafka, error) {
 		return Kafka{}, fmt.Errorf("KAFKA_GROUP_ID environment variable not set")
 	}
 
+	value, ok := os.LookupEnv("KAFKA_CONNECT_RETRIES")
+	if !ok {
+		return Kafka{}, fmt.Errorf("KAFKA_CONNECT_RETRIES environment variable not set")
+	}
+	retries, err := strconv.Atoi(value)
+	if err != nil || retries < 0 {
+		return Kafka{}, fmt.Errorf("KAFKA_CONNECT_RETRIES environment variable must be non-negative integer")
+	}
+
+	value, ok = os.LookupEnv("KAFKA_CONNECT_BACKOFF")
+	if !ok {
+		return Kafka{}, fmt.Errorf("KAFKA_CONNECT_BACKOFF environment variable not set")
+	}
+	backoff, err := time.ParseDuration(value)
+	if err != nil || backoff <= 0 {
+		return Kafka{}, fmt.Errorf("KAFKA_CONNECT_BACKOFF environment variable must be positive duration")
+	}
+
 	return Kafka{
-		Brokers: brokers,
-		Topics:  topics,
-		GroupID: groupID,
+		Brokers:        brokers,
+		Topics:         topics,
+		GroupID:        groupID,
+		ConnectRetries: retries,
+		ConnectBackoff: backoff,
 	}, nil
 }
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"os"
+	"storage/internal/logging"
+	"strconv"
+)
+
+type Logging struct {
+	Format             string
+	Level              logrus.Level
+	SamplingInitial    int
+	SamplingThereafter int
+	LogContent         bool
+}
+
+func getLoggingConfig() (*logging.Config, error) {
+	l, err := loadEnvLoggingConfig()
+	if err != nil {
+		return nil, err
+	}
+	loggingConfig := &logging.Config{
+		Format:             l.Format,
+		Level:              l.Level,
+		SamplingInitial:    l.SamplingInitial,
+		SamplingThereafter: l.SamplingThereafter,
+		LogContent:         l.LogContent,
+	}
+	return loggingConfig, nil
+}
+
+func loadEnvLoggingConfig() (Logging, error) {
+	format, ok := os.LookupEnv("LOG_FORMAT")
+	if !ok {
+		return Logging{}, fmt.Errorf("LOG_FORMAT environment variable not set")
+	}
+	if format != logging.FormatText && format != logging.FormatJSON {
+		return Logging{}, fmt.Errorf("LOG_FORMAT environment variable must be %s or %s", logging.FormatText, logging.FormatJSON)
+	}
+
+	value, ok := os.LookupEnv("LOG_LEVEL")
+	if !ok {
+		return Logging{}, fmt.Errorf("LOG_LEVEL environment variable not set")
+	}
+	level, err := logrus.ParseLevel(value)
+	if err != nil {
+		return Logging{}, fmt.Errorf("LOG_LEVEL environment variable must be log level: %w", err)
+	}
+
+	value, ok = os.LookupEnv("LOG_SAMPLING_INITIAL")
+	if !ok {
+		return Logging{}, fmt.Errorf("LOG_SAMPLING_INITIAL environment variable not set")
+	}
+	initial, err := strconv.Atoi(value)
+	if err != nil || initial < 0 {
+		return Logging{}, fmt.Errorf("LOG_SAMPLING_INITIAL environment variable must be non-negative integer")
+	}
+
+	value, ok = os.LookupEnv("LOG_SAMPLING_THEREAFTER")
+	if !ok {
+		return Logging{}, fmt.Errorf("LOG_SAMPLING_THEREAFTER environment variable not set")
+	}
+	thereafter, err := strconv.Atoi(value)
+	if err != nil || thereafter < 0 {
+		return Logging{}, fmt.Errorf("LOG_SAMPLING_THEREAFTER environment variable must be non-negative integer")
+	}
+
+	value, ok = os.LookupEnv("LOG_MESSAGE_CONTENT")
+	if !ok {
+		return Logging{}, fmt.Errorf("LOG_MESSAGE_CONTENT environment variable not set")
+	}
+	logContent, err := strconv.ParseBool(value)
+	if err != nil {
+		return Logging{}, fmt.Errorf("LOG_MESSAGE_CONTENT environment variable must be boolean")
+	}
+
+	return Logging{
+		Format:             format,
+		Level:              level,
+		SamplingInitial:    initial,
+		SamplingThereafter: thereafter,
+		LogContent:         logContent,
+	}, nil
+}
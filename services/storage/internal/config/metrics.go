@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"storage/internal/metrics"
+)
+
+type Metrics struct {
+	Port string
+}
+
+func getMetricsConfig() (*metrics.Config, error) {
+	m, err := loadEnvMetricsConfig()
+	if err != nil {
+		return nil, err
+	}
+	metricsConfig := &metrics.Config{
+		Port: m.Port,
+	}
+	return metricsConfig, nil
+}
+
+func loadEnvMetricsConfig() (Metrics, error) {
+	port, ok := os.LookupEnv("METRICS_PORT")
+	if !ok {
+		return Metrics{}, fmt.Errorf("METRICS_PORT environment variable not set")
+	}
+
+	return Metrics{
+		Port: port,
+	}, nil
+}
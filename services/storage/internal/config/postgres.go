@@ -9,6 +9,8 @@ import (
 	"github.com/sirupsen/logrus"
 	"os"
 	"storage/internal/adapters/postgres"
+	"storage/internal/tracing"
+	"time"
 )
 
 type Postgres struct {
@@ -17,6 +19,7 @@ type Postgres struct {
 	Host     string
 	Port     string
 	Database string
+	Timeout  time.Duration
 }
 
 func getPostgresConfig(logger logrus.FieldLogger) (*postgres.Config, error) {
@@ -40,9 +43,11 @@ func getPostgresConfig(logger logrus.FieldLogger) (*postgres.Config, error) {
 			Error("cannot parse postgres config")
 		return nil, err
 	}
-	pgxConfig.ConnConfig.Tracer = &tracelog.TraceLog{
-		Logger:   pgxLogrus.NewLogger(logger.WithField("FROM", "[PGX-POOL]")),
-		LogLevel: tracelog.LogLevelDebug,
+	pgxConfig.ConnConfig.Tracer = &tracing.QueryTracer{
+		Next: &tracelog.TraceLog{
+			Logger:   pgxLogrus.NewLogger(logger.WithField("FROM", "[PGX-POOL]")),
+			LogLevel: tracelog.LogLevelDebug,
+		},
 	}
 
 	pool, err := pgxpool.NewWithConfig(context.Background(), pgxConfig)
@@ -53,8 +58,9 @@ func getPostgresConfig(logger logrus.FieldLogger) (*postgres.Config, error) {
 		return nil, err
 	}
 	postgresConfig := &postgres.Config{
-		Pool:   pool,
-		Logger: logger.WithField("FROM", "[POSTGRES]"),
+		Pool:    pool,
+		Timeout: config.Timeout,
+		Logger:  logger.WithField("FROM", "[POSTGRES]"),
 	}
 	return postgresConfig, nil
 }
@@ -80,11 +86,20 @@ func loadEnvPostgresConfig() (Postgres, error) {
 	if !ok {
 		return Postgres{}, fmt.Errorf("POSTGRES_DB environment variable not set")
 	}
+	value, ok := os.LookupEnv("POSTGRES_TIMEOUT")
+	if !ok {
+		return Postgres{}, fmt.Errorf("POSTGRES_TIMEOUT environment variable not set")
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		return Postgres{}, fmt.Errorf("POSTGRES_TIMEOUT environment variable must be non-negative duration")
+	}
 	return Postgres{
 		Username: username,
 		Password: password,
 		Host:     host,
 		Port:     port,
 		Database: database,
+		Timeout:  timeout,
 	}, nil
 }
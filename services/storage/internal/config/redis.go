@@ -7,13 +7,15 @@ import (
 	"os"
 	rds "storage/internal/adapters/redis"
 	"strconv"
+	"time"
 )
 
 type Redis struct {
-	Host string
-	Port string
-	Key  string
-	DB   int
+	Host    string
+	Port    string
+	Key     string
+	DB      int
+	Timeout time.Duration
 }
 
 func getRedisConfig(logger logrus.FieldLogger) (*rds.Config, error) {
@@ -26,8 +28,9 @@ func getRedisConfig(logger logrus.FieldLogger) (*rds.Config, error) {
 			Addr: fmt.Sprintf("%s:%s", r.Host, r.Port),
 			DB:   r.DB,
 		},
-		Key:    r.Key,
-		Logger: logger.WithField("FROM", "[REDIS]"),
+		Key:     r.Key,
+		Timeout: r.Timeout,
+		Logger:  logger.WithField("FROM", "[REDIS]"),
 	}
 	return redisConfig, nil
 }
@@ -53,10 +56,19 @@ func loadEnvRedisConfig() (Redis, error) {
 	if !ok {
 		return Redis{}, fmt.Errorf("REDIS_KEY environment variable not set")
 	}
+	value, ok := os.LookupEnv("REDIS_TIMEOUT")
+	if !ok {
+		return Redis{}, fmt.Errorf("REDIS_TIMEOUT environment variable not set")
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		return Redis{}, fmt.Errorf("REDIS_TIMEOUT environment variable must be non-negative duration")
+	}
 	return Redis{
-		Host: host,
-		Port: port,
-		Key:  key,
-		DB:   db,
+		Host:    host,
+		Port:    port,
+		Key:     key,
+		DB:      db,
+		Timeout: timeout,
 	}, nil
 }
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"storage/internal/tracing"
+	"strconv"
+)
+
+// serviceName is the name of the service in the exported spans.
+const serviceName = "storage"
+
+type Tracing struct {
+	Exporter    string
+	Endpoint    string
+	SampleRatio float64
+}
+
+func getTracingConfig() (*tracing.Config, error) {
+	t, err := loadEnvTracingConfig()
+	if err != nil {
+		return nil, err
+	}
+	tracingConfig := &tracing.Config{
+		Exporter:    t.Exporter,
+		Endpoint:    t.Endpoint,
+		ServiceName: serviceName,
+		SampleRatio: t.SampleRatio,
+	}
+	return tracingConfig, nil
+}
+
+func loadEnvTracingConfig() (Tracing, error) {
+	exporter, ok := os.LookupEnv("TRACING_EXPORTER")
+	if !ok {
+		return Tracing{}, fmt.Errorf("TRACING_EXPORTER environment variable not set")
+	}
+	switch exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	default:
+		return Tracing{}, fmt.Errorf("TRACING_EXPORTER environment variable must be one of %s, %s or %s",
+			tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP)
+	}
+
+	endpoint, ok := os.LookupEnv("TRACING_ENDPOINT")
+	if !ok && exporter == tracing.ExporterOTLP {
+		return Tracing{}, fmt.Errorf("TRACING_ENDPOINT environment variable not set")
+	}
+
+	value, ok := os.LookupEnv("TRACING_SAMPLE_RATIO")
+	if !ok {
+		return Tracing{}, fmt.Errorf("TRACING_SAMPLE_RATIO environment variable not set")
+	}
+	ratio, err := strconv.ParseFloat(value, 64)
+	if err != nil || ratio < 0 || ratio > 1 {
+		return Tracing{}, fmt.Errorf("TRACING_SAMPLE_RATIO environment variable must be number between 0 and 1")
+	}
+
+	return Tracing{
+		Exporter:    exporter,
+		Endpoint:    endpoint,
+		SampleRatio: ratio,
+	}, nil
+}
@@ -1,6 +1,21 @@
 package domain
 
+import "fmt"
+
+// DefaultRoom is used for messages produced without a room.
+const DefaultRoom = "general"
+
 type Message struct {
-	Username string `json:"username" required:"true"`
-	Text     string `json:"message" required:"true"`
+	ID       int64    `json:"id"`
+	Username string   `json:"username" required:"true"`
+	Text     string   `json:"message" required:"true"`
+	Room     string   `json:"room,omitempty"`
+	ReplyTo  int64    `json:"reply_to,omitempty"`
+	Mentions []string `json:"mentions,omitempty"`
+}
+
+// Redacted returns the message without the text, it is logged unless the content logging is enabled.
+func (m Message) Redacted() any {
+	m.Text = fmt.Sprintf("[redacted %d bytes]", len(m.Text))
+	return m
 }
@@ -0,0 +1,127 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"storage/internal/domain"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLogger(cfg *Config) (*logrus.Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	Configure(logger, cfg)
+	return logger, buf
+}
+
+func TestConfigure_Redaction(t *testing.T) {
+	type testcase struct {
+		name       string
+		logContent bool
+		field      string
+		value      any
+		expected   any
+	}
+
+	tests := []testcase{
+		{name: "record value", field: "data", value: []byte(`{"message": "secret"}`), expected: "[redacted 21 bytes]"},
+		{name: "query args", field: "args", value: []any{"danil", "secret"}, expected: "[redacted 2 args]"},
+		{
+			name:     "message",
+			field:    "message",
+			value:    domain.Message{ID: 42, Username: "danil", Text: "secret"},
+			expected: map[string]any{"id": float64(42), "username": "danil", "message": "[redacted 6 bytes]"},
+		},
+		{name: "other field", field: "topic", value: "messages", expected: "messages"},
+		{name: "content logging", logContent: true, field: "data", value: "secret", expected: "secret"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logger, buf := newTestLogger(&Config{Format: FormatJSON, Level: logrus.InfoLevel, LogContent: test.logContent})
+			logger.WithField(test.field, test.value).Info("test")
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Equal(t, test.expected, entry[test.field])
+			if !test.logContent {
+				assert.NotContains(t, buf.String(), "secret")
+			}
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	logger, buf := newTestLogger(&Config{Format: FormatJSON, Level: logrus.InfoLevel})
+
+	ctx := WithFields(context.Background(), logrus.Fields{"topic": "messages", "partition": 0})
+	ctx = WithFields(ctx, logrus.Fields{"partition": 1, "offset": 42})
+	FromContext(ctx, logger.WithField("FROM", "[TEST]")).Info("test")
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "messages", entry["topic"])
+	assert.Equal(t, float64(1), entry["partition"])
+	assert.Equal(t, float64(42), entry["offset"])
+	assert.Equal(t, "[TEST]", entry["FROM"])
+}
+
+func TestConfigure_SpanIDs(t *testing.T) {
+	logger, buf := newTestLogger(&Config{Format: FormatJSON, Level: logrus.InfoLevel})
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	logger.WithContext(ctx).Info("test")
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entry["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", entry["span_id"])
+}
+
+func TestSamplingFormatter(t *testing.T) {
+	type testcase struct {
+		name       string
+		initial    int
+		thereafter int
+		level      logrus.Level
+		entries    int
+		expected   int
+	}
+
+	tests := []testcase{
+		{name: "first entries", initial: 3, thereafter: 0, level: logrus.InfoLevel, entries: 10, expected: 3},
+		{name: "every n-th entry", initial: 2, thereafter: 3, level: logrus.InfoLevel, entries: 11, expected: 5},
+		{name: "errors are not sampled", initial: 1, thereafter: 0, level: logrus.ErrorLevel, entries: 10, expected: 10},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logger, buf := newTestLogger(&Config{
+				Format:             FormatText,
+				Level:              logrus.InfoLevel,
+				SamplingInitial:    test.initial,
+				SamplingThereafter: test.thereafter,
+			})
+			// the counters are not reset during the test
+			now := time.Now()
+			logger.Formatter.(*samplingFormatter).now = func() time.Time { return now }
+
+			for range test.entries {
+				logger.Log(test.level, "test")
+			}
+			assert.Equal(t, test.expected, strings.Count(buf.String(), "\n"))
+		})
+	}
+}
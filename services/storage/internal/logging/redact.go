@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"reflect"
+)
+
+// redacter is a logged value with the user content, e.g. domain.Message, Redacted returns the value without it.
+type redacter interface {
+	Redacted() any
+}
+
+// contentFields are the fields with the values of the kafka records, the messages
+// and the arguments of the postgres queries logged by pgx.
+var contentFields = map[string]bool{
+	"data":    true,
+	"message": true,
+	"args":    true,
+}
+
+// redactHook replaces the user content in the entry fields.
+type redactHook struct{}
+
+func (redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (redactHook) Fire(entry *logrus.Entry) error {
+	for k, v := range entry.Data {
+		if r, ok := v.(redacter); ok {
+			if rv := reflect.ValueOf(v); rv.Kind() != reflect.Pointer || !rv.IsNil() {
+				entry.Data[k] = r.Redacted()
+			}
+			continue
+		}
+		if !contentFields[k] {
+			continue
+		}
+		switch v := v.(type) {
+		case string:
+			entry.Data[k] = redacted(len(v))
+		case []byte:
+			entry.Data[k] = redacted(len(v))
+		case []any:
+			entry.Data[k] = fmt.Sprintf("[redacted %d args]", len(v))
+		}
+	}
+	return nil
+}
+
+func redacted(size int) string {
+	return fmt.Sprintf("[redacted %d bytes]", size)
+}
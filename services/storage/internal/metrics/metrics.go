@@ -0,0 +1,61 @@
+// Package metrics contains the Prometheus metrics of the storage service,
+// they are exposed by the metrics server.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "storage"
+
+// Events persisted by the service.
+const (
+	EventMessage  = "message"
+	EventReaction = "reaction"
+)
+
+// Stages of the consumer group rebalance.
+const (
+	StageSetup   = "setup"
+	StageCleanup = "cleanup"
+)
+
+var (
+	ConsumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "consumer_lag",
+		Help:      "Number of the records in the partition not consumed yet.",
+	}, []string{"topic", "partition"})
+
+	AssignedPartitions = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "assigned_partitions",
+		Help:      "Number of the partitions claimed by the consumer in the current session.",
+	})
+
+	Rebalances = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rebalances_total",
+		Help:      "Number of the consumer group sessions set up and cleaned up.",
+	}, []string{"stage"})
+
+	EventsPersisted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_persisted_total",
+		Help:      "Number of the messages and reactions saved to Postgres.",
+	}, []string{"event"})
+
+	PostgresInsertDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "postgres_insert_duration_seconds",
+		Help:      "Time of saving an event to Postgres.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"event"})
+
+	RedisPushFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redis_push_failures_total",
+		Help:      "Number of the messages not cached in Redis.",
+	})
+)
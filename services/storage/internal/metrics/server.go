@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// readinessTimeout limits the checks of the dependencies in a readiness probe.
+const readinessTimeout = 2 * time.Second
+
+type Config struct {
+	Port string
+}
+
+// Check checks a dependency of the service, nil means the dependency is up.
+type Check func(ctx context.Context) error
+
+// health is the response of the probes, it has the same shape as in the chat service.
+type health struct {
+	Status string                      `json:"status"`
+	Checks map[string]dependencyStatus `json:"checks,omitempty"`
+}
+
+type dependencyStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Server serves the metrics on GET /metrics, the liveness probe on GET /healthz
+// and the readiness probe on GET /readyz.
+type Server struct {
+	srv    *http.Server
+	checks map[string]Check
+	ready  atomic.Bool
+	log    logrus.FieldLogger
+}
+
+// NewServer creates the server, the readiness probe runs the checks by the names of the dependencies.
+func NewServer(cfg *Config, checks map[string]Check, log logrus.FieldLogger) *Server {
+	s := &Server{
+		checks: checks,
+		log:    log,
+	}
+	s.ready.Store(true)
+
+	r := &http.ServeMux{}
+	r.Handle("GET /metrics", promhttp.Handler())
+	r.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		s.writeJSON(w, http.StatusOK, health{Status: "ok"})
+	})
+	r.HandleFunc("GET /readyz", s.readyz)
+
+	s.srv = &http.Server{
+		Addr:    fmt.Sprintf(":%s", cfg.Port),
+		Handler: r,
+	}
+	return s
+}
+
+func (s *Server) ListenAndServe() error {
+	return s.srv.ListenAndServe()
+}
+
+// SetShuttingDown fails the readiness probe until the server is shut down.
+func (s *Server) SetShuttingDown() {
+	s.ready.Store(false)
+}
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.SetShuttingDown()
+	return s.srv.Shutdown(ctx)
+}
+
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	if !s.ready.Load() {
+		s.writeJSON(w, http.StatusServiceUnavailable, health{Status: "shutting_down"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	var (
+		mx  sync.Mutex
+		wg  sync.WaitGroup
+		res = health{Status: "ready", Checks: make(map[string]dependencyStatus, len(s.checks))}
+	)
+	for name, check := range s.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := dependencyStatus{Status: "up"}
+			if err := check(ctx); err != nil {
+				s.log.WithError(err).WithField("dependency", name).Warn("the dependency is down")
+				status = dependencyStatus{Status: "down", Error: err.Error()}
+			}
+
+			mx.Lock()
+			defer mx.Unlock()
+			res.Checks[name] = status
+			if status.Status == "down" {
+				res.Status = "not_ready"
+			}
+		}()
+	}
+	wg.Wait()
+
+	code := http.StatusOK
+	if res.Status != "ready" {
+		code = http.StatusServiceUnavailable
+	}
+	s.writeJSON(w, code, res)
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		s.log.WithError(err).Error("cannot marshal data to json")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err = w.Write(data); err != nil {
+		s.log.WithError(err).Error("cannot write response")
+	}
+}
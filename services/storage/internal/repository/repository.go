@@ -6,12 +6,16 @@ import (
 	"storage/internal/adapters/postgres"
 	"storage/internal/adapters/redis"
 	"storage/internal/domain"
+	"storage/internal/logging"
+	"sync"
 )
 
 type Repository struct {
 	postgres *postgres.Repository
 	redis    *redis.Repository
-	log      logrus.FieldLogger
+	// caching tracks the messages cached in the background.
+	caching sync.WaitGroup
+	log     logrus.FieldLogger
 }
 
 func New(pgConf *postgres.Config, redisConf *redis.Config, log logrus.FieldLogger) *Repository {
@@ -22,25 +26,53 @@ func New(pgConf *postgres.Config, redisConf *redis.Config, log logrus.FieldLogge
 	}
 }
 
+// Migrate applies the schema migrations to postgres.
+func (r *Repository) Migrate(ctx context.Context) error {
+	return r.postgres.Migrate(ctx)
+}
+
+// PingPostgres checks that postgres is available.
+func (r *Repository) PingPostgres(ctx context.Context) error {
+	return r.postgres.Ping(ctx)
+}
+
+// PingRedis checks that redis is available.
+func (r *Repository) PingRedis(ctx context.Context) error {
+	return r.redis.Ping(ctx)
+}
+
+// Close waits for the messages cached in the background and closes the postgres pool and the redis client.
+func (r *Repository) Close() error {
+	r.caching.Wait()
+	r.postgres.Close()
+	return r.redis.Close()
+}
+
 func (r *Repository) SaveMessage(ctx context.Context, message *domain.Message) error {
-	r.log.
+	log := logging.FromContext(ctx, r.log)
+	log.
 		WithField("message", message).
 		Info("saving message to postgres")
 	err := r.postgres.SaveMessage(ctx, message)
 	if err != nil {
-		r.log.
+		log.
 			WithError(err).
 			WithField("message", message).
 			Error("cannot save message to postgres")
 		return err
 	}
+	// the message is cached in the background, so the context of the record
+	// may be done before it is cached, the cache has its own timeout
+	ctx = context.WithoutCancel(ctx)
+	r.caching.Add(1)
 	go func() {
-		r.log.
+		defer r.caching.Done()
+		log.
 			WithField("message", message).
 			Info("saving message to redis")
-		err = r.redis.SaveMessage(ctx, message)
+		err := r.redis.SaveMessage(ctx, message)
 		if err != nil {
-			r.log.
+			log.
 				WithError(err).
 				WithField("message", message).
 				Error("cannot save message to redis")
@@ -48,3 +80,20 @@ func (r *Repository) SaveMessage(ctx context.Context, message *domain.Message) e
 	}()
 	return nil
 }
+
+// SaveReaction updates reaction counts in postgres, reactions are not cached in redis.
+func (r *Repository) SaveReaction(ctx context.Context, reaction *domain.Reaction) error {
+	log := logging.FromContext(ctx, r.log)
+	log.
+		WithField("reaction", reaction).
+		Info("saving reaction to postgres")
+	err := r.postgres.SaveReaction(ctx, reaction)
+	if err != nil {
+		log.
+			WithError(err).
+			WithField("reaction", reaction).
+			Error("cannot save reaction to postgres")
+		return err
+	}
+	return nil
+}
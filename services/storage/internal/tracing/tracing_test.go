@@ -0,0 +1,149 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+// recordSpans sets the global provider recording the ended spans until the end of the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+		_ = provider.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+func TestNewProvider(t *testing.T) {
+	type testcase struct {
+		exporter string
+		valid    bool
+	}
+
+	tests := []testcase{
+		{exporter: ExporterNone, valid: true},
+		{exporter: ExporterStdout, valid: true},
+		{exporter: "jaeger", valid: false},
+	}
+
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	for _, test := range tests {
+		provider, err := NewProvider(&Config{Exporter: test.exporter, ServiceName: "storage", SampleRatio: 1})
+		if !test.valid {
+			assert.Error(t, err, test.exporter)
+			continue
+		}
+		require.NoError(t, err, test.exporter)
+		assert.Equal(t, provider, otel.GetTracerProvider())
+		assert.NoError(t, provider.Shutdown(context.Background()))
+	}
+}
+
+func TestOperationName(t *testing.T) {
+	type testcase struct {
+		sql      string
+		expected string
+	}
+
+	tests := []testcase{
+		{sql: "INSERT INTO messages (id) VALUES ($1)", expected: "INSERT"},
+		{sql: "\n  select * from messages", expected: "SELECT"},
+		{sql: "", expected: ""},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, operationName(test.sql), test.sql)
+	}
+}
+
+// nextTracer counts the calls passed to the next tracer.
+type nextTracer struct {
+	starts int
+	ends   int
+}
+
+func (t *nextTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	t.starts++
+	return ctx
+}
+
+func (t *nextTracer) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {
+	t.ends++
+}
+
+func TestQueryTracer(t *testing.T) {
+	type testcase struct {
+		err    error
+		status codes.Code
+	}
+
+	tests := []testcase{
+		{err: nil, status: codes.Unset},
+		{err: errors.New("connection refused"), status: codes.Error},
+	}
+
+	for _, test := range tests {
+		recorder := recordSpans(t)
+		next := &nextTracer{}
+		tracer := &QueryTracer{Next: next}
+
+		ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "insert into messages (id) values ($1)"})
+		tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: test.err})
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "postgres INSERT", spans[0].Name())
+		assert.Equal(t, test.status, spans[0].Status().Code)
+		assert.Equal(t, 1, next.starts)
+		assert.Equal(t, 1, next.ends)
+	}
+}
+
+func TestRedisHook(t *testing.T) {
+	type testcase struct {
+		name   string
+		err    error
+		status codes.Code
+	}
+
+	tests := []testcase{
+		{name: "reply", err: nil, status: codes.Unset},
+		{name: "nil reply", err: redis.Nil, status: codes.Unset},
+		{name: "error", err: errors.New("connection refused"), status: codes.Error},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := recordSpans(t)
+			hook := RedisHook{}
+			process := hook.ProcessHook(func(context.Context, redis.Cmder) error { return test.err })
+			pipeline := hook.ProcessPipelineHook(func(context.Context, []redis.Cmder) error { return test.err })
+
+			cmd := redis.NewStringCmd(context.Background(), "get", "key")
+			assert.Equal(t, test.err, process(context.Background(), cmd))
+			assert.Equal(t, test.err, pipeline(context.Background(), []redis.Cmder{cmd}))
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 2)
+			assert.Equal(t, "redis get", spans[0].Name())
+			assert.Equal(t, "redis pipeline", spans[1].Name())
+			for _, span := range spans {
+				assert.Equal(t, test.status, span.Status().Code)
+			}
+		})
+	}
+}